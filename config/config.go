@@ -4,13 +4,30 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"log"
+	"net/mail"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Rate is a number of requests allowed per window, written as
+// "<requests>/<window>", e.g. "20/15m". Zero requests means unlimited.
+type Rate struct {
+	Requests int
+	Window   time.Duration
+}
+
+func (r Rate) String() string {
+	if r.Requests <= 0 {
+		return "unlimited"
+	}
+	return strconv.Itoa(r.Requests) + "/" + r.Window.String()
+}
+
 type Config struct {
 	DatabaseURL   string
 	DBHost        string
@@ -20,11 +37,55 @@ type Config struct {
 	DBName        string
 	AppPort       string
 	EmailFrom     string
+	EmailFromName string
+	ProductName   string
+	DefaultLocale string
+	EmailBackend  string
 	ResendAPIKey  string
 	SessionSecret string
 	CSRFSecret    string
 	Environment   string
 	AppURL        string
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPSecurity string
+	MailboxDir   string
+
+	ItemsPageSize int
+
+	RetentionDays     int
+	RetentionMaxItems int
+	CleanupInterval   time.Duration
+
+	DigestCheckInterval time.Duration
+	AlertCheckInterval  time.Duration
+	WebhookInterval     time.Duration
+	VAPIDSubject        string
+
+	SessionMaxAge          time.Duration
+	SessionCleanupInterval time.Duration
+	OTPCleanupInterval     time.Duration
+	RateLimitBackend       string
+
+	TrustedProxies  []string
+	LoginThrottle   Rate
+	ImportThrottle  Rate
+	RefreshThrottle Rate
+	FeverThrottle   Rate
+
+	OTPLoginEnabled  bool
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCScopes       []string
+	OIDCProviderName string
+
+	OIDCTrustUnverifiedEmail bool
+
+	AdminEmails []string
 }
 
 func Load() *Config {
@@ -47,7 +108,7 @@ func Load() *Config {
 
 	appPort := getEnv("APP_PORT", "8080")
 	appURL := getEnv("APP_URL", "")
-	
+
 	if appURL == "" {
 		if environment == "production" {
 			log.Println("Warning: APP_URL not set in production, CSRF origin validation may fail")
@@ -60,17 +121,99 @@ func Load() *Config {
 		DatabaseURL:   getEnv("DATABASE_URL", ""),
 		AppPort:       appPort,
 		EmailFrom:     getEnv("EMAIL_FROM", ""),
+		EmailFromName: getEnv("EMAIL_FROM_NAME", ""),
+		ProductName:   getEnv("PRODUCT_NAME", "FeedStream"),
+		DefaultLocale: getEnv("DEFAULT_LOCALE", "en"),
+		EmailBackend:  getEnv("EMAIL_BACKEND", ""),
 		ResendAPIKey:  getEnv("RESEND_API_KEY", ""),
 		SessionSecret: sessionSecret,
 		CSRFSecret:    csrfSecret,
 		Environment:   environment,
 		AppURL:        appURL,
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPSecurity: getEnv("SMTP_SECURITY", "starttls"),
+		MailboxDir:   getEnv("MAILBOX_DIR", "tmp/mailbox"),
+
+		ItemsPageSize: getEnvInt("ITEMS_PAGE_SIZE", 50),
+
+		RetentionDays:     getEnvInt("RETENTION_DAYS", 90),
+		RetentionMaxItems: getEnvInt("RETENTION_MAX_ITEMS", 0),
+		CleanupInterval:   getEnvDuration("CLEANUP_INTERVAL", 24*time.Hour),
+
+		DigestCheckInterval: getEnvDuration("DIGEST_CHECK_INTERVAL", 15*time.Minute),
+		AlertCheckInterval:  getEnvDuration("ALERT_CHECK_INTERVAL", time.Minute),
+		WebhookInterval:     getEnvDuration("WEBHOOK_RETRY_INTERVAL", 15*time.Second),
+		VAPIDSubject:        getEnv("VAPID_SUBJECT", appURL),
+
+		SessionMaxAge:          getEnvDuration("SESSION_MAX_AGE", 7*24*time.Hour),
+		SessionCleanupInterval: getEnvDuration("SESSION_CLEANUP_INTERVAL", time.Hour),
+		OTPCleanupInterval:     getEnvDuration("OTP_CLEANUP_INTERVAL", time.Hour),
+		RateLimitBackend:       getEnv("RATE_LIMIT_BACKEND", "memory"),
+
+		TrustedProxies:  splitList(getEnv("TRUSTED_PROXIES", "")),
+		LoginThrottle:   getEnvRate("THROTTLE_LOGIN", Rate{Requests: 30, Window: 15 * time.Minute}),
+		ImportThrottle:  getEnvRate("THROTTLE_IMPORT", Rate{Requests: 10, Window: time.Hour}),
+		RefreshThrottle: getEnvRate("THROTTLE_REFRESH", Rate{Requests: 30, Window: 15 * time.Minute}),
+		FeverThrottle:   getEnvRate("THROTTLE_FEVER", Rate{Requests: 600, Window: 15 * time.Minute}),
+
+		OTPLoginEnabled:  getEnvBool("OTP_LOGIN_ENABLED", true),
+		OIDCIssuer:       getEnv("OIDC_ISSUER", ""),
+		OIDCClientID:     getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCScopes:       strings.Fields(getEnv("OIDC_SCOPES", "openid email profile")),
+		OIDCProviderName: getEnv("OIDC_PROVIDER_NAME", "Single Sign-On"),
+
+		OIDCTrustUnverifiedEmail: getEnvBool("OIDC_TRUST_UNVERIFIED_EMAIL", false),
+
+		AdminEmails: splitList(getEnv("ADMIN_EMAILS", "")),
+	}
+
+	// OIDC_ISSUER=mock signs in against the development identity provider
+	// served at /dev/oidc.
+	if cfg.OIDCIssuer == "mock" && cfg.OIDCClientID == "" {
+		cfg.OIDCClientID = "feedstream-dev"
+		cfg.OIDCClientSecret = "feedstream-dev-secret"
+	}
+
+	if cfg.EmailBackend == "" {
+		switch {
+		case cfg.SMTPHost != "":
+			cfg.EmailBackend = "smtp"
+		case cfg.ResendAPIKey == "" && cfg.IsDevelopment():
+			cfg.EmailBackend = "mailbox"
+		default:
+			cfg.EmailBackend = "resend"
+		}
+	}
+
+	if cfg.ItemsPageSize <= 0 {
+		log.Printf("Warning: ITEMS_PAGE_SIZE must be positive, using default 50")
+		cfg.ItemsPageSize = 50
 	}
 
 	log.Printf("Configuration loaded:")
 	log.Printf("  Environment: %s", cfg.Environment)
+	log.Printf("  Email backend: %s", cfg.EmailBackend)
+	log.Printf("  Product name: %s, default email locale: %s", cfg.ProductName, cfg.DefaultLocale)
 	log.Printf("  APP_PORT: %s", cfg.AppPort)
 	log.Printf("  APP_URL: %s", cfg.AppURL)
+	log.Printf("  Retention: %d days, %d items per feed, cleanup every %s",
+		cfg.RetentionDays, cfg.RetentionMaxItems, cfg.CleanupInterval)
+	log.Printf("  Digest check interval: %s", cfg.DigestCheckInterval)
+	log.Printf("  Alert check interval: %s", cfg.AlertCheckInterval)
+	log.Printf("  Webhook retry interval: %s", cfg.WebhookInterval)
+	log.Printf("  Sessions: expire after %s, cleanup every %s", cfg.SessionMaxAge, cfg.SessionCleanupInterval)
+	log.Printf("  Expired OTP cleanup interval: %s", cfg.OTPCleanupInterval)
+	log.Printf("  Email OTP login: %t, OIDC issuer: %s, trust unverified OIDC email: %t",
+		cfg.OTPLoginEnabled, cfg.OIDCIssuer, cfg.OIDCTrustUnverifiedEmail)
+	log.Printf("  Rate limit backend: %s", cfg.RateLimitBackend)
+	log.Printf("  Per-IP throttles: login %s, import %s, refresh %s, fever %s, trusted proxies: %v",
+		cfg.LoginThrottle, cfg.ImportThrottle, cfg.RefreshThrottle, cfg.FeverThrottle, cfg.TrustedProxies)
+	log.Printf("  Admin emails: %v", cfg.AdminEmails)
 
 	if cfg.DatabaseURL != "" {
 		cfg.parseDBURL()
@@ -92,6 +235,78 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func getEnvInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: invalid integer for %s (%q), using default %d", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: invalid boolean for %s (%q), using default %t", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		log.Printf("Warning: invalid duration for %s (%q), using default %s", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+func getEnvRate(key string, fallback Rate) Rate {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	requests, window, found := strings.Cut(value, "/")
+	parsed := Rate{}
+	var err error
+	if parsed.Requests, err = strconv.Atoi(requests); err == nil && found {
+		parsed.Window, err = time.ParseDuration(window)
+	}
+	if err != nil || !found || parsed.Requests < 0 || parsed.Window <= 0 {
+		log.Printf("Warning: invalid rate for %s (%q), using default %s", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// splitList splits a comma-separated value, dropping empty entries.
+func splitList(value string) []string {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
 func (c *Config) parseDBURL() {
 	u, err := url.Parse(c.DatabaseURL)
 	if err != nil {
@@ -115,19 +330,39 @@ func (c *Config) parseDBURL() {
 
 func generateRandomSecret(name string) string {
 	log.Printf("Warning: %s not set, generating random secret (will not persist across restarts)", name)
-	
+
 	b := make([]byte, 32)
 	if _, err := rand.Read(b); err != nil {
 		log.Fatalf("Failed to generate random secret for %s: %v", name, err)
 	}
-	
+
 	return base64.StdEncoding.EncodeToString(b)
 }
 
+// EmailSender is the From address of outgoing email. EMAIL_FROM may be a
+// bare address or a full "Name <address>"; a bare address is shown with
+// EMAIL_FROM_NAME, or the product name.
+func (c *Config) EmailSender() string {
+	if c.EmailFrom == "" || strings.Contains(c.EmailFrom, "<") {
+		return c.EmailFrom
+	}
+
+	name := c.EmailFromName
+	if name == "" {
+		name = c.ProductName
+	}
+	return (&mail.Address{Name: name, Address: c.EmailFrom}).String()
+}
+
+// OIDCEnabled reports whether single sign-on is configured.
+func (c *Config) OIDCEnabled() bool {
+	return c.OIDCIssuer != ""
+}
+
 func (c *Config) IsProduction() bool {
 	return c.Environment == "production"
 }
 
 func (c *Config) IsDevelopment() bool {
 	return c.Environment == "development"
-}
\ No newline at end of file
+}
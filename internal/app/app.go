@@ -1,17 +1,27 @@
 package app
 
 import (
+	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
 	"rss-reader/config"
 	"rss-reader/internal/database"
+	"rss-reader/internal/domain"
 	"rss-reader/internal/handler"
 	"rss-reader/internal/middleware"
 	"rss-reader/internal/repository"
 	"rss-reader/internal/service"
 	"rss-reader/pkg/datetime"
 	"rss-reader/pkg/email"
+	"rss-reader/pkg/favicon"
+	"rss-reader/pkg/idp"
+	"rss-reader/pkg/ratelimit"
 	"rss-reader/pkg/security"
+	"rss-reader/pkg/webhook"
+	"rss-reader/pkg/webpush"
+	"strings"
+	"time"
 
 	"github.com/gorilla/csrf"
 	"github.com/gorilla/mux"
@@ -19,12 +29,34 @@ import (
 )
 
 type Application struct {
-	Router         *mux.Router
-	Config         *config.Config
-	DBManager      *database.Manager
-	AuthHandler    *handler.AuthHandler
-	FeedHandler    *handler.FeedHandler
-	AuthMiddleware *middleware.AuthMiddleware
+	Router           *mux.Router
+	Config           *config.Config
+	DBManager        *database.Manager
+	AuthHandler      *handler.AuthHandler
+	FeedHandler      *handler.FeedHandler
+	SettingsHandler  *handler.SettingsHandler
+	DigestHandler    *handler.DigestHandler
+	AlertHandler     *handler.AlertHandler
+	WebhookHandler   *handler.WebhookHandler
+	PushHandler      *handler.PushHandler
+	PasskeyHandler   *handler.PasskeyHandler
+	SessionHandler   *handler.SessionHandler
+	TwoFactorHandler *handler.TwoFactorHandler
+	AdminHandler     *handler.AdminHandler
+	OIDCHandler      *handler.OIDCHandler
+	MockIdP          *idp.MockProvider
+	LocalPush        *webpush.LocalService
+	MailboxHandler   *handler.MailboxHandler
+	APIHandler       *handler.APIHandler
+	FeverHandler     *handler.FeverHandler
+	GReaderHandler   *handler.GReaderHandler
+	AuthMiddleware   *middleware.AuthMiddleware
+	APIAuth          *middleware.APIAuthMiddleware
+	AdminMiddleware  *middleware.AdminMiddleware
+	LoginThrottle    *middleware.Throttle
+	ImportThrottle   *middleware.Throttle
+	RefreshThrottle  *middleware.Throttle
+	FeverThrottle    *middleware.Throttle
 }
 
 func New(cfg *config.Config) (*Application, error) {
@@ -47,37 +79,224 @@ func New(cfg *config.Config) (*Application, error) {
 	otpRepository := repository.NewOTPRepository(db)
 	feedRepository := repository.NewFeedRepository(db)
 	feedItemRepository := repository.NewFeedItemRepository(db)
+	userSettingsRepository := repository.NewUserSettingsRepository(db)
+	folderRepository := repository.NewFolderRepository(db)
+	apiTokenRepository := repository.NewAPITokenRepository(db)
+	feedIconRepository := repository.NewFeedIconRepository(db)
+	feedImportRepository := repository.NewFeedImportRepository(db)
+	archiveRepository := repository.NewArchiveRepository(db)
+	digestRepository := repository.NewDigestRepository(db)
+	alertRepository := repository.NewAlertRepository(db)
+	webhookRepository := repository.NewWebhookRepository(db)
+	pushRepository := repository.NewPushRepository(db)
+	feverCredentialRepository := repository.NewFeverCredentialRepository(db)
+	passkeyRepository := repository.NewPasskeyRepository(db)
+	sessionRepository := repository.NewSessionRepository(db)
+	totpRepository := repository.NewTOTPRepository(db)
+	refreshRunRepository := repository.NewRefreshRunRepository(db)
+	adminRepository := repository.NewAdminRepository(db)
 	otpGenerator := security.NewOTPGenerator()
 	dateFormatter := datetime.NewFormatter()
-	emailService, err := email.NewResendService(cfg.ResendAPIKey, cfg.EmailFrom)
+	emailService, err := newEmailService(cfg)
 	if err != nil {
-		log.Printf("Warning: Email service initialization failed: %v", err)
-		log.Println("Authentication will not work without email service")
+		return nil, fmt.Errorf("email service initialization failed, sign-in needs email: %w", err)
+	}
+	emailTemplates, err := email.LoadTemplates("templates/email", cfg.DefaultLocale, email.Branding{
+		ProductName: cfg.ProductName,
+		AppURL:      cfg.AppURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("email template loading failed: %w", err)
+	}
+	mailer := service.NewMailer(emailService, emailTemplates, userRepository)
+	newLimiter, err := rateLimiterFactory(cfg, db)
+	if err != nil {
+		return nil, err
+	}
+	authService := service.NewAuthService(
+		userRepository,
+		otpRepository,
+		mailer,
+		otpGenerator,
+		security.NewTokenGenerator(),
+		security.NewSigner(cfg.SessionSecret),
+		cfg.AppURL,
+		newLimiter("otp_send"),
+		newLimiter("otp_verify"),
+	)
+	authService.Start(cfg.OTPCleanupInterval)
+	feedService := service.NewFeedService(
+		feedRepository,
+		feedItemRepository,
+		folderRepository,
+		feedIconRepository,
+		feedImportRepository,
+		refreshRunRepository,
+		dateFormatter,
+		favicon.NewFetcher(),
+		cfg.ItemsPageSize,
+	)
+	retentionService := service.NewRetentionService(
+		feedRepository,
+		feedItemRepository,
+		userSettingsRepository,
+		domain.RetentionPolicy{MaxAgeDays: cfg.RetentionDays, MaxItems: cfg.RetentionMaxItems},
+	)
+	retentionService.Start(cfg.CleanupInterval)
+	apiTokenService := service.NewAPITokenService(apiTokenRepository, security.NewTokenGenerator(), mailer)
+	feverService := service.NewFeverService(userRepository, feverCredentialRepository, mailer)
+	archiveService := service.NewArchiveService(archiveRepository, security.NewTokenGenerator())
+	digestService := service.NewDigestService(
+		digestRepository,
+		userRepository,
+		feedService,
+		mailer,
+		security.NewTokenGenerator(),
+		cfg.AppURL,
+	)
+	digestService.Start(cfg.DigestCheckInterval)
+	alertService := service.NewAlertService(alertRepository, userRepository, mailer)
+	feedService.AddNewItemListener(alertService)
+	alertService.Start(cfg.AlertCheckInterval)
+	webhookService := service.NewWebhookService(
+		webhookRepository,
+		alertRepository,
+		userRepository,
+		feedService,
+		webhook.NewSender(cfg.IsDevelopment()),
+		security.NewTokenGenerator(),
+	)
+	feedService.AddNewItemListener(webhookService)
+	if cfg.IsDevelopment() {
+		log.Printf("Development: webhooks and push notifications may be sent to private and loopback addresses")
+	}
+	webhookService.Start(cfg.WebhookInterval)
+	pushService, err := service.NewPushService(pushRepository, userRepository, cfg.VAPIDSubject, cfg.AppURL, cfg.IsDevelopment())
+	if err != nil {
+		return nil, err
+	}
+	feedService.AddNewItemListener(pushService)
+	passkeyService, err := service.NewPasskeyService(passkeyRepository, userRepository, mailer, cfg.AppURL, cfg.ProductName)
+	if err != nil {
+		return nil, err
+	}
+	totpService := service.NewTOTPService(totpRepository, userRepository, mailer, cfg.ProductName, newLimiter("totp_verify"))
+
+	var localPush *webpush.LocalService
+	if cfg.IsDevelopment() {
+		localPush = webpush.NewLocalService(cfg.AppURL, pushService.PublicKey())
+	}
+	if !cfg.OTPLoginEnabled && !cfg.OIDCEnabled() {
+		return nil, fmt.Errorf("OTP_LOGIN_ENABLED=false needs OIDC_ISSUER, or nobody could sign in")
+	}
+	var oidcService *service.OIDCService
+	var mockIdP *idp.MockProvider
+	if cfg.OIDCEnabled() {
+		issuer := cfg.OIDCIssuer
+		if issuer == "mock" {
+			if !cfg.IsDevelopment() {
+				return nil, fmt.Errorf("OIDC_ISSUER=mock is only available in development")
+			}
+			issuer = strings.TrimSuffix(cfg.AppURL, "/") + "/dev/oidc"
+			mockIdP, err = idp.NewMockProvider(issuer, cfg.OIDCClientID, cfg.OIDCClientSecret)
+			if err != nil {
+				return nil, err
+			}
+			log.Printf("Development identity provider available at %s", issuer)
+		}
+		oidcService = service.NewOIDCService(service.OIDCConfig{
+			Issuer:       issuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  strings.TrimSuffix(cfg.AppURL, "/") + "/login/oidc/callback",
+			Scopes:       cfg.OIDCScopes,
+
+			TrustUnverifiedEmail: cfg.OIDCTrustUnverifiedEmail,
+		})
+	}
+	var mailboxHandler *handler.MailboxHandler
+	if mailbox, ok := emailService.(*email.MailboxService); ok {
+		mailboxHandler = handler.NewMailboxHandler(mailbox)
+		log.Printf("Development mailbox available at %s/dev/mailbox", cfg.AppURL)
+	}
+
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
 	}
-	authService := service.NewAuthService(userRepository, otpRepository, emailService, otpGenerator)
-	feedService := service.NewFeedService(feedRepository, feedItemRepository, dateFormatter)
 
-	sessionStore := sessions.NewCookieStore([]byte(cfg.SessionSecret))
-	sessionStore.Options = &sessions.Options{
+	sessionService := service.NewSessionService(sessionRepository)
+	sessionService.Start(cfg.SessionCleanupInterval)
+	adminService := service.NewAdminService(userRepository, adminRepository, refreshRunRepository, feedService, sessionService)
+	if err := adminService.Bootstrap(cfg.AdminEmails); err != nil {
+		return nil, err
+	}
+	sessionStore := middleware.NewSessionStore(sessionService, trustedProxies, &sessions.Options{
 		Path:     "/",
-		MaxAge:   86400 * 7,
+		MaxAge:   int(cfg.SessionMaxAge / time.Second),
 		HttpOnly: true,
 		Secure:   cfg.IsProduction(),
 		SameSite: http.SameSiteLaxMode,
-	}
+	})
 
 	authMiddleware := middleware.NewAuthMiddleware(sessionStore)
-	authHandler := handler.NewAuthHandler(authService, authMiddleware)
-	feedHandler := handler.NewFeedHandler(feedService, authMiddleware)
+	loginOptions := handler.LoginOptions{OTPEnabled: cfg.OTPLoginEnabled}
+	var oidcHandler *handler.OIDCHandler
+	if oidcService != nil {
+		loginOptions.SSOName = cfg.OIDCProviderName
+		oidcHandler = handler.NewOIDCHandler(oidcService, authService, authMiddleware)
+	}
+	authHandler := handler.NewAuthHandler(authService, totpService, authMiddleware, loginOptions)
+	loginThrottle := middleware.NewThrottle("login", newLimiter("ip_login"), trustedProxies, cfg.LoginThrottle.Requests, cfg.LoginThrottle.Window)
+	importThrottle := middleware.NewThrottle("import", newLimiter("ip_import"), trustedProxies, cfg.ImportThrottle.Requests, cfg.ImportThrottle.Window)
+	refreshThrottle := middleware.NewThrottle("refresh", newLimiter("ip_refresh"), trustedProxies, cfg.RefreshThrottle.Requests, cfg.RefreshThrottle.Window)
+	feverThrottle := middleware.NewThrottle("fever", newLimiter("ip_fever"), trustedProxies, cfg.FeverThrottle.Requests, cfg.FeverThrottle.Window)
+	feedHandler := handler.NewFeedHandler(feedService, retentionService, authMiddleware, refreshThrottle)
+	settingsHandler := handler.NewSettingsHandler(retentionService, apiTokenService, feverService, archiveService, digestService, mailer, authMiddleware, cfg.AppURL)
+	digestHandler := handler.NewDigestHandler(digestService)
+	alertHandler := handler.NewAlertHandler(alertService, authMiddleware)
+	webhookHandler := handler.NewWebhookHandler(webhookService, feedService, alertService, authMiddleware)
+	pushHandler := handler.NewPushHandler(pushService, feedService, localPush, authMiddleware)
+	passkeyHandler := handler.NewPasskeyHandler(passkeyService, authMiddleware)
+	sessionHandler := handler.NewSessionHandler(sessionService, authMiddleware)
+	twoFactorHandler := handler.NewTwoFactorHandler(totpService, authMiddleware)
+	adminMiddleware := middleware.NewAdminMiddleware(authMiddleware, adminService)
+	adminHandler := handler.NewAdminHandler(adminService, authMiddleware)
+	apiAuth := middleware.NewAPIAuthMiddleware(apiTokenService)
+	apiHandler := handler.NewAPIHandler(feedService, retentionService, apiAuth)
+	feverHandler := handler.NewFeverHandler(feverService, feedService)
+	greaderHandler := handler.NewGReaderHandler(feedService, apiTokenService, authService, apiAuth)
 	router := mux.NewRouter()
 
 	app := &Application{
-		Router:         router,
-		Config:         cfg,
-		DBManager:      dbManager,
-		AuthHandler:    authHandler,
-		FeedHandler:    feedHandler,
-		AuthMiddleware: authMiddleware,
+		Router:           router,
+		Config:           cfg,
+		DBManager:        dbManager,
+		AuthHandler:      authHandler,
+		FeedHandler:      feedHandler,
+		SettingsHandler:  settingsHandler,
+		DigestHandler:    digestHandler,
+		AlertHandler:     alertHandler,
+		WebhookHandler:   webhookHandler,
+		PushHandler:      pushHandler,
+		PasskeyHandler:   passkeyHandler,
+		SessionHandler:   sessionHandler,
+		TwoFactorHandler: twoFactorHandler,
+		AdminHandler:     adminHandler,
+		OIDCHandler:      oidcHandler,
+		MockIdP:          mockIdP,
+		LocalPush:        localPush,
+		MailboxHandler:   mailboxHandler,
+		APIHandler:       apiHandler,
+		FeverHandler:     feverHandler,
+		GReaderHandler:   greaderHandler,
+		AuthMiddleware:   authMiddleware,
+		APIAuth:          apiAuth,
+		AdminMiddleware:  adminMiddleware,
+		LoginThrottle:    loginThrottle,
+		ImportThrottle:   importThrottle,
+		RefreshThrottle:  refreshThrottle,
+		FeverThrottle:    feverThrottle,
 	}
 
 	app.setupMiddleware()
@@ -102,12 +321,31 @@ func (a *Application) setupMiddleware() {
 			log.Printf("CSRF Configuration - Trusted Origin: %s", a.Config.AppURL)
 		}
 		csrfMiddleware := csrf.Protect([]byte(a.Config.CSRFSecret), csrfOptions...)
-		a.Router.Use(csrfMiddleware)
+		a.Router.Use(skipForPrefixes(csrfMiddleware, csrfExemptPrefixes...))
 	} else {
 		log.Printf("CSRF Configuration - Disabled in development mode")
 	}
 }
 
+// csrfExemptPrefixes lists routes authenticated by tokens rather than the
+// session cookie, which are not exposed to cross-site request forgery.
+var csrfExemptPrefixes = []string{"/api/", "/fever", "/accounts/ClientLogin", "/reader/api/"}
+
+func skipForPrefixes(mw func(http.Handler) http.Handler, prefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
+
 func securityHeadersMiddleware(isProduction bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -115,13 +353,13 @@ func securityHeadersMiddleware(isProduction bool) func(http.Handler) http.Handle
 			w.Header().Set("X-Content-Type-Options", "nosniff")
 			w.Header().Set("X-XSS-Protection", "1; mode=block")
 			w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
-			
+
 			if isProduction {
 				w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data:;")
 			} else {
 				w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:;")
 			}
-			
+
 			next.ServeHTTP(w, r)
 		})
 	}
@@ -129,20 +367,126 @@ func securityHeadersMiddleware(isProduction bool) func(http.Handler) http.Handle
 
 func (a *Application) setupRoutes() {
 	a.Router.HandleFunc("/", a.redirectToLogin).Methods("GET")
-	a.Router.HandleFunc("/login", a.AuthHandler.Login).Methods("GET", "POST")
+	// Routes that send email or check credentials share the per-IP login
+	// budget; showing the login page does not use it up.
+	login := a.LoginThrottle.Limit
+	a.Router.HandleFunc("/login", a.AuthHandler.Login).Methods("GET")
+	a.Router.Handle("/login", login(http.HandlerFunc(a.AuthHandler.Login))).Methods("POST")
+	a.Router.Handle("/login/link", login(http.HandlerFunc(a.AuthHandler.LoginLink))).Methods("GET")
+	a.Router.Handle("/login/two-factor", login(http.HandlerFunc(a.AuthHandler.TwoFactor))).Methods("POST")
+	a.Router.Handle("/login/passkey/begin", login(http.HandlerFunc(a.PasskeyHandler.BeginLogin))).Methods("POST")
+	a.Router.Handle("/login/passkey/finish", login(http.HandlerFunc(a.PasskeyHandler.FinishLogin))).Methods("POST")
 	a.Router.HandleFunc("/logout", a.AuthHandler.Logout).Methods("GET")
+	if a.OIDCHandler != nil {
+		a.Router.HandleFunc("/login/oidc", a.OIDCHandler.Login).Methods("GET")
+		a.Router.Handle("/login/oidc/callback", login(http.HandlerFunc(a.OIDCHandler.Callback))).Methods("GET")
+	}
+	a.Router.HandleFunc("/digest/unsubscribe", a.DigestHandler.Unsubscribe).Methods("GET", "POST")
+
+	api := a.Router.PathPrefix("/api/v1").Subrouter()
+	api.Use(a.APIAuth.RequireToken)
+	api.HandleFunc("/feeds", a.APIHandler.ListFeeds).Methods("GET")
+	api.HandleFunc("/feeds", a.APIHandler.CreateFeed).Methods("POST")
+	api.HandleFunc("/feeds/{id:[0-9]+}", a.APIHandler.GetFeed).Methods("GET")
+	api.HandleFunc("/feeds/{id:[0-9]+}", a.APIHandler.UpdateFeed).Methods("PUT", "PATCH")
+	api.HandleFunc("/feeds/{id:[0-9]+}", a.APIHandler.DeleteFeed).Methods("DELETE")
+	api.HandleFunc("/folders", a.APIHandler.ListFolders).Methods("GET")
+	api.HandleFunc("/items", a.APIHandler.ListItems).Methods("GET")
+	api.HandleFunc("/items/{id:[0-9]+}", a.APIHandler.UpdateItem).Methods("PATCH")
+	api.Handle("/refresh", a.RefreshThrottle.Limit(http.HandlerFunc(a.APIHandler.Refresh))).Methods("POST")
+
+	// Every Fever request carries the API key, so the API gets a per-IP
+	// budget of its own: large enough for a client's sync, small enough to
+	// stop keys being guessed.
+	fever := a.FeverThrottle.Limit(http.HandlerFunc(a.FeverHandler.Serve))
+	a.Router.Handle("/fever/", fever).Methods("GET", "POST")
+	a.Router.Handle("/fever", fever).Methods("GET", "POST")
+
+	a.Router.Handle("/accounts/ClientLogin", login(http.HandlerFunc(a.GReaderHandler.ClientLogin))).Methods("GET", "POST")
+	greader := a.Router.PathPrefix("/reader/api/0").Subrouter()
+	greader.Use(a.APIAuth.RequireGoogleLogin)
+	greader.HandleFunc("/token", a.GReaderHandler.Token).Methods("GET", "POST")
+	greader.HandleFunc("/user-info", a.GReaderHandler.UserInfo).Methods("GET")
+	greader.HandleFunc("/subscription/list", a.GReaderHandler.SubscriptionList).Methods("GET")
+	greader.HandleFunc("/subscription/edit", a.GReaderHandler.SubscriptionEdit).Methods("POST")
+	greader.HandleFunc("/subscription/quickadd", a.GReaderHandler.QuickAdd).Methods("POST")
+	greader.HandleFunc("/tag/list", a.GReaderHandler.TagList).Methods("GET")
+	greader.HandleFunc("/unread-count", a.GReaderHandler.UnreadCount).Methods("GET")
+	greader.HandleFunc("/stream/items/ids", a.GReaderHandler.StreamItemIDs).Methods("GET")
+	greader.HandleFunc("/stream/items/contents", a.GReaderHandler.StreamItemsContents).Methods("GET", "POST")
+	greader.HandleFunc("/stream/contents", a.GReaderHandler.StreamContents).Methods("GET")
+	greader.HandleFunc("/stream/contents/{stream:.+}", a.GReaderHandler.StreamContents).Methods("GET")
+	greader.HandleFunc("/edit-tag", a.GReaderHandler.EditTag).Methods("POST")
+	greader.HandleFunc("/mark-all-as-read", a.GReaderHandler.MarkAllAsRead).Methods("POST")
+
+	if a.LocalPush != nil {
+		a.Router.Handle("/dev/push", a.LocalPush).Methods("GET")
+		a.Router.PathPrefix("/dev/push/").Handler(a.LocalPush).Methods("POST")
+	}
+	if a.MockIdP != nil {
+		a.Router.PathPrefix("/dev/oidc/").Handler(a.MockIdP).Methods("GET", "POST")
+	}
+	if a.MailboxHandler != nil {
+		a.Router.HandleFunc("/dev/mailbox", a.MailboxHandler.Mailbox).Methods("GET")
+		a.Router.HandleFunc("/dev/mailbox/clear", a.MailboxHandler.Clear).Methods("POST")
+		a.Router.HandleFunc("/dev/mailbox/{id}/html", a.MailboxHandler.MessageHTML).Methods("GET")
+	}
+
 	protected := a.Router.PathPrefix("/").Subrouter()
 	protected.Use(a.AuthMiddleware.RequireAuth)
 
 	protected.HandleFunc("/feeds", a.FeedHandler.ViewFeeds).Methods("GET")
+	protected.HandleFunc("/feeds/items", a.FeedHandler.FeedItems).Methods("GET")
+	protected.HandleFunc("/feeds/items/{id}/read", a.FeedHandler.SetItemRead).Methods("POST")
+	protected.HandleFunc("/feeds/items/{id}/star", a.FeedHandler.SetItemStarred).Methods("POST")
 	protected.HandleFunc("/feeds/add", a.FeedHandler.AddFeed).Methods("GET", "POST")
-	protected.HandleFunc("/feeds/refresh", a.FeedHandler.RefreshFeeds).Methods("GET")
+	protected.Handle("/feeds/refresh", a.RefreshThrottle.Limit(http.HandlerFunc(a.FeedHandler.RefreshFeeds))).Methods("GET")
 	protected.HandleFunc("/feeds/manage", a.FeedHandler.ManageFeeds).Methods("GET")
 	protected.HandleFunc("/feeds/edit/{id}", a.FeedHandler.EditFeed).Methods("GET", "POST")
 	protected.HandleFunc("/feeds/delete/{id}", a.FeedHandler.DeleteFeed).Methods("POST")
-	protected.HandleFunc("/feeds/import", a.FeedHandler.ImportFeeds).Methods("POST")
+	protected.Handle("/feeds/import", a.ImportThrottle.Limit(http.HandlerFunc(a.FeedHandler.ImportFeeds))).Methods("POST")
+	protected.Handle("/feeds/import/commit", a.ImportThrottle.Limit(http.HandlerFunc(a.FeedHandler.CommitImport))).Methods("POST")
 	protected.HandleFunc("/feeds/export", a.FeedHandler.ExportFeeds).Methods("GET")
 	protected.HandleFunc("/feeds/debug", a.FeedHandler.Debug).Methods("GET")
+	protected.HandleFunc("/settings", a.SettingsHandler.Settings).Methods("GET", "POST")
+	protected.HandleFunc("/settings/tokens", a.SettingsHandler.CreateAPIToken).Methods("POST")
+	protected.HandleFunc("/settings/tokens/{id}/revoke", a.SettingsHandler.RevokeAPIToken).Methods("POST")
+	protected.HandleFunc("/settings/fever", a.SettingsHandler.SetFeverPassword).Methods("POST")
+	protected.HandleFunc("/settings/fever/disable", a.SettingsHandler.DisableFever).Methods("POST")
+	protected.HandleFunc("/settings/digest", a.SettingsHandler.UpdateDigest).Methods("POST")
+	protected.HandleFunc("/settings/locale", a.SettingsHandler.UpdateLocale).Methods("POST")
+	protected.HandleFunc("/settings/archive", a.SettingsHandler.ExportArchive).Methods("GET")
+	protected.Handle("/settings/archive/restore", a.ImportThrottle.Limit(http.HandlerFunc(a.SettingsHandler.RestoreArchive))).Methods("POST")
+	protected.HandleFunc("/alerts", a.AlertHandler.Alerts).Methods("GET", "POST")
+	protected.HandleFunc("/alerts/{id}/delete", a.AlertHandler.DeleteAlert).Methods("POST")
+	protected.HandleFunc("/webhooks", a.WebhookHandler.Webhooks).Methods("GET", "POST")
+	protected.HandleFunc("/webhooks/{id}/delete", a.WebhookHandler.DeleteWebhook).Methods("POST")
+	protected.HandleFunc("/webhooks/{id}/test", a.WebhookHandler.SendTest).Methods("POST")
+	protected.HandleFunc("/notifications", a.PushHandler.Notifications).Methods("GET")
+	protected.HandleFunc("/notifications/subscribe", a.PushHandler.Subscribe).Methods("POST")
+	protected.HandleFunc("/notifications/devices/{id}/delete", a.PushHandler.RemoveDevice).Methods("POST")
+	protected.HandleFunc("/notifications/feeds", a.PushHandler.UpdateFeeds).Methods("POST")
+	protected.HandleFunc("/notifications/quiet", a.PushHandler.UpdateQuietHours).Methods("POST")
+	protected.HandleFunc("/notifications/test", a.PushHandler.SendTest).Methods("POST")
+	protected.HandleFunc("/notifications/local", a.PushHandler.AddLocalDevice).Methods("POST")
+	protected.HandleFunc("/passkeys", a.PasskeyHandler.Passkeys).Methods("GET")
+	protected.HandleFunc("/passkeys/register/begin", a.PasskeyHandler.BeginRegistration).Methods("POST")
+	protected.HandleFunc("/passkeys/register/finish", a.PasskeyHandler.FinishRegistration).Methods("POST")
+	protected.HandleFunc("/passkeys/{id}/delete", a.PasskeyHandler.DeletePasskey).Methods("POST")
+	protected.HandleFunc("/sessions", a.SessionHandler.Sessions).Methods("GET")
+	protected.HandleFunc("/sessions/revoke-others", a.SessionHandler.RevokeOtherSessions).Methods("POST")
+	protected.HandleFunc("/sessions/{id:[0-9]+}/revoke", a.SessionHandler.RevokeSession).Methods("POST")
+	protected.HandleFunc("/two-factor", a.TwoFactorHandler.TwoFactor).Methods("GET")
+	protected.HandleFunc("/two-factor/confirm", a.TwoFactorHandler.Confirm).Methods("POST")
+	protected.HandleFunc("/two-factor/recovery-codes", a.TwoFactorHandler.RegenerateRecoveryCodes).Methods("POST")
+	protected.HandleFunc("/two-factor/disable", a.TwoFactorHandler.Disable).Methods("POST")
+
+	admin := protected.PathPrefix("/admin").Subrouter()
+	admin.Use(a.AdminMiddleware.RequireAdmin)
+	admin.HandleFunc("", a.AdminHandler.Dashboard).Methods("GET")
+	admin.HandleFunc("/users/{id:[0-9]+}/disable", a.AdminHandler.DisableUser).Methods("POST")
+	admin.HandleFunc("/users/{id:[0-9]+}/enable", a.AdminHandler.EnableUser).Methods("POST")
+	admin.HandleFunc("/feeds/{id:[0-9]+}/refresh", a.AdminHandler.RefreshFeed).Methods("POST")
 	a.Router.PathPrefix("/static/").Handler(
 		http.StripPrefix("/static/", http.FileServer(http.Dir("static"))),
 	)
@@ -158,3 +502,50 @@ func (a *Application) Close() error {
 	}
 	return nil
 }
+
+// rateLimiterFactory returns a constructor for the limiters selected by
+// RATE_LIMIT_BACKEND. Each scope gets its own limiter; with the postgres
+// backend, limits are shared by every instance using the database.
+func rateLimiterFactory(cfg *config.Config, db *sql.DB) (func(scope string) ratelimit.Limiter, error) {
+	switch cfg.RateLimitBackend {
+	case "memory":
+		return func(string) ratelimit.Limiter {
+			return ratelimit.NewMemoryLimiter()
+		}, nil
+	case "postgres":
+		return func(scope string) ratelimit.Limiter {
+			return ratelimit.NewPostgresLimiter(db, scope)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown RATE_LIMIT_BACKEND %q", cfg.RateLimitBackend)
+}
+
+// newEmailService builds the email backend selected by EMAIL_BACKEND. It
+// returns an error rather than a half-configured service, since sign-in is
+// impossible without email.
+func newEmailService(cfg *config.Config) (email.Service, error) {
+	switch cfg.EmailBackend {
+	case "resend":
+		return email.NewResendService(cfg.ResendAPIKey, cfg.EmailSender())
+	case "smtp":
+		return email.NewSMTPService(email.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			Security: cfg.SMTPSecurity,
+			From:     cfg.EmailSender(),
+		})
+	case "log", "mailbox":
+		// Both expose sign-in codes to anyone who can read the log or the
+		// directory, so they are refused outside development.
+		if !cfg.IsDevelopment() {
+			return nil, fmt.Errorf("EMAIL_BACKEND %q is only available in development", cfg.EmailBackend)
+		}
+		if cfg.EmailBackend == "log" {
+			return email.NewLogService(), nil
+		}
+		return email.NewMailboxService(cfg.MailboxDir)
+	}
+	return nil, fmt.Errorf("unknown EMAIL_BACKEND %q", cfg.EmailBackend)
+}
@@ -100,6 +100,223 @@ func (m *Manager) runMigrations() error {
 		`CREATE INDEX IF NOT EXISTS idx_feed_items_published_at ON feed_items(published_at DESC)`,
 		`CREATE INDEX IF NOT EXISTS idx_feeds_user_id ON feeds(user_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_otps_email ON otps(email, expires_at DESC)`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS retention_days INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS retention_max_items INTEGER NOT NULL DEFAULT 0`,
+		`CREATE TABLE IF NOT EXISTS user_settings (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			retention_days INTEGER NOT NULL DEFAULT 0,
+			retention_max_items INTEGER NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_feed_items_feed_published ON feed_items(feed_id, published_at DESC, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_feed_items_published_id ON feed_items(published_at DESC, id DESC)`,
+		`CREATE TABLE IF NOT EXISTS folders (
+			id SERIAL PRIMARY KEY,
+			name TEXT NOT NULL,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(user_id, name)
+		)`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS folder_id INTEGER REFERENCES folders(id) ON DELETE SET NULL`,
+		`ALTER TABLE feed_items ADD COLUMN IF NOT EXISTS is_read BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE feed_items ADD COLUMN IF NOT EXISTS is_starred BOOLEAN NOT NULL DEFAULT FALSE`,
+		`CREATE INDEX IF NOT EXISTS idx_feeds_folder_id ON feeds(folder_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_feed_items_unread ON feed_items(feed_id) WHERE NOT is_read`,
+		`CREATE INDEX IF NOT EXISTS idx_feed_items_starred ON feed_items(feed_id) WHERE is_starred`,
+		`CREATE TABLE IF NOT EXISTS api_tokens (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			prefix TEXT NOT NULL,
+			token_hash TEXT UNIQUE NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			last_used_at TIMESTAMP WITH TIME ZONE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id)`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS site_url TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS last_refreshed_at TIMESTAMP WITH TIME ZONE`,
+		`CREATE TABLE IF NOT EXISTS feed_icons (
+			feed_id INTEGER PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+			mime_type TEXT NOT NULL DEFAULT '',
+			data BYTEA,
+			fetched_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS fever_credentials (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			key_hash TEXT UNIQUE NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS digest_subscriptions (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			frequency TEXT NOT NULL DEFAULT 'off',
+			send_hour INTEGER NOT NULL DEFAULT 8,
+			weekday INTEGER NOT NULL DEFAULT 1,
+			timezone TEXT NOT NULL DEFAULT 'UTC',
+			unsubscribe_token TEXT UNIQUE NOT NULL,
+			last_sent_at TIMESTAMP WITH TIME ZONE,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS digest_sent_items (
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			item_id INTEGER REFERENCES feed_items(id) ON DELETE CASCADE,
+			sent_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, item_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS alerts (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			pattern TEXT NOT NULL,
+			is_regex BOOLEAN NOT NULL DEFAULT FALSE,
+			batch_minutes INTEGER NOT NULL DEFAULT 60,
+			last_sent_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_alerts_user_id ON alerts(user_id)`,
+		`CREATE TABLE IF NOT EXISTS alert_matches (
+			alert_id INTEGER REFERENCES alerts(id) ON DELETE CASCADE,
+			item_id INTEGER REFERENCES feed_items(id) ON DELETE CASCADE,
+			matched_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			sent_at TIMESTAMP WITH TIME ZONE,
+			PRIMARY KEY (alert_id, item_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_alert_matches_pending ON alert_matches(alert_id) WHERE sent_at IS NULL`,
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			feed_id INTEGER REFERENCES feeds(id) ON DELETE CASCADE,
+			folder_id INTEGER REFERENCES folders(id) ON DELETE CASCADE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks(user_id)`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id SERIAL PRIMARY KEY,
+			webhook_id INTEGER REFERENCES webhooks(id) ON DELETE CASCADE,
+			event TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_status INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			delivered_at TIMESTAMP WITH TIME ZONE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(next_attempt_at) WHERE status = 'pending'`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at DESC)`,
+		`CREATE TABLE IF NOT EXISTS vapid_keys (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			public_key TEXT NOT NULL,
+			private_key TEXT NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS push_subscriptions (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			endpoint TEXT UNIQUE NOT NULL,
+			p256dh TEXT NOT NULL,
+			auth TEXT NOT NULL,
+			user_agent TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user_id ON push_subscriptions(user_id)`,
+		`CREATE TABLE IF NOT EXISTS push_settings (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			quiet_start INTEGER NOT NULL DEFAULT 0,
+			quiet_end INTEGER NOT NULL DEFAULT 0,
+			timezone TEXT NOT NULL DEFAULT 'UTC'
+		)`,
+		`CREATE TABLE IF NOT EXISTS push_feeds (
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			feed_id INTEGER REFERENCES feeds(id) ON DELETE CASCADE,
+			PRIMARY KEY (user_id, feed_id)
+		)`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(16) NOT NULL DEFAULT ''`,
+		`CREATE TABLE IF NOT EXISTS webauthn_users (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			handle BYTEA NOT NULL UNIQUE
+		)`,
+		`CREATE TABLE IF NOT EXISTS passkeys (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			name VARCHAR(100) NOT NULL,
+			credential_id BYTEA NOT NULL UNIQUE,
+			public_key BYTEA NOT NULL,
+			attestation_type TEXT NOT NULL DEFAULT '',
+			aaguid BYTEA,
+			sign_count BIGINT NOT NULL DEFAULT 0,
+			transports TEXT NOT NULL DEFAULT '',
+			backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+			backup_state BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_used_at TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_passkeys_user_id ON passkeys(user_id)`,
+		`ALTER TABLE otps ADD COLUMN IF NOT EXISTS link_hash TEXT NOT NULL DEFAULT ''`,
+		`CREATE TABLE IF NOT EXISTS sessions (
+			id SERIAL PRIMARY KEY,
+			token_hash TEXT NOT NULL UNIQUE,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			data BYTEA NOT NULL,
+			user_agent TEXT NOT NULL DEFAULT '',
+			ip TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at)`,
+		`CREATE TABLE IF NOT EXISTS totp_credentials (
+			user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			secret TEXT NOT NULL,
+			confirmed_at TIMESTAMP WITH TIME ZONE,
+			last_used_step BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS totp_recovery_codes (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			code_hash TEXT NOT NULL,
+			used_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_totp_recovery_codes_user_id ON totp_recovery_codes(user_id)`,
+		`CREATE TABLE IF NOT EXISTS rate_limit_attempts (
+			id BIGSERIAL PRIMARY KEY,
+			scope TEXT NOT NULL,
+			key TEXT NOT NULL,
+			attempted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_rate_limit_attempts_key ON rate_limit_attempts(scope, key, attempted_at)`,
+		`ALTER TABLE otps ADD COLUMN IF NOT EXISTS otp_hash TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE otps ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE otps DROP COLUMN IF EXISTS otp`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user'`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP WITH TIME ZONE`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS last_error TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS failure_count INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS last_failed_at TIMESTAMP WITH TIME ZONE`,
+		`CREATE TABLE IF NOT EXISTS refresh_runs (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			feed_id INTEGER REFERENCES feeds(id) ON DELETE SET NULL,
+			trigger TEXT NOT NULL,
+			feeds_total INTEGER NOT NULL DEFAULT 0,
+			feeds_failed INTEGER NOT NULL DEFAULT 0,
+			items_processed INTEGER NOT NULL DEFAULT 0,
+			items_new INTEGER NOT NULL DEFAULT 0,
+			started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			finished_at TIMESTAMP WITH TIME ZONE NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_refresh_runs_started_at ON refresh_runs(started_at DESC)`,
+		`ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS alert_id INTEGER REFERENCES alerts(id) ON DELETE CASCADE`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_push_subscriptions_user_endpoint ON push_subscriptions(user_id, endpoint)`,
+		`ALTER TABLE push_subscriptions DROP CONSTRAINT IF EXISTS push_subscriptions_endpoint_key`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS trimmed_before TIMESTAMP WITH TIME ZONE`,
+		`ALTER TABLE digest_subscriptions ADD COLUMN IF NOT EXISTS enabled_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP`,
 	}
 
 	for i, migration := range migrations {
@@ -121,4 +338,4 @@ func (m *Manager) Close() error {
 
 func (m *Manager) GetDB() *sql.DB {
 	return m.DB
-}
\ No newline at end of file
+}
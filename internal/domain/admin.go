@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// UserOverview is a user as listed in the admin area.
+type UserOverview struct {
+	User
+	FeedCount    int        `json:"feed_count"`
+	ItemCount    int        `json:"item_count"`
+	StorageBytes int64      `json:"storage_bytes"`
+	LastSeenAt   *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// TableUsage is the size of one database table including its indexes.
+type TableUsage struct {
+	Name  string `json:"name"`
+	Rows  int64  `json:"rows"`
+	Bytes int64  `json:"bytes"`
+}
+
+// StorageUsage is the instance's database size, largest tables first.
+type StorageUsage struct {
+	DatabaseBytes int64        `json:"database_bytes"`
+	Tables        []TableUsage `json:"tables"`
+}
+
+// FailingFeed is a feed whose latest refresh failed, with its owner.
+type FailingFeed struct {
+	Feed
+	OwnerEmail string `json:"owner_email"`
+}
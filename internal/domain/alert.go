@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+const MaxAlertBatchMinutes = 24 * 60
+
+// Alert is a saved keyword or regular expression checked against the title
+// and description of every newly ingested item. Keywords match
+// case-insensitively; regular expressions use Go syntax and can opt into
+// case-insensitivity with (?i). BatchMinutes is the minimum gap between two
+// emails for the same alert.
+type Alert struct {
+	ID           int        `json:"id"`
+	UserID       int        `json:"user_id"`
+	Name         string     `json:"name"`
+	Pattern      string     `json:"pattern"`
+	IsRegex      bool       `json:"is_regex"`
+	BatchMinutes int        `json:"batch_minutes"`
+	LastSentAt   *time.Time `json:"last_sent_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (a *Alert) Validate() error {
+	if a.UserID <= 0 {
+		return ErrInvalidUserID
+	}
+	return a.validateRule()
+}
+
+// validateRule checks everything but the owner, for alerts read from an
+// archive.
+func (a *Alert) validateRule() error {
+	if strings.TrimSpace(a.Name) == "" || strings.TrimSpace(a.Pattern) == "" {
+		return ErrInvalidAlert
+	}
+	if a.BatchMinutes < 0 || a.BatchMinutes > MaxAlertBatchMinutes {
+		return ErrInvalidAlert
+	}
+	if a.IsRegex {
+		if _, err := regexp.Compile(a.Pattern); err != nil {
+			return ErrInvalidAlertPattern
+		}
+	}
+	return nil
+}
+
+// Matcher compiles the alert into a function reporting whether a piece of
+// text matches.
+func (a *Alert) Matcher() (func(string) bool, error) {
+	if a.IsRegex {
+		re, err := regexp.Compile(a.Pattern)
+		if err != nil {
+			return nil, ErrInvalidAlertPattern
+		}
+		return re.MatchString, nil
+	}
+
+	keyword := strings.ToLower(a.Pattern)
+	return func(text string) bool {
+		return strings.Contains(strings.ToLower(text), keyword)
+	}, nil
+}
+
+// NextSendAt is the earliest time another email may go out for the alert.
+func (a *Alert) NextSendAt() time.Time {
+	if a.LastSentAt == nil {
+		return time.Time{}
+	}
+	return a.LastSentAt.Add(time.Duration(a.BatchMinutes) * time.Minute)
+}
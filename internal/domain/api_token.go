@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+type APIToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	TokenHash  string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+func (t *APIToken) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return ErrInvalidAPITokenName
+	}
+	if t.UserID <= 0 {
+		return ErrInvalidUserID
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package domain
+
+import "time"
+
+const (
+	ArchiveFormat  = "feedstream-archive"
+	ArchiveVersion = 2
+)
+
+// Archive is a complete, portable copy of an account. Feeds are keyed by URL
+// and items by feed URL plus link, so an archive can be restored into any
+// instance. Items are only included when they are read or starred; starred
+// items carry their full content. Version 2 added alerts and the digest and
+// notification preferences.
+type Archive struct {
+	Format      string             `json:"format"`
+	Version     int                `json:"version"`
+	ExportedAt  time.Time          `json:"exported_at"`
+	Preferences ArchivePreferences `json:"preferences"`
+	Folders     []ArchiveFolder    `json:"folders"`
+	Feeds       []ArchiveFeed      `json:"feeds"`
+	Items       []ArchiveItem      `json:"items"`
+	Alerts      []ArchiveAlert     `json:"alerts"`
+}
+
+// ArchivePreferences holds account-wide settings. Digest and Push are nil
+// when the user never saved them.
+type ArchivePreferences struct {
+	Retention RetentionPolicy `json:"retention"`
+	Digest    *ArchiveDigest  `json:"digest,omitempty"`
+	Push      *ArchivePush    `json:"push,omitempty"`
+}
+
+type ArchiveDigest struct {
+	Frequency string       `json:"frequency"`
+	SendHour  int          `json:"send_hour"`
+	Weekday   time.Weekday `json:"weekday"`
+	Timezone  string       `json:"timezone"`
+}
+
+// ArchivePush holds the notification preferences. Browser subscriptions are
+// tied to the instance's keys and are not archived.
+type ArchivePush struct {
+	QuietStart int      `json:"quiet_start"`
+	QuietEnd   int      `json:"quiet_end"`
+	Timezone   string   `json:"timezone"`
+	FeedURLs   []string `json:"feed_urls"`
+}
+
+type ArchiveFolder struct {
+	Name string `json:"name"`
+}
+
+type ArchiveFeed struct {
+	Name      string          `json:"name"`
+	URL       string          `json:"url"`
+	SiteURL   string          `json:"site_url,omitempty"`
+	Folder    string          `json:"folder,omitempty"`
+	Retention RetentionPolicy `json:"retention"`
+}
+
+type ArchiveItem struct {
+	FeedURL     string    `json:"feed_url"`
+	Title       string    `json:"title"`
+	Link        string    `json:"link"`
+	Description string    `json:"description,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+	IsRead      bool      `json:"is_read"`
+	IsStarred   bool      `json:"is_starred"`
+}
+
+type ArchiveAlert struct {
+	Name         string `json:"name"`
+	Pattern      string `json:"pattern"`
+	IsRegex      bool   `json:"is_regex"`
+	BatchMinutes int    `json:"batch_minutes"`
+}
+
+func (a *Archive) Validate() error {
+	if a.Format != ArchiveFormat || a.Version < 1 || a.Version > ArchiveVersion {
+		return ErrUnsupportedArchive
+	}
+	for _, feed := range a.Feeds {
+		if !IsHTTPURL(feed.URL) {
+			return ErrInvalidFeedURL
+		}
+		if err := feed.Retention.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, item := range a.Items {
+		if item.FeedURL == "" || item.Link == "" {
+			return ErrInvalidFeedItemLink
+		}
+	}
+	for _, alert := range a.Alerts {
+		rule := Alert{Name: alert.Name, Pattern: alert.Pattern, IsRegex: alert.IsRegex, BatchMinutes: alert.BatchMinutes}
+		if err := rule.validateRule(); err != nil {
+			return err
+		}
+	}
+
+	if digest := a.Preferences.Digest; digest != nil {
+		settings := DigestSettings{Frequency: digest.Frequency, SendHour: digest.SendHour, Weekday: digest.Weekday, Timezone: digest.Timezone}
+		if err := settings.Validate(); err != nil {
+			return err
+		}
+	}
+	if push := a.Preferences.Push; push != nil {
+		settings := PushSettings{QuietStart: push.QuietStart, QuietEnd: push.QuietEnd, Timezone: push.Timezone}
+		if err := settings.Validate(); err != nil {
+			return err
+		}
+	}
+	return a.Preferences.Retention.Validate()
+}
+
+// RestoreReport counts what a restore changed. Restoring the same archive
+// twice reports zero changes the second time.
+type RestoreReport struct {
+	FoldersCreated int `json:"folders_created"`
+	FeedsCreated   int `json:"feeds_created"`
+	FeedsUpdated   int `json:"feeds_updated"`
+	ItemsCreated   int `json:"items_created"`
+	ItemsUpdated   int `json:"items_updated"`
+	AlertsCreated  int `json:"alerts_created"`
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// ItemCursor marks a position in the item river. It carries every column
+// the active sort order uses so the next page can continue strictly after it.
+type ItemCursor struct {
+	PublishedAt time.Time
+	ID          int
+	FeedID      int
+	FeedName    string
+}
+
+type encodedCursor struct {
+	PublishedAt int64  `json:"p"`
+	ID          int    `json:"i"`
+	FeedID      int    `json:"f,omitempty"`
+	FeedName    string `json:"n,omitempty"`
+}
+
+func NewItemCursor(item FeedItem) *ItemCursor {
+	return &ItemCursor{
+		PublishedAt: item.PublishedAt,
+		ID:          item.ID,
+		FeedID:      item.FeedID,
+		FeedName:    item.FeedName,
+	}
+}
+
+func (c *ItemCursor) Encode() string {
+	raw, _ := json.Marshal(encodedCursor{
+		PublishedAt: c.PublishedAt.UnixMicro(),
+		ID:          c.ID,
+		FeedID:      c.FeedID,
+		FeedName:    c.FeedName,
+	})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeItemCursor(encoded string) (*ItemCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var decoded encodedCursor
+	if err := json.Unmarshal(raw, &decoded); err != nil || decoded.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &ItemCursor{
+		PublishedAt: time.UnixMicro(decoded.PublishedAt).UTC(),
+		ID:          decoded.ID,
+		FeedID:      decoded.FeedID,
+		FeedName:    decoded.FeedName,
+	}, nil
+}
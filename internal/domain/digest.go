@@ -0,0 +1,97 @@
+package domain
+
+import "time"
+
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestSettings controls a user's email digest. SendHour and Weekday are
+// interpreted in Timezone; Weekday only matters for weekly digests.
+// EnabledAt is when the digest was last switched on.
+type DigestSettings struct {
+	UserID           int
+	Frequency        string
+	SendHour         int
+	Weekday          time.Weekday
+	Timezone         string
+	UnsubscribeToken string
+	EnabledAt        time.Time
+	LastSentAt       *time.Time
+}
+
+func (d *DigestSettings) Validate() error {
+	switch d.Frequency {
+	case DigestOff, DigestDaily, DigestWeekly:
+	default:
+		return ErrInvalidDigestSettings
+	}
+	if d.SendHour < 0 || d.SendHour > 23 || d.Weekday < time.Sunday || d.Weekday > time.Saturday {
+		return ErrInvalidDigestSettings
+	}
+	if _, err := time.LoadLocation(d.Timezone); err != nil || d.Timezone == "" {
+		return ErrInvalidDigestSettings
+	}
+	return nil
+}
+
+func (d *DigestSettings) Enabled() bool {
+	return d.Frequency == DigestDaily || d.Frequency == DigestWeekly
+}
+
+// LastScheduled returns the most recent send slot at or before now, in the
+// user's timezone.
+func (d *DigestSettings) LastScheduled(now time.Time) time.Time {
+	location, err := time.LoadLocation(d.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	local := now.In(location)
+	slot := time.Date(local.Year(), local.Month(), local.Day(), d.SendHour, 0, 0, 0, location)
+	if slot.After(local) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	if d.Frequency == DigestWeekly {
+		for slot.Weekday() != d.Weekday {
+			slot = slot.AddDate(0, 0, -1)
+		}
+	}
+	return slot
+}
+
+// IsDue reports whether a digest should go out now: the latest slot has
+// passed and nothing was sent, nor the digest switched on, since. A newly
+// enabled digest therefore waits for its first slot.
+func (d *DigestSettings) IsDue(now time.Time) bool {
+	if !d.Enabled() {
+		return false
+	}
+	after := d.EnabledAt
+	if d.LastSentAt != nil && d.LastSentAt.After(after) {
+		after = *d.LastSentAt
+	}
+	return after.Before(d.LastScheduled(now))
+}
+
+// Period is the time between digests.
+func (d *DigestSettings) Period() time.Duration {
+	if d.Frequency == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Since is the earliest time an item may have been added to appear in a
+// digest sent at now. The first digest after the digest is switched on looks
+// back one period; later ones start one period before the previous digest,
+// so items left over from it carry over once without the rest of the
+// account's backlog coming along.
+func (d *DigestSettings) Since(now time.Time) time.Time {
+	if d.LastSentAt == nil || d.LastSentAt.Before(d.EnabledAt) {
+		return now.Add(-d.Period())
+	}
+	return d.LastSentAt.Add(-d.Period())
+}
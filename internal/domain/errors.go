@@ -7,6 +7,8 @@ var (
 	ErrUserNotFound      = errors.New("user not found")
 	ErrUserAlreadyExists = errors.New("user already exists")
 	ErrInvalidUserID     = errors.New("invalid user ID")
+	ErrUserDisabled      = errors.New("user account is disabled")
+	ErrDisableSelf       = errors.New("administrators cannot disable their own account")
 
 	ErrInvalidFeedName   = errors.New("invalid feed name")
 	ErrInvalidFeedURL    = errors.New("invalid feed URL")
@@ -14,17 +16,58 @@ var (
 	ErrFeedNotFound      = errors.New("feed not found")
 	ErrFeedAlreadyExists = errors.New("feed already exists for this user")
 	ErrUnauthorizedFeed  = errors.New("unauthorized to access this feed")
+	ErrFeedIconNotFound  = errors.New("feed icon not found")
+	ErrInvalidRetention  = errors.New("invalid retention policy")
+
+	ErrInvalidFolderName = errors.New("invalid folder name")
+	ErrFolderNotFound    = errors.New("folder not found")
 
 	ErrInvalidFeedItemTitle = errors.New("invalid feed item title")
 	ErrInvalidFeedItemLink  = errors.New("invalid feed item link")
 	ErrFeedItemNotFound     = errors.New("feed item not found")
+	ErrInvalidCursor        = errors.New("invalid pagination cursor")
+	ErrInvalidItemFilter    = errors.New("invalid item filter")
 
 	ErrInvalidOTP       = errors.New("invalid OTP")
 	ErrInvalidOTPExpiry = errors.New("invalid OTP expiry time")
 	ErrOTPExpired       = errors.New("OTP has expired")
 	ErrOTPNotFound      = errors.New("OTP not found")
+	ErrOTPAttempts      = errors.New("too many incorrect attempts for OTP")
+	ErrInvalidLoginLink = errors.New("invalid sign-in link")
+	ErrLoginLinkBrowser = errors.New("sign-in link was requested from another browser")
+	ErrSessionNotFound  = errors.New("session not found")
+
+	ErrOIDCLogin           = errors.New("single sign-on failed")
+	ErrOIDCEmailUnverified = errors.New("email address not verified by identity provider")
+
+	ErrTOTPNotFound       = errors.New("two-factor authentication not set up")
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrInvalidTOTPCode    = errors.New("invalid two-factor code")
+
+	ErrInvalidAPITokenName      = errors.New("invalid API token name")
+	ErrAPITokenNotFound         = errors.New("API token not found")
+	ErrInvalidAPIToken          = errors.New("invalid API token")
+	ErrWeakPassword             = errors.New("password is too short")
+	ErrInvalidImportDecision    = errors.New("invalid import decision")
+	ErrUnsupportedArchive       = errors.New("unsupported archive format or version")
+	ErrInvalidDigestSettings    = errors.New("invalid digest settings")
+	ErrDigestNotFound           = errors.New("digest subscription not found")
+	ErrInvalidAlert             = errors.New("invalid alert")
+	ErrInvalidAlertPattern      = errors.New("invalid alert pattern")
+	ErrAlertNotFound            = errors.New("alert not found")
+	ErrInvalidWebhook           = errors.New("invalid webhook")
+	ErrInvalidWebhookURL        = errors.New("invalid webhook URL")
+	ErrWebhookNotFound          = errors.New("webhook not found")
+	ErrInvalidPushSubscription  = errors.New("invalid push subscription")
+	ErrInvalidPushSettings      = errors.New("invalid push settings")
+	ErrPushSubscriptionNotFound = errors.New("push subscription not found")
+	ErrVAPIDKeysNotFound        = errors.New("VAPID keys not found")
+	ErrInvalidLocale            = errors.New("unsupported language")
+	ErrInvalidPasskey           = errors.New("invalid passkey")
+	ErrPasskeyNotFound          = errors.New("passkey not found")
+	ErrPasskeyVerification      = errors.New("passkey verification failed")
 
 	ErrDatabaseConnection = errors.New("database connection error")
 	ErrDatabaseQuery      = errors.New("database query error")
 	ErrDuplicateEntry     = errors.New("duplicate entry")
-)
\ No newline at end of file
+)
@@ -1,13 +1,29 @@
 package domain
 
-import "time"
+import (
+	"net/url"
+	"time"
+)
 
 type Feed struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	URL       string    `json:"url"`
-	UserID    int       `json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int             `json:"id"`
+	Name      string          `json:"name"`
+	URL       string          `json:"url"`
+	UserID    int             `json:"user_id"`
+	FolderID  int             `json:"folder_id,omitempty"`
+	Folder    string          `json:"folder,omitempty"`
+	SiteURL   string          `json:"site_url,omitempty"`
+	Retention RetentionPolicy `json:"retention"`
+	CreatedAt time.Time       `json:"created_at"`
+
+	LastRefreshedAt *time.Time `json:"last_refreshed_at,omitempty"`
+
+	// LastError is the error of the most recent failed refresh, and
+	// FailureCount the number of refreshes that have failed since the last
+	// success.
+	LastError    string     `json:"last_error,omitempty"`
+	FailureCount int        `json:"failure_count,omitempty"`
+	LastFailedAt *time.Time `json:"last_failed_at,omitempty"`
 }
 
 func (f *Feed) Validate() error {
@@ -21,4 +37,11 @@ func (f *Feed) Validate() error {
 		return ErrInvalidUserID
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// IsHTTPURL reports whether rawURL is an absolute http or https URL, the only
+// kind of feed URL the refresh loop may fetch.
+func IsHTTPURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	return err == nil && parsed.Host != "" && (parsed.Scheme == "http" || parsed.Scheme == "https")
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+type FeedIcon struct {
+	FeedID    int       `json:"feed_id"`
+	MimeType  string    `json:"mime_type"`
+	Data      []byte    `json:"-"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// HasData reports whether the icon was fetched successfully; failed fetches
+// are stored empty so they are not retried on every refresh.
+func (i *FeedIcon) HasData() bool {
+	return len(i.Data) > 0
+}
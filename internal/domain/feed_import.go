@@ -0,0 +1,78 @@
+package domain
+
+const (
+	FeedImportNew       = "new"
+	FeedImportDuplicate = "duplicate"
+	FeedImportInvalid   = "invalid"
+
+	FeedImportActionAdd       = "add"
+	FeedImportActionSkip      = "skip"
+	FeedImportActionRename    = "rename"
+	FeedImportActionOverwrite = "overwrite"
+)
+
+// FeedImportEntry is one feed read from an uploaded OPML or JSON file. Line
+// is the entry's line in an OPML file, or its position in a JSON file.
+type FeedImportEntry struct {
+	Line    int    `json:"line"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	SiteURL string `json:"site_url,omitempty"`
+	Folder  string `json:"folder,omitempty"`
+}
+
+// FeedImportPreviewEntry classifies an entry before anything is written.
+// Duplicates carry the feed they collide with, which is either an existing
+// subscription or an earlier entry in the same file (ExistingFeedID 0).
+type FeedImportPreviewEntry struct {
+	FeedImportEntry
+	Status         string `json:"status"`
+	Reason         string `json:"reason,omitempty"`
+	ExistingFeedID int    `json:"existing_feed_id,omitempty"`
+	ExistingName   string `json:"existing_name,omitempty"`
+}
+
+type FeedImportPreview struct {
+	Entries    []FeedImportPreviewEntry `json:"entries"`
+	New        int                      `json:"new"`
+	Duplicates int                      `json:"duplicates"`
+	Invalid    int                      `json:"invalid"`
+}
+
+// FeedImportDecision is the user's choice for one previewed entry. Rename
+// gives ExistingFeedID the entry's name; Overwrite replaces its name, URL and
+// folder with the entry's.
+type FeedImportDecision struct {
+	Entry          FeedImportEntry
+	Action         string
+	ExistingFeedID int
+}
+
+func (d *FeedImportDecision) Validate() error {
+	switch d.Action {
+	case FeedImportActionSkip:
+		return nil
+	case FeedImportActionAdd:
+	case FeedImportActionRename, FeedImportActionOverwrite:
+		if d.ExistingFeedID <= 0 {
+			return ErrInvalidImportDecision
+		}
+	default:
+		return ErrInvalidImportDecision
+	}
+
+	if d.Entry.Name == "" {
+		return ErrInvalidFeedName
+	}
+	if d.Entry.URL == "" {
+		return ErrInvalidFeedURL
+	}
+	return nil
+}
+
+type FeedImportReport struct {
+	Added       int `json:"added"`
+	Renamed     int `json:"renamed"`
+	Overwritten int `json:"overwritten"`
+	Skipped     int `json:"skipped"`
+}
@@ -11,6 +11,15 @@ type FeedItem struct {
 	FeedName    string    `json:"feed_name"`
 	PublishedAt time.Time `json:"published_at"`
 	IsNew       bool      `json:"is_new"`
+	IsRead      bool      `json:"is_read"`
+	IsStarred   bool      `json:"is_starred"`
+}
+
+// UnreadCount summarises a feed's unread items.
+type UnreadCount struct {
+	FeedID   int
+	Count    int
+	NewestAt time.Time
 }
 
 func (fi *FeedItem) Validate() error {
@@ -24,4 +33,4 @@ func (fi *FeedItem) Validate() error {
 		return ErrInvalidFeedID
 	}
 	return nil
-}
\ No newline at end of file
+}
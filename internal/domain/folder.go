@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+type Folder struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	UserID    int       `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (f *Folder) Validate() error {
+	if strings.TrimSpace(f.Name) == "" {
+		return ErrInvalidFolderName
+	}
+	if f.UserID <= 0 {
+		return ErrInvalidUserID
+	}
+	return nil
+}
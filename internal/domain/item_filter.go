@@ -0,0 +1,72 @@
+package domain
+
+import "time"
+
+const (
+	ItemStatusAll     = "all"
+	ItemStatusUnread  = "unread"
+	ItemStatusStarred = "starred"
+	ItemStatusRead    = "read"
+
+	ItemSortNewest = "newest"
+	ItemSortOldest = "oldest"
+	ItemSortFeed   = "feed"
+)
+
+// ItemFilter narrows the item river. Zero values mean "no restriction";
+// From is inclusive and To is exclusive. Unread further limits a starred
+// filter to unread items, for sync clients that combine the two.
+type ItemFilter struct {
+	FeedID   int
+	FolderID int
+	Status   string
+	Unread   bool
+	From     time.Time
+	To       time.Time
+	Sort     string
+}
+
+func (f *ItemFilter) Validate() error {
+	switch f.Status {
+	case "", ItemStatusAll, ItemStatusUnread, ItemStatusStarred, ItemStatusRead:
+	default:
+		return ErrInvalidItemFilter
+	}
+
+	switch f.Sort {
+	case "", ItemSortNewest, ItemSortOldest, ItemSortFeed:
+	default:
+		return ErrInvalidItemFilter
+	}
+
+	if f.FeedID < 0 || f.FolderID < 0 {
+		return ErrInvalidItemFilter
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return ErrInvalidItemFilter
+	}
+	return nil
+}
+
+// NarrowStatus restricts the filter to items that also have status, failing
+// when the combination cannot be expressed.
+func (f *ItemFilter) NarrowStatus(status string) error {
+	switch {
+	case f.Status == "" || f.Status == ItemStatusAll || f.Status == status:
+		f.Status = status
+	case f.Status == ItemStatusStarred && status == ItemStatusUnread,
+		f.Status == ItemStatusUnread && status == ItemStatusStarred:
+		f.Status = ItemStatusStarred
+		f.Unread = true
+	default:
+		return ErrInvalidItemFilter
+	}
+	return nil
+}
+
+func (f *ItemFilter) SortOrder() string {
+	if f.Sort == "" {
+		return ItemSortNewest
+	}
+	return f.Sort
+}
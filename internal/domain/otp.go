@@ -1,11 +1,20 @@
 package domain
 
-import "time"
+import (
+	"crypto/subtle"
+	"time"
+)
+
+// MaxOTPAttempts is how many codes may be tried against one OTP before it
+// stops working and a new one has to be requested.
+const MaxOTPAttempts = 5
 
 type OTP struct {
 	ID        int       `json:"id"`
 	Email     string    `json:"email"`
-	OTP       string    `json:"otp"`
+	OTPHash   string    `json:"-"`
+	LinkHash  string    `json:"-"`
+	Attempts  int       `json:"attempts"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
@@ -13,15 +22,23 @@ func (o *OTP) IsExpired() bool {
 	return time.Now().After(o.ExpiresAt)
 }
 
-func (o *OTP) IsValid(otpToVerify string) bool {
-	return o.OTP == otpToVerify && !o.IsExpired()
+// IsExhausted reports whether every attempt at the code has been used, which
+// also retires the sign-in link sent with it.
+func (o *OTP) IsExhausted() bool {
+	return o.Attempts >= MaxOTPAttempts
+}
+
+// Matches compares the stored hash with the hash of a submitted code in
+// constant time.
+func (o *OTP) Matches(otpHash string) bool {
+	return o.OTPHash != "" && subtle.ConstantTimeCompare([]byte(o.OTPHash), []byte(otpHash)) == 1
 }
 
 func (o *OTP) Validate() error {
 	if o.Email == "" {
 		return ErrInvalidEmail
 	}
-	if o.OTP == "" {
+	if o.OTPHash == "" {
 		return ErrInvalidOTP
 	}
 	if o.ExpiresAt.IsZero() {
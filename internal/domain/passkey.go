@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+const MaxPasskeyNameLength = 100
+
+// Passkey is a WebAuthn credential registered to a user. Only the public key
+// is stored; SignCount and BackupState are updated on every sign-in.
+type Passkey struct {
+	ID              int
+	UserID          int
+	Name            string
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType string
+	AAGUID          []byte
+	SignCount       uint32
+	Transports      []string
+	BackupEligible  bool
+	BackupState     bool
+	CreatedAt       time.Time
+	LastUsedAt      *time.Time
+}
+
+func (p *Passkey) Validate() error {
+	name := strings.TrimSpace(p.Name)
+	if name == "" || len(name) > MaxPasskeyNameLength {
+		return ErrInvalidPasskey
+	}
+	if len(p.CredentialID) == 0 || len(p.PublicKey) == 0 {
+		return ErrInvalidPasskey
+	}
+	if p.UserID <= 0 {
+		return ErrInvalidUserID
+	}
+	return nil
+}
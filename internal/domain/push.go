@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"net/url"
+	"time"
+)
+
+// PushSubscription is a browser's Web Push endpoint and the keys used to
+// encrypt payloads for it.
+type PushSubscription struct {
+	ID        int
+	UserID    int
+	Endpoint  string
+	P256dh    string
+	Auth      string
+	UserAgent string
+	CreatedAt time.Time
+}
+
+func (p *PushSubscription) Validate() error {
+	endpoint, err := url.Parse(p.Endpoint)
+	if err != nil || (endpoint.Scheme != "https" && endpoint.Scheme != "http") || endpoint.Host == "" {
+		return ErrInvalidPushSubscription
+	}
+	if p.P256dh == "" || p.Auth == "" {
+		return ErrInvalidPushSubscription
+	}
+	if p.UserID <= 0 {
+		return ErrInvalidUserID
+	}
+	return nil
+}
+
+// PushSettings holds a user's quiet hours. No notifications are sent from
+// QuietStart until QuietEnd, both hours in Timezone; the window may wrap
+// past midnight, and equal hours mean no quiet hours.
+type PushSettings struct {
+	UserID     int
+	QuietStart int
+	QuietEnd   int
+	Timezone   string
+}
+
+func (p *PushSettings) Validate() error {
+	if p.QuietStart < 0 || p.QuietStart > 23 || p.QuietEnd < 0 || p.QuietEnd > 23 {
+		return ErrInvalidPushSettings
+	}
+	if _, err := time.LoadLocation(p.Timezone); err != nil || p.Timezone == "" {
+		return ErrInvalidPushSettings
+	}
+	return nil
+}
+
+func (p *PushSettings) InQuietHours(now time.Time) bool {
+	if p.QuietStart == p.QuietEnd {
+		return false
+	}
+
+	location, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	hour := now.In(location).Hour()
+	if p.QuietStart < p.QuietEnd {
+		return hour >= p.QuietStart && hour < p.QuietEnd
+	}
+	return hour >= p.QuietStart || hour < p.QuietEnd
+}
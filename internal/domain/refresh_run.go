@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// What started a refresh run.
+const (
+	RefreshTriggerPageView = "page_view"
+	RefreshTriggerManual   = "manual"
+	RefreshTriggerAPI      = "api"
+	RefreshTriggerDigest   = "digest"
+	RefreshTriggerAdmin    = "admin"
+)
+
+// RefreshRun records one refresh of a user's feeds, or of a single feed
+// when FeedID is set.
+type RefreshRun struct {
+	ID             int       `json:"id"`
+	UserID         int       `json:"user_id"`
+	UserEmail      string    `json:"user_email,omitempty"`
+	FeedID         int       `json:"feed_id,omitempty"`
+	FeedName       string    `json:"feed_name,omitempty"`
+	Trigger        string    `json:"trigger"`
+	FeedsTotal     int       `json:"feeds_total"`
+	FeedsFailed    int       `json:"feeds_failed"`
+	ItemsProcessed int       `json:"items_processed"`
+	ItemsNew       int       `json:"items_new"`
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at"`
+}
+
+func (r *RefreshRun) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// RetentionPolicy limits how long and how many items are kept per feed.
+// A zero value for either field means "inherit" from the next level up
+// (feed -> user -> instance default).
+type RetentionPolicy struct {
+	MaxAgeDays int `json:"max_age_days"`
+	MaxItems   int `json:"max_items"`
+}
+
+func (p RetentionPolicy) Validate() error {
+	if p.MaxAgeDays < 0 || p.MaxItems < 0 {
+		return ErrInvalidRetention
+	}
+	return nil
+}
+
+func (p RetentionPolicy) IsZero() bool {
+	return p.MaxAgeDays == 0 && p.MaxItems == 0
+}
+
+type CleanupReport struct {
+	DeletedByAge   int64         `json:"deleted_by_age"`
+	DeletedByCount int64         `json:"deleted_by_count"`
+	StartedAt      time.Time     `json:"started_at"`
+	Duration       time.Duration `json:"duration"`
+}
+
+func (r CleanupReport) Total() int64 {
+	return r.DeletedByAge + r.DeletedByCount
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// MaxUserAgentLength caps the user agent stored with a session.
+const MaxUserAgentLength = 512
+
+// Session is a server-side browser session. The browser only holds a random
+// token; the session's values and metadata live in the database, so a
+// session can be revoked from anywhere.
+type Session struct {
+	ID         int
+	UserID     int
+	Data       []byte
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	Current    bool
+}
+
+func (s *Session) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
+
+// Device describes the session's browser and operating system from its
+// user agent, such as "Firefox on Linux".
+func (s *Session) Device() string {
+	ua := s.UserAgent
+	var browser, system string
+
+	switch {
+	case strings.Contains(ua, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(ua, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "Chrome/") || strings.Contains(ua, "CriOS/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "Safari/"):
+		browser = "Safari"
+	}
+
+	switch {
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"):
+		system = "iOS"
+	case strings.Contains(ua, "Android"):
+		system = "Android"
+	case strings.Contains(ua, "Windows"):
+		system = "Windows"
+	case strings.Contains(ua, "Mac OS X"):
+		system = "macOS"
+	case strings.Contains(ua, "CrOS"):
+		system = "ChromeOS"
+	case strings.Contains(ua, "Linux"):
+		system = "Linux"
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	case ua != "":
+		return ua
+	}
+	return "Unknown browser"
+}
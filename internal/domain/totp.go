@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// RecoveryCodeCount is the number of recovery codes issued at a time.
+const RecoveryCodeCount = 10
+
+// TOTPCredential is a user's authenticator app secret. It only protects
+// sign-in once ConfirmedAt is set, after the user has proven their app
+// produces matching codes.
+type TOTPCredential struct {
+	UserID       int
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+func (t *TOTPCredential) IsEnabled() bool {
+	return t.ConfirmedAt != nil
+}
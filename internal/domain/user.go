@@ -2,10 +2,29 @@ package domain
 
 import "time"
 
+// User roles. Administrators are promoted through ADMIN_EMAILS.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID        int       `json:"id"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         int        `json:"id"`
+	Email      string     `json:"email"`
+	Locale     string     `json:"locale"`
+	Role       string     `json:"role"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// IsDisabled reports whether an administrator has blocked the account from
+// signing in and receiving email.
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
 }
 
 func (u *User) Validate() error {
@@ -0,0 +1,13 @@
+package domain
+
+type UserSettings struct {
+	UserID    int             `json:"user_id"`
+	Retention RetentionPolicy `json:"retention"`
+}
+
+func (s *UserSettings) Validate() error {
+	if s.UserID <= 0 {
+		return ErrInvalidUserID
+	}
+	return s.Retention.Validate()
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/middleware"
+	"rss-reader/internal/service"
+	"strconv"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler serves the instance-wide admin area. Its routes are wrapped
+// in AdminMiddleware.RequireAdmin.
+type AdminHandler struct {
+	adminService   *service.AdminService
+	authMiddleware *middleware.AuthMiddleware
+	adminTemplate  *template.Template
+}
+
+func NewAdminHandler(adminService *service.AdminService, authMiddleware *middleware.AuthMiddleware) *AdminHandler {
+	adminTemplate, err := template.New("admin.html").Funcs(template.FuncMap{
+		"bytes": formatBytes,
+	}).ParseFiles("templates/admin.html")
+	if err != nil {
+		log.Fatalf("Failed to parse admin template: %v", err)
+	}
+
+	return &AdminHandler{
+		adminService:   adminService,
+		authMiddleware: authMiddleware,
+		adminTemplate:  adminTemplate,
+	}
+}
+
+func (h *AdminHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	overview, err := h.adminService.GetOverview()
+	if err != nil {
+		log.Printf("Error getting admin overview: %v", err)
+		http.Error(w, "Error getting admin overview", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Overview":      overview,
+		"CurrentUserID": userID,
+		"csrfField":     csrf.TemplateField(r),
+	}
+	switch r.URL.Query().Get("status") {
+	case "disabled":
+		data["Message"] = "User disabled and signed out everywhere."
+	case "enabled":
+		data["Message"] = "User enabled."
+	case "refreshed":
+		data["Message"] = "Feed refreshed. See the latest refresh run below."
+	case "disable-self":
+		data["Error"] = "You cannot disable your own account."
+	}
+
+	if err := h.adminTemplate.Execute(w, data); err != nil {
+		log.Printf("Error executing template: %v", err)
+		http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *AdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserDisabled(w, r, true)
+}
+
+func (h *AdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserDisabled(w, r, false)
+}
+
+func (h *AdminHandler) setUserDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	adminID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.adminService.SetUserDisabled(adminID, userID, disabled); err != nil {
+		if err == domain.ErrDisableSelf {
+			http.Redirect(w, r, "/admin?status=disable-self", http.StatusFound)
+			return
+		}
+		log.Printf("Error updating user %d: %v", userID, err)
+		http.Error(w, "Error updating user", http.StatusNotFound)
+		return
+	}
+
+	status := "enabled"
+	if disabled {
+		status = "disabled"
+	}
+	http.Redirect(w, r, "/admin?status="+status, http.StatusFound)
+}
+
+func (h *AdminHandler) RefreshFeed(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	feedID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.adminService.RefreshFeed(adminID, feedID); err != nil {
+		log.Printf("Error refreshing feed %d: %v", feedID, err)
+		http.Error(w, "Error refreshing feed", http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, "/admin?status=refreshed", http.StatusFound)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/middleware"
+	"rss-reader/internal/service"
+	"strconv"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+)
+
+const defaultAlertBatchMinutes = 60
+
+type AlertHandler struct {
+	alertService   *service.AlertService
+	authMiddleware *middleware.AuthMiddleware
+	alertsTemplate *template.Template
+}
+
+func NewAlertHandler(alertService *service.AlertService, authMiddleware *middleware.AuthMiddleware) *AlertHandler {
+	alertsTemplate, err := template.ParseFiles("templates/alerts.html")
+	if err != nil {
+		log.Fatalf("Failed to parse alerts template: %v", err)
+	}
+
+	return &AlertHandler{
+		alertService:   alertService,
+		authMiddleware: authMiddleware,
+		alertsTemplate: alertsTemplate,
+	}
+}
+
+func (h *AlertHandler) Alerts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if r.Method == "POST" {
+		h.createAlert(w, r, userID)
+		return
+	}
+
+	h.showAlertsPage(w, r, userID, nil)
+}
+
+func (h *AlertHandler) showAlertsPage(w http.ResponseWriter, r *http.Request, userID int, data map[string]interface{}) {
+	alerts, err := h.alertService.GetAlerts(userID)
+	if err != nil {
+		log.Printf("Error getting alerts for user %d: %v", userID, err)
+		http.Error(w, "Error getting alerts", http.StatusInternalServerError)
+		return
+	}
+
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	if _, ok := data["BatchMinutes"]; !ok {
+		data["BatchMinutes"] = defaultAlertBatchMinutes
+	}
+	data["Alerts"] = alerts
+	data["MaxBatchMinutes"] = domain.MaxAlertBatchMinutes
+	data["csrfField"] = csrf.TemplateField(r)
+
+	if err := h.alertsTemplate.Execute(w, data); err != nil {
+		log.Printf("Error executing template: %v", err)
+		http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *AlertHandler) createAlert(w http.ResponseWriter, r *http.Request, userID int) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	name := r.FormValue("name")
+	pattern := r.FormValue("pattern")
+	isRegex := r.FormValue("match") == "regex"
+	batchMinutes, err := strconv.Atoi(r.FormValue("batch_minutes"))
+	if err != nil {
+		batchMinutes = -1
+	}
+
+	if _, err := h.alertService.CreateAlert(userID, name, pattern, isRegex, batchMinutes); err != nil {
+		log.Printf("Error creating alert for user %d: %v", userID, err)
+		message := "Could not create alert. Please give it a name, a pattern and a batching window between 0 and 1440 minutes."
+		if err == domain.ErrInvalidAlertPattern {
+			message = "The regular expression is not valid."
+		}
+		h.showAlertsPage(w, r, userID, map[string]interface{}{
+			"Error":        message,
+			"Name":         name,
+			"Pattern":      pattern,
+			"IsRegex":      isRegex,
+			"BatchMinutes": r.FormValue("batch_minutes"),
+		})
+		return
+	}
+
+	h.showAlertsPage(w, r, userID, map[string]interface{}{
+		"Message": "Alert created. Matching items from future refreshes will be emailed to you.",
+	})
+}
+
+func (h *AlertHandler) DeleteAlert(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	alertID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid alert ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.alertService.DeleteAlert(alertID, userID); err != nil {
+		log.Printf("Error deleting alert %d: %v", alertID, err)
+		http.Error(w, "Error deleting alert", http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, "/alerts", http.StatusFound)
+}
@@ -0,0 +1,356 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/middleware"
+	"rss-reader/internal/service"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const maxAPIPageSize = 200
+
+// APIHandler serves the versioned JSON API under /api/v1. Requests are
+// authenticated with personal API tokens instead of the session cookie.
+type APIHandler struct {
+	feedService      *service.FeedService
+	retentionService *service.RetentionService
+	apiAuth          *middleware.APIAuthMiddleware
+}
+
+func NewAPIHandler(
+	feedService *service.FeedService,
+	retentionService *service.RetentionService,
+	apiAuth *middleware.APIAuthMiddleware,
+) *APIHandler {
+	return &APIHandler{
+		feedService:      feedService,
+		retentionService: retentionService,
+		apiAuth:          apiAuth,
+	}
+}
+
+type apiFeedRequest struct {
+	Name      *string                 `json:"name"`
+	URL       *string                 `json:"url"`
+	Folder    *string                 `json:"folder"`
+	Retention *domain.RetentionPolicy `json:"retention"`
+}
+
+type apiItemRequest struct {
+	IsRead    *bool `json:"is_read"`
+	IsStarred *bool `json:"is_starred"`
+}
+
+func (h *APIHandler) ListFeeds(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	feeds, err := h.feedService.GetFeedsByUserID(userID)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if feeds == nil {
+		feeds = []domain.Feed{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"feeds": feeds})
+}
+
+func (h *APIHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	feedID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeAPIError(w, domain.ErrInvalidFeedID)
+		return
+	}
+
+	feed, err := h.feedService.GetFeedByID(feedID, userID)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, feed)
+}
+
+func (h *APIHandler) CreateFeed(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	var request apiFeedRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+
+	// Check the retention before creating the feed so that a rejected
+	// request does not leave a feed behind for a retry to duplicate.
+	if request.Retention != nil {
+		if err := request.Retention.Validate(); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	}
+
+	feed, err := h.feedService.CreateFeed(stringValue(request.Name), stringValue(request.URL), stringValue(request.Folder), userID)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if request.Retention != nil {
+		if err := h.retentionService.UpdateFeedRetention(feed.ID, userID, *request.Retention); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	}
+
+	feed, err = h.feedService.GetFeedByID(feed.ID, userID)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, feed)
+}
+
+// UpdateFeed applies the fields present in the body and leaves the rest as
+// they are.
+func (h *APIHandler) UpdateFeed(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	feedID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeAPIError(w, domain.ErrInvalidFeedID)
+		return
+	}
+
+	var request apiFeedRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+
+	// As in CreateFeed, a rejected retention must not leave the other
+	// fields half applied.
+	if request.Retention != nil {
+		if err := request.Retention.Validate(); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	}
+
+	feed, err := h.feedService.GetFeedByID(feedID, userID)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	name, url, folder := feed.Name, feed.URL, feed.Folder
+	if request.Name != nil {
+		name = *request.Name
+	}
+	if request.URL != nil {
+		url = *request.URL
+	}
+	if request.Folder != nil {
+		folder = *request.Folder
+	}
+
+	if err := h.feedService.UpdateFeed(feedID, name, url, folder, userID); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if request.Retention != nil {
+		if err := h.retentionService.UpdateFeedRetention(feedID, userID, *request.Retention); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	}
+
+	feed, err = h.feedService.GetFeedByID(feedID, userID)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, feed)
+}
+
+func (h *APIHandler) DeleteFeed(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	feedID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeAPIError(w, domain.ErrInvalidFeedID)
+		return
+	}
+
+	if err := h.feedService.DeleteFeed(feedID, userID); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) ListFolders(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	folders, err := h.feedService.GetFoldersByUserID(userID)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if folders == nil {
+		folders = []domain.Folder{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"folders": folders})
+}
+
+// ListItems accepts the same filter parameters as the /feeds page plus
+// cursor and limit, and returns next_cursor while more items remain.
+func (h *APIHandler) ListItems(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+	query := r.URL.Query()
+
+	filter, err := parseItemFilter(query)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	var cursor *domain.ItemCursor
+	if value := query.Get("cursor"); value != "" {
+		cursor, err = domain.DecodeItemCursor(value)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	}
+
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxAPIPageSize {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be between 1 and 200"})
+			return
+		}
+	}
+
+	items, nextCursor, err := h.feedService.ListItems(userID, filter, cursor, limit)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if items == nil {
+		items = []domain.FeedItem{}
+	}
+
+	response := map[string]interface{}{"items": items}
+	if nextCursor != "" {
+		response["next_cursor"] = nextCursor
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *APIHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	itemID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeAPIError(w, domain.ErrFeedItemNotFound)
+		return
+	}
+
+	var request apiItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+
+	if request.IsRead != nil {
+		if err := h.feedService.SetItemRead(itemID, userID, *request.IsRead); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	}
+
+	if request.IsStarred != nil {
+		if err := h.feedService.SetItemStarred(itemID, userID, *request.IsStarred); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	totalItems, newItems, err := h.feedService.RefreshFeeds(userID, domain.RefreshTriggerAPI)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{
+		"processed": totalItems,
+		"new":       newItems,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// writeAPIError maps domain errors to HTTP status codes, hiding internal
+// failures behind a generic message.
+func writeAPIError(w http.ResponseWriter, err error) {
+	status, message := apiErrorStatus(err)
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// apiErrorStatus maps domain errors onto an HTTP status and a message that is
+// safe to show to API clients; anything unexpected is logged.
+func apiErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, domain.ErrFeedNotFound),
+		errors.Is(err, domain.ErrFeedItemNotFound),
+		errors.Is(err, domain.ErrFolderNotFound):
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, domain.ErrFeedAlreadyExists):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, domain.ErrInvalidFeedName),
+		errors.Is(err, domain.ErrInvalidFeedURL),
+		errors.Is(err, domain.ErrInvalidFeedID),
+		errors.Is(err, domain.ErrInvalidItemFilter),
+		errors.Is(err, domain.ErrInvalidCursor),
+		errors.Is(err, domain.ErrInvalidRetention):
+		return http.StatusBadRequest, err.Error()
+	}
+
+	log.Printf("API error: %v", err)
+	return http.StatusInternalServerError, "internal server error"
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -1,37 +1,82 @@
 package handler
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"html/template"
 	"log"
 	"net/http"
+	"rss-reader/internal/domain"
 	"rss-reader/internal/middleware"
 	"rss-reader/internal/service"
+	"time"
 
 	"github.com/gorilla/csrf"
 )
 
+// loginBindingKey is the session key holding the random value that ties a
+// sign-in link to the browser that requested it.
+const loginBindingKey = "login_binding"
+
+// Session keys for a sign-in waiting for the user's two-factor code, and how
+// long it may wait.
+const (
+	twoFactorUserKey    = "two_factor_user_id"
+	twoFactorStartedKey = "two_factor_started_at"
+	twoFactorTimeout    = 5 * time.Minute
+)
+
+// LoginOptions selects the sign-in methods offered on the login page.
+type LoginOptions struct {
+	// OTPEnabled allows signing in with an emailed code or link.
+	OTPEnabled bool
+	// SSOName labels the single sign-on button, empty when single sign-on
+	// is not configured.
+	SSOName string
+}
+
 type AuthHandler struct {
-	authService     *service.AuthService
-	authMiddleware  *middleware.AuthMiddleware
-	loginTemplate   *template.Template
+	authService       *service.AuthService
+	totpService       *service.TOTPService
+	authMiddleware    *middleware.AuthMiddleware
+	loginOptions      LoginOptions
+	loginTemplate     *template.Template
+	twoFactorTemplate *template.Template
 }
 
-func NewAuthHandler(authService *service.AuthService, authMiddleware *middleware.AuthMiddleware) *AuthHandler {
+func NewAuthHandler(authService *service.AuthService, totpService *service.TOTPService, authMiddleware *middleware.AuthMiddleware, loginOptions LoginOptions) *AuthHandler {
 	loginTemplate, err := template.ParseFiles("templates/login.html")
 	if err != nil {
 		log.Fatalf("Failed to parse login template: %v", err)
 	}
 
+	twoFactorTemplate, err := template.ParseFiles("templates/login_two_factor.html")
+	if err != nil {
+		log.Fatalf("Failed to parse two-factor login template: %v", err)
+	}
+
 	return &AuthHandler{
-		authService:    authService,
-		authMiddleware: authMiddleware,
-		loginTemplate:  loginTemplate,
+		authService:       authService,
+		totpService:       totpService,
+		authMiddleware:    authMiddleware,
+		loginOptions:      loginOptions,
+		loginTemplate:     loginTemplate,
+		twoFactorTemplate: twoFactorTemplate,
 	}
 }
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "GET" {
-		h.showLoginPage(w, r, nil)
+		var data map[string]string
+		switch r.URL.Query().Get("error") {
+		case "sso":
+			data = map[string]string{"Error": "Single sign-on failed. Please try again."}
+		case "sso-unverified":
+			data = map[string]string{"Error": "Your identity provider has not verified your email address."}
+		case "disabled":
+			data = map[string]string{"Error": "This account has been disabled."}
+		}
+		h.showLoginPage(w, r, data)
 		return
 	}
 
@@ -52,7 +97,10 @@ func (h *AuthHandler) showLoginPage(w http.ResponseWriter, r *http.Request, data
 		"Email":     data["Email"],
 		"Message":   data["Message"],
 		"Error":     data["Error"],
-		"csrfField": csrf.TemplateField(r),
+		"CSRFToken":  csrf.Token(r),
+		"csrfField":  csrf.TemplateField(r),
+		"OTPEnabled": h.loginOptions.OTPEnabled,
+		"SSOName":    h.loginOptions.SSOName,
 	}
 
 	h.loginTemplate.Execute(w, templateData)
@@ -64,6 +112,11 @@ func (h *AuthHandler) handleLoginPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.loginOptions.OTPEnabled {
+		h.showLoginPage(w, r, map[string]string{"Error": "Email sign-in is disabled."})
+		return
+	}
+
 	email := r.FormValue("email")
 	otp := r.FormValue("otp")
 
@@ -75,9 +128,9 @@ func (h *AuthHandler) handleLoginPost(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *AuthHandler) handleSendOTP(w http.ResponseWriter, r *http.Request, email string) {
-	err := h.authService.SendOTP(email)
+	binding, err := h.newLoginBinding(w, r)
 	if err != nil {
-		log.Printf("Error sending OTP to %s: %v", email, err)
+		log.Printf("Error saving login binding for %s: %v", email, err)
 		h.showLoginPage(w, r, map[string]string{
 			"Email": email,
 			"Error": "Failed to send OTP. Please try again.",
@@ -85,9 +138,23 @@ func (h *AuthHandler) handleSendOTP(w http.ResponseWriter, r *http.Request, emai
 		return
 	}
 
+	err = h.authService.SendOTP(email, binding)
+	if err != nil {
+		log.Printf("Error sending OTP to %s: %v", email, err)
+		message := "Failed to send OTP. Please try again."
+		if err == domain.ErrUserDisabled {
+			message = "This account has been disabled."
+		}
+		h.showLoginPage(w, r, map[string]string{
+			"Email": email,
+			"Error": message,
+		})
+		return
+	}
+
 	h.showLoginPage(w, r, map[string]string{
 		"Email":   email,
-		"Message": "An OTP has been sent to your email.",
+		"Message": "An OTP has been sent to your email. Enter it below, or open the link in the email in this browser.",
 	})
 }
 
@@ -95,23 +162,181 @@ func (h *AuthHandler) handleVerifyOTP(w http.ResponseWriter, r *http.Request, em
 	user, err := h.authService.VerifyOTP(email, otp)
 	if err != nil {
 		log.Printf("OTP verification failed for %s: %v", email, err)
+		message := "Invalid or expired OTP. Please try again."
+		if err == domain.ErrOTPAttempts {
+			message = "Too many incorrect codes. Please request a new one."
+		}
 		h.showLoginPage(w, r, map[string]string{
 			"Email": email,
-			"Error": "Invalid or expired OTP. Please try again.",
+			"Error": message,
 		})
 		return
 	}
 
+	h.signIn(w, r, user)
+}
+
+// LoginLink signs the user in from the link in an OTP email.
+func (h *AuthHandler) LoginLink(w http.ResponseWriter, r *http.Request) {
+	if !h.loginOptions.OTPEnabled {
+		h.showLoginPage(w, r, map[string]string{"Error": "Email sign-in is disabled."})
+		return
+	}
+
+	query := r.URL.Query()
+	email := query.Get("email")
+
+	var binding string
+	if session, err := h.authMiddleware.GetSession(r); err == nil {
+		binding, _ = session.Values[loginBindingKey].(string)
+	}
+
+	user, err := h.authService.VerifyLoginLink(email, query.Get("token"), query.Get("expires"), query.Get("sig"), binding)
+	if err != nil {
+		log.Printf("Sign-in link verification failed for %s: %v", email, err)
+		message := "This sign-in link is invalid or has already been used. Please request a new one."
+		switch err {
+		case domain.ErrLoginLinkBrowser:
+			message = "This sign-in link only works in the browser where it was requested. Enter the code from the email instead."
+		case domain.ErrOTPExpired:
+			message = "This sign-in link has expired. Please request a new one."
+		case domain.ErrOTPAttempts:
+			message = "Too many incorrect codes were entered for this sign-in. Please request a new one."
+		}
+		h.showLoginPage(w, r, map[string]string{
+			"Email": email,
+			"Error": message,
+		})
+		return
+	}
+
+	h.signIn(w, r, user)
+}
+
+// signIn completes an email code or link sign-in, first asking for the
+// user's authenticator code if they have two-factor authentication on.
+func (h *AuthHandler) signIn(w http.ResponseWriter, r *http.Request, user *domain.User) {
+	session, err := h.authMiddleware.GetSession(r)
+	if err != nil {
+		log.Printf("Failed to get session for user %d: %v", user.ID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	delete(session.Values, loginBindingKey)
+
+	twoFactor, err := h.totpService.IsEnabled(user.ID)
+	if err != nil {
+		log.Printf("Failed to check two-factor authentication for user %d: %v", user.ID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if twoFactor {
+		session.Values[twoFactorUserKey] = user.ID
+		session.Values[twoFactorStartedKey] = time.Now().Unix()
+		if err := session.Save(r, w); err != nil {
+			log.Printf("Failed to save session for user %d: %v", user.ID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		h.showTwoFactorPage(w, r, "")
+		return
+	}
+
+	h.completeSignIn(w, r, user)
+}
+
+// TwoFactor checks the authenticator or recovery code of a sign-in started
+// by signIn.
+func (h *AuthHandler) TwoFactor(w http.ResponseWriter, r *http.Request) {
+	session, err := h.authMiddleware.GetSession(r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	userID, ok := session.Values[twoFactorUserKey].(int)
+	startedAt, _ := session.Values[twoFactorStartedKey].(int64)
+	if !ok || time.Since(time.Unix(startedAt, 0)) > twoFactorTimeout {
+		delete(session.Values, twoFactorUserKey)
+		delete(session.Values, twoFactorStartedKey)
+		if err := session.Save(r, w); err != nil {
+			log.Printf("Warning: failed to clear two-factor sign-in: %v", err)
+		}
+		h.showLoginPage(w, r, map[string]string{"Error": "Your sign-in expired. Please start again."})
+		return
+	}
+
+	if err := h.totpService.Verify(userID, r.FormValue("code")); err != nil {
+		log.Printf("Two-factor verification failed for user %d: %v", userID, err)
+		message := "Invalid code. Please try again."
+		if err != domain.ErrInvalidTOTPCode {
+			message = "Too many attempts. Please wait 15 minutes and try again."
+		}
+		h.showTwoFactorPage(w, r, message)
+		return
+	}
+
+	delete(session.Values, twoFactorUserKey)
+	delete(session.Values, twoFactorStartedKey)
+
+	user, err := h.authService.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Failed to get user %d: %v", userID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user.IsDisabled() {
+		h.showLoginPage(w, r, map[string]string{"Error": "This account has been disabled."})
+		return
+	}
+
+	h.completeSignIn(w, r, user)
+}
+
+func (h *AuthHandler) showTwoFactorPage(w http.ResponseWriter, r *http.Request, errorMessage string) {
+	data := map[string]interface{}{
+		"Error":     errorMessage,
+		"csrfField": csrf.TemplateField(r),
+	}
+
+	if err := h.twoFactorTemplate.Execute(w, data); err != nil {
+		log.Printf("Error executing template: %v", err)
+	}
+}
+
+func (h *AuthHandler) completeSignIn(w http.ResponseWriter, r *http.Request, user *domain.User) {
 	if err := h.authMiddleware.SetUserSession(w, r, user.ID); err != nil {
 		log.Printf("Failed to set session for user %d: %v", user.ID, err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("User %s logged in successfully", email)
+	log.Printf("User %s logged in successfully", user.Email)
 	http.Redirect(w, r, "/feeds", http.StatusFound)
 }
 
+// newLoginBinding stores a fresh random value in the session for SendOTP to
+// bind the sign-in link to. Requesting a new code replaces it, just as it
+// replaces the previous code.
+func (h *AuthHandler) newLoginBinding(w http.ResponseWriter, r *http.Request) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	binding := base64.RawURLEncoding.EncodeToString(b)
+
+	session, err := h.authMiddleware.GetSession(r)
+	if err != nil {
+		return "", err
+	}
+	session.Values[loginBindingKey] = binding
+	if err := session.Save(r, w); err != nil {
+		return "", err
+	}
+	return binding, nil
+}
+
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	if err := h.authMiddleware.ClearSession(w, r); err != nil {
 		log.Printf("Error clearing session: %v", err)
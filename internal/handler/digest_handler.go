@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/service"
+
+	"github.com/gorilla/csrf"
+)
+
+// DigestHandler serves the unsubscribe link included in every digest. It
+// works without logging in; the token in the link identifies the digest.
+type DigestHandler struct {
+	digestService       *service.DigestService
+	unsubscribeTemplate *template.Template
+}
+
+func NewDigestHandler(digestService *service.DigestService) *DigestHandler {
+	unsubscribeTemplate, err := template.ParseFiles("templates/digest_unsubscribe.html")
+	if err != nil {
+		log.Fatalf("Failed to parse digest_unsubscribe template: %v", err)
+	}
+
+	return &DigestHandler{
+		digestService:       digestService,
+		unsubscribeTemplate: unsubscribeTemplate,
+	}
+}
+
+// Unsubscribe asks for confirmation on GET, so that link scanners in mail
+// clients do not unsubscribe anyone, and turns the digest off on POST.
+func (h *DigestHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	data := map[string]interface{}{
+		"Token":     r.FormValue("token"),
+		"csrfField": csrf.TemplateField(r),
+	}
+
+	if r.Method == "POST" {
+		if err := h.digestService.Unsubscribe(r.FormValue("token")); err != nil {
+			if err != domain.ErrDigestNotFound {
+				log.Printf("Error unsubscribing from digest: %v", err)
+			}
+			data["Error"] = "This unsubscribe link is not valid."
+		} else {
+			data["Done"] = true
+		}
+	}
+
+	if err := h.unsubscribeTemplate.Execute(w, data); err != nil {
+		log.Printf("Error executing template: %v", err)
+		http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		return
+	}
+}
@@ -1,15 +1,19 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"rss-reader/internal/domain"
 	"rss-reader/internal/middleware"
 	"rss-reader/internal/service"
+	"rss-reader/pkg/opml"
 	"strconv"
-	"time"
 
 	"github.com/gorilla/csrf"
 	"github.com/gorilla/mux"
@@ -17,15 +21,23 @@ import (
 
 type FeedHandler struct {
 	feedService         *service.FeedService
+	retentionService    *service.RetentionService
 	authMiddleware      *middleware.AuthMiddleware
+	refreshThrottle     *middleware.Throttle
 	feedsTemplate       *template.Template
 	addFeedTemplate     *template.Template
 	manageFeedsTemplate *template.Template
 	editFeedTemplate    *template.Template
+	importTemplate      *template.Template
 }
 
-func NewFeedHandler(feedService *service.FeedService, authMiddleware *middleware.AuthMiddleware) *FeedHandler {
-	feedsTemplate, err := template.ParseFiles("templates/feeds.html")
+func NewFeedHandler(
+	feedService *service.FeedService,
+	retentionService *service.RetentionService,
+	authMiddleware *middleware.AuthMiddleware,
+	refreshThrottle *middleware.Throttle,
+) *FeedHandler {
+	feedsTemplate, err := template.ParseFiles("templates/feeds.html", "templates/feed_items.html")
 	if err != nil {
 		log.Fatalf("Failed to parse feeds template: %v", err)
 	}
@@ -45,16 +57,33 @@ func NewFeedHandler(feedService *service.FeedService, authMiddleware *middleware
 		log.Fatalf("Failed to parse edit_feed template: %v", err)
 	}
 
+	importTemplate, err := template.ParseFiles("templates/import_preview.html")
+	if err != nil {
+		log.Fatalf("Failed to parse import_preview template: %v", err)
+	}
+
 	return &FeedHandler{
 		feedService:         feedService,
+		retentionService:    retentionService,
 		authMiddleware:      authMiddleware,
+		refreshThrottle:     refreshThrottle,
 		feedsTemplate:       feedsTemplate,
 		addFeedTemplate:     addFeedTemplate,
 		manageFeedsTemplate: manageFeedsTemplate,
 		editFeedTemplate:    editFeedTemplate,
+		importTemplate:      importTemplate,
 	}
 }
 
+type feedsPageData struct {
+	*service.FeedItemPage
+	Filter      url.Values
+	FilterQuery string
+	Feeds       []domain.Feed
+	Folders     []domain.Folder
+	CSRFToken   string
+}
+
 func (h *FeedHandler) ViewFeeds(w http.ResponseWriter, r *http.Request) {
 	userID, ok := h.authMiddleware.GetUserID(r)
 	if !ok {
@@ -62,45 +91,144 @@ func (h *FeedHandler) ViewFeeds(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	daysOffset := 0
-	if offsetStr := r.URL.Query().Get("days"); offsetStr != "" {
-		if parsed, err := strconv.Atoi(offsetStr); err == nil && parsed >= 0 {
-			daysOffset = parsed
-		}
+	filter, err := parseItemFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, "Invalid filter", http.StatusBadRequest)
+		return
 	}
 
-	// Refresh feeds when viewing the page
-	if daysOffset == 0 {
-		totalItems, newItems, err := h.feedService.RefreshFeeds(userID)
+	// Refresh feeds when viewing the page, within the same per-IP budget as
+	// manual refreshes; past it the page shows what is already stored.
+	if h.refreshThrottle.Allow(r) {
+		totalItems, newItems, err := h.feedService.RefreshFeeds(userID, domain.RefreshTriggerPageView)
 		if err != nil {
 			log.Printf("Error refreshing feeds: %v", err)
 		} else {
 			log.Printf("Auto-refreshed feeds for user %d: %d total, %d new", userID, totalItems, newItems)
 		}
+	} else {
+		log.Printf("Skipped refresh on page view for user %d: refresh budget used up", userID)
+	}
+
+	page, err := h.feedService.GetFeedItemsGroupedByDate(userID, filter, nil)
+	if err != nil {
+		log.Printf("Error getting feed items: %v", err)
+		http.Error(w, "Error getting feed items", http.StatusInternalServerError)
+		return
+	}
+
+	feeds, err := h.feedService.GetFeedsByUserID(userID)
+	if err != nil {
+		log.Printf("Error getting feeds: %v", err)
+		http.Error(w, "Error getting feeds", http.StatusInternalServerError)
+		return
+	}
+
+	folders, err := h.feedService.GetFoldersByUserID(userID)
+	if err != nil {
+		log.Printf("Error getting folders: %v", err)
+		http.Error(w, "Error getting folders", http.StatusInternalServerError)
+		return
+	}
+
+	filterValues := encodeItemFilter(filter)
+	pageData := feedsPageData{
+		FeedItemPage: page,
+		Filter:       filterValues,
+		FilterQuery:  filterValues.Encode(),
+		Feeds:        feeds,
+		Folders:      folders,
+		CSRFToken:    csrf.Token(r),
+	}
+
+	if err := h.feedsTemplate.Execute(w, pageData); err != nil {
+		log.Printf("Error executing template: %v", err)
+	}
+}
+
+// FeedItems renders the page of items after the given cursor as an HTML
+// fragment for infinite scrolling.
+func (h *FeedHandler) FeedItems(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := parseItemFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, "Invalid filter", http.StatusBadRequest)
+		return
+	}
+
+	cursor, err := domain.DecodeItemCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		http.Error(w, "Invalid cursor", http.StatusBadRequest)
+		return
 	}
 
-	dateGroups, hasMore, feedNames, err := h.feedService.GetFeedItemsGroupedByDate(userID, daysOffset)
+	page, err := h.feedService.GetFeedItemsGroupedByDate(userID, filter, cursor)
 	if err != nil {
 		log.Printf("Error getting feed items: %v", err)
 		http.Error(w, "Error getting feed items", http.StatusInternalServerError)
 		return
 	}
 
-	pageData := struct {
-		DateGroups  []service.FeedItemGroup
-		HasMore     bool
-		NextOffset  int
-		CurrentDays int
-		FeedNames   []string
-	}{
-		DateGroups:  dateGroups,
-		HasMore:     hasMore,
-		NextOffset:  daysOffset + 60,
-		CurrentDays: daysOffset,
-		FeedNames:   feedNames,
+	filterValues := encodeItemFilter(filter)
+	pageData := feedsPageData{
+		FeedItemPage: page,
+		Filter:       filterValues,
+		FilterQuery:  filterValues.Encode(),
 	}
 
-	h.feedsTemplate.Execute(w, pageData)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := h.feedsTemplate.ExecuteTemplate(w, "feed_items", pageData); err != nil {
+		log.Printf("Error executing template: %v", err)
+	}
+}
+
+func (h *FeedHandler) SetItemRead(w http.ResponseWriter, r *http.Request) {
+	h.setItemFlag(w, r, "read", h.feedService.SetItemRead)
+}
+
+func (h *FeedHandler) SetItemStarred(w http.ResponseWriter, r *http.Request) {
+	h.setItemFlag(w, r, "starred", h.feedService.SetItemStarred)
+}
+
+func (h *FeedHandler) setItemFlag(w http.ResponseWriter, r *http.Request, field string, update func(itemID, userID int, value bool) error) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	itemID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		return
+	}
+
+	value, err := strconv.ParseBool(r.FormValue(field))
+	if err != nil {
+		http.Error(w, "Invalid value", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := update(itemID, userID, value); err != nil {
+		log.Printf("Error updating item %d: %v", itemID, err)
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Item not found",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		field:     value,
+	})
 }
 
 func (h *FeedHandler) AddFeed(w http.ResponseWriter, r *http.Request) {
@@ -121,7 +249,13 @@ func (h *FeedHandler) showAddFeedPage(w http.ResponseWriter, r *http.Request) {
 	data := map[string]interface{}{
 		"csrfField": csrf.TemplateField(r),
 	}
-	
+
+	if userID, ok := h.authMiddleware.GetUserID(r); ok {
+		if folders, err := h.feedService.GetFoldersByUserID(userID); err == nil {
+			data["Folders"] = folders
+		}
+	}
+
 	h.addFeedTemplate.Execute(w, data)
 }
 
@@ -139,8 +273,9 @@ func (h *FeedHandler) handleAddFeedPost(w http.ResponseWriter, r *http.Request)
 
 	name := r.FormValue("name")
 	url := r.FormValue("url")
+	folder := r.FormValue("folder")
 
-	_, err := h.feedService.CreateFeed(name, url, userID)
+	_, err := h.feedService.CreateFeed(name, url, folder, userID)
 	if err != nil {
 		log.Printf("Error creating feed: %v", err)
 		http.Error(w, "Error creating feed", http.StatusInternalServerError)
@@ -157,7 +292,7 @@ func (h *FeedHandler) RefreshFeeds(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	totalItems, newItems, err := h.feedService.RefreshFeeds(userID)
+	totalItems, newItems, err := h.feedService.RefreshFeeds(userID, domain.RefreshTriggerManual)
 	if err != nil {
 		log.Printf("Error refreshing feeds: %v", err)
 		http.Error(w, "Error refreshing feeds", http.StatusInternalServerError)
@@ -175,6 +310,12 @@ func (h *FeedHandler) ManageFeeds(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.showManagePage(w, r, userID, nil)
+}
+
+// showManagePage renders the manage page; data carries per-request extras
+// such as Message or Error.
+func (h *FeedHandler) showManagePage(w http.ResponseWriter, r *http.Request, userID int, data map[string]interface{}) {
 	feeds, err := h.feedService.GetFeedsByUserID(userID)
 	if err != nil {
 		log.Printf("Error getting feeds: %v", err)
@@ -182,14 +323,12 @@ func (h *FeedHandler) ManageFeeds(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data := map[string]interface{}{
-		"Feeds": feeds,
-	}
-	
-	if csrfToken := csrf.Token(r); csrfToken != "" {
-		data["csrfField"] = csrf.TemplateField(r)
+	if data == nil {
+		data = make(map[string]interface{})
 	}
-	
+	data["Feeds"] = feeds
+	data["csrfField"] = csrf.TemplateField(r)
+
 	if err := h.manageFeedsTemplate.Execute(w, data); err != nil {
 		log.Printf("Error executing template: %v", err)
 		http.Error(w, "Error rendering page", http.StatusInternalServerError)
@@ -233,10 +372,18 @@ func (h *FeedHandler) showEditFeedPage(w http.ResponseWriter, r *http.Request, f
 	}
 
 	data := map[string]interface{}{
-		"ID":        feed.ID,
-		"Name":      feed.Name,
-		"URL":       feed.URL,
-		"csrfField": csrf.TemplateField(r),
+		"ID":                feed.ID,
+		"Name":              feed.Name,
+		"URL":               feed.URL,
+		"Folder":            feed.Folder,
+		"CreatedAt":         feed.CreatedAt,
+		"Retention":         feed.Retention,
+		"RetentionDefaults": h.retentionService.Defaults(),
+		"csrfField":         csrf.TemplateField(r),
+	}
+
+	if folders, err := h.feedService.GetFoldersByUserID(userID); err == nil {
+		data["Folders"] = folders
 	}
 
 	h.editFeedTemplate.Execute(w, data)
@@ -250,14 +397,27 @@ func (h *FeedHandler) handleEditFeedPost(w http.ResponseWriter, r *http.Request,
 
 	name := r.FormValue("name")
 	url := r.FormValue("url")
+	folder := r.FormValue("folder")
 
-	err := h.feedService.UpdateFeed(feedID, name, url, userID)
+	err := h.feedService.UpdateFeed(feedID, name, url, folder, userID)
 	if err != nil {
 		log.Printf("Error updating feed: %v", err)
 		http.Error(w, "Error updating feed", http.StatusInternalServerError)
 		return
 	}
 
+	policy, err := parseRetentionForm(r)
+	if err != nil {
+		http.Error(w, "Invalid retention values", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.retentionService.UpdateFeedRetention(feedID, userID, policy); err != nil {
+		log.Printf("Error updating feed retention: %v", err)
+		http.Error(w, "Error updating feed", http.StatusInternalServerError)
+		return
+	}
+
 	http.Redirect(w, r, "/feeds/manage", http.StatusFound)
 }
 
@@ -285,6 +445,21 @@ func (h *FeedHandler) DeleteFeed(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/feeds/manage", http.StatusFound)
 }
 
+const maxImportSize = 5 << 20
+
+// maxImportEntries bounds the feeds in one import, which also bounds the
+// entries CommitImport reads back from the preview form.
+const maxImportEntries = 5000
+
+type exportedFeed struct {
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	SiteURL string `json:"site_url,omitempty"`
+	Folder  string `json:"folder,omitempty"`
+}
+
+// ExportFeeds downloads the user's feeds as FeedStream JSON, or as OPML 2.0
+// with folders as nested outlines when format=opml.
 func (h *FeedHandler) ExportFeeds(w http.ResponseWriter, r *http.Request) {
 	userID, ok := h.authMiddleware.GetUserID(r)
 	if !ok {
@@ -299,20 +474,36 @@ func (h *FeedHandler) ExportFeeds(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Query().Get("format") == "opml" {
+		entries := make([]opml.Entry, 0, len(feeds))
+		for _, feed := range feeds {
+			entries = append(entries, opml.Entry{
+				Title:   feed.Name,
+				XMLURL:  feed.URL,
+				HTMLURL: feed.SiteURL,
+				Folder:  feed.Folder,
+			})
+		}
+
+		w.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename=feedstream-feeds.opml")
+
+		if err := opml.Write(w, "FeedStream subscriptions", entries); err != nil {
+			log.Printf("Error writing OPML: %v", err)
+		}
+		return
+	}
+
 	exportData := struct {
-		Feeds []struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"feeds"`
+		Feeds []exportedFeed `json:"feeds"`
 	}{}
 
 	for _, feed := range feeds {
-		exportData.Feeds = append(exportData.Feeds, struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		}{
-			Name: feed.Name,
-			URL:  feed.URL,
+		exportData.Feeds = append(exportData.Feeds, exportedFeed{
+			Name:    feed.Name,
+			URL:     feed.URL,
+			SiteURL: feed.SiteURL,
+			Folder:  feed.Folder,
 		})
 	}
 
@@ -325,53 +516,180 @@ func (h *FeedHandler) ExportFeeds(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ImportFeeds is the first step of an import: it parses the uploaded OPML or
+// FeedStream JSON file and shows a preview where the user decides what to do
+// with each entry. Nothing is written until CommitImport.
 func (h *FeedHandler) ImportFeeds(w http.ResponseWriter, r *http.Request) {
 	userID, ok := h.authMiddleware.GetUserID(r)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		http.Redirect(w, r, "/login", http.StatusFound)
 		return
 	}
 
-	var importData struct {
-		Feeds []struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"feeds"`
+	data, err := readImportUpload(w, r)
+	if err != nil {
+		h.showManagePage(w, r, userID, map[string]interface{}{"Error": "Import failed: " + err.Error()})
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&importData); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   "Invalid JSON format",
+	entries, format, err := parseImportFile(data)
+	if err != nil {
+		h.showManagePage(w, r, userID, map[string]interface{}{"Error": "Could not read the import file: " + err.Error()})
+		return
+	}
+
+	preview, err := h.feedService.PreviewImport(userID, entries)
+	if err != nil {
+		log.Printf("Error previewing import for user %d: %v", userID, err)
+		http.Error(w, "Error previewing import", http.StatusInternalServerError)
+		return
+	}
+
+	if len(preview.Entries) == 0 {
+		h.showManagePage(w, r, userID, map[string]interface{}{"Error": "The import file does not contain any feeds."})
+		return
+	}
+
+	templateData := map[string]interface{}{
+		"Preview":       preview,
+		"LocationLabel": "Line",
+		"csrfField":     csrf.TemplateField(r),
+	}
+	if format == "json" {
+		templateData["LocationLabel"] = "Entry"
+	}
+
+	if err := h.importTemplate.Execute(w, templateData); err != nil {
+		log.Printf("Error executing template: %v", err)
+		http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		return
+	}
+}
+
+// CommitImport applies the choices made on the preview page. Entries are
+// posted as parallel fields suffixed with their index.
+func (h *FeedHandler) CommitImport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	count, err := strconv.Atoi(r.FormValue("count"))
+	if err != nil || count < 0 || count > maxImportEntries {
+		http.Error(w, "Invalid import", http.StatusBadRequest)
+		return
+	}
+
+	decisions := make([]domain.FeedImportDecision, 0, count)
+	for i := 0; i < count; i++ {
+		field := func(name string) string {
+			return r.FormValue(fmt.Sprintf("%s_%d", name, i))
+		}
+
+		line, _ := strconv.Atoi(field("line"))
+		existingFeedID, _ := strconv.Atoi(field("existing"))
+		decisions = append(decisions, domain.FeedImportDecision{
+			Entry: domain.FeedImportEntry{
+				Line:    line,
+				Name:    field("name"),
+				URL:     field("url"),
+				SiteURL: field("site_url"),
+				Folder:  field("folder"),
+			},
+			Action:         field("action"),
+			ExistingFeedID: existingFeedID,
+		})
+	}
+
+	report, err := h.feedService.CommitImport(userID, decisions)
+	if err != nil {
+		log.Printf("Error importing feeds for user %d: %v", userID, err)
+		h.showManagePage(w, r, userID, map[string]interface{}{
+			"Error": "Nothing was imported: " + err.Error(),
 		})
 		return
 	}
 
-	feeds := make([]struct{ Name, URL string }, len(importData.Feeds))
-	for i, f := range importData.Feeds {
-		feeds[i] = struct{ Name, URL string }{Name: f.Name, URL: f.URL}
+	h.showManagePage(w, r, userID, map[string]interface{}{
+		"Message": fmt.Sprintf("Import complete: %d added, %d renamed, %d overwritten, %d skipped.",
+			report.Added, report.Renamed, report.Overwritten, report.Skipped),
+	})
+}
+
+func readImportUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	file, _, err := r.FormFile("feedFile")
+	if err != nil {
+		return nil, fmt.Errorf("choose an OPML or JSON file to import")
 	}
+	defer file.Close()
 
-	successCount, errors := h.feedService.ImportFeeds(userID, feeds)
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the uploaded file")
+	}
+	return data, nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	response := map[string]interface{}{
-		"success":      successCount > 0,
-		"imported":     successCount,
-		"errors":       len(errors),
-		"errorDetails": errors,
+var errTooManyImportEntries = fmt.Errorf("file has more than %d feeds", maxImportEntries)
+
+// parseImportFile detects OPML by its leading "<" and otherwise expects
+// FeedStream's {"feeds": [...]} JSON.
+func parseImportFile(data []byte) ([]domain.FeedImportEntry, string, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+
+	if bytes.HasPrefix(trimmed, []byte("<")) {
+		outlines, err := opml.Parse(bytes.NewReader(trimmed))
+		if err != nil {
+			return nil, "opml", err
+		}
+
+		if len(outlines) > maxImportEntries {
+			return nil, "opml", errTooManyImportEntries
+		}
+
+		entries := make([]domain.FeedImportEntry, 0, len(outlines))
+		for _, outline := range outlines {
+			entries = append(entries, domain.FeedImportEntry{
+				Line:    outline.Line,
+				Name:    outline.Title,
+				URL:     outline.XMLURL,
+				SiteURL: outline.HTMLURL,
+				Folder:  outline.Folder,
+			})
+		}
+		return entries, "opml", nil
 	}
 
-	if successCount > 0 && len(errors) > 0 {
-		response["message"] = fmt.Sprintf("Imported %d feeds with %d errors", successCount, len(errors))
-	} else if successCount > 0 {
-		response["message"] = fmt.Sprintf("Successfully imported %d feeds", successCount)
-	} else {
-		response["error"] = "No feeds were imported"
+	var importData struct {
+		Feeds []exportedFeed `json:"feeds"`
+	}
+	if err := json.Unmarshal(trimmed, &importData); err != nil {
+		return nil, "json", fmt.Errorf("file is neither OPML nor FeedStream JSON")
 	}
 
-	json.NewEncoder(w).Encode(response)
+	if len(importData.Feeds) > maxImportEntries {
+		return nil, "json", errTooManyImportEntries
+	}
+
+	entries := make([]domain.FeedImportEntry, 0, len(importData.Feeds))
+	for i, feed := range importData.Feeds {
+		entries = append(entries, domain.FeedImportEntry{
+			Line:    i + 1,
+			Name:    feed.Name,
+			URL:     feed.URL,
+			SiteURL: feed.SiteURL,
+			Folder:  feed.Folder,
+		})
+	}
+	return entries, "json", nil
 }
 
 func (h *FeedHandler) Debug(w http.ResponseWriter, r *http.Request) {
@@ -385,29 +703,37 @@ func (h *FeedHandler) Debug(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Debug Information for User %d\n", userID)
 	fmt.Fprintf(w, "=================================\n\n")
 
-	for i := 0; i < 50; i += 10 {
-		endDate := time.Now().AddDate(0, 0, -i)
-		startDate := endDate.AddDate(0, 0, -10)
-
-		dateGroups, hasMore, _, err := h.feedService.GetFeedItemsGroupedByDate(userID, i)
+	var cursor *domain.ItemCursor
+	for pageNumber := 1; pageNumber <= 5; pageNumber++ {
+		page, err := h.feedService.GetFeedItemsGroupedByDate(userID, domain.ItemFilter{}, cursor)
 		if err != nil {
-			fmt.Fprintf(w, "Error for offset %d: %v\n", i, err)
-			continue
+			fmt.Fprintf(w, "Error for page %d: %v\n", pageNumber, err)
+			break
 		}
 
 		totalItems := 0
-		for _, group := range dateGroups {
+		for _, group := range page.DateGroups {
 			totalItems += len(group.Items)
 		}
 
-		fmt.Fprintf(w, "Offset %d days: %s to %s\n", i,
-			startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+		fmt.Fprintf(w, "Page %d:\n", pageNumber)
+		if len(page.DateGroups) > 0 {
+			first := page.DateGroups[0]
+			last := page.DateGroups[len(page.DateGroups)-1]
+			fmt.Fprintf(w, "  Dates: %s to %s\n", last.Key, first.Key)
+		}
 		fmt.Fprintf(w, "  Items found: %d\n", totalItems)
-		fmt.Fprintf(w, "  Has more: %t\n", hasMore)
+		fmt.Fprintf(w, "  Has more: %t\n", page.HasMore)
 		fmt.Fprintf(w, "\n")
 
-		if totalItems == 0 && !hasMore {
+		if !page.HasMore {
+			break
+		}
+
+		cursor, err = domain.DecodeItemCursor(page.NextCursor)
+		if err != nil {
+			fmt.Fprintf(w, "Error decoding cursor: %v\n", err)
 			break
 		}
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,375 @@
+package handler
+
+import (
+	"encoding/base64"
+	"html"
+	"log"
+	"net/http"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/service"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	feverAPIVersion = 3
+	feverPageSize   = 50
+)
+
+// FeverHandler implements the Fever API (https://feedafever.com/api) so that
+// clients such as Reeder or Unread can sync against FeedStream. Every request
+// is a single endpoint whose query flags select which sections to return.
+type FeverHandler struct {
+	feverService *service.FeverService
+	feedService  *service.FeedService
+}
+
+func NewFeverHandler(feverService *service.FeverService, feedService *service.FeedService) *FeverHandler {
+	return &FeverHandler{
+		feverService: feverService,
+		feedService:  feedService,
+	}
+}
+
+type feverGroup struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+type feverFeedsGroup struct {
+	GroupID int    `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feverFeed struct {
+	ID                int    `json:"id"`
+	FaviconID         int    `json:"favicon_id"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	SiteURL           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type feverFavicon struct {
+	ID   int    `json:"id"`
+	Data string `json:"data"`
+}
+
+type feverItem struct {
+	ID            int    `json:"id"`
+	FeedID        int    `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	HTML          string `json:"html"`
+	URL           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+func (h *FeverHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]interface{}{
+		"api_version": feverAPIVersion,
+		"auth":        0,
+	}
+
+	userID, err := h.feverService.Authenticate(r.FormValue("api_key"))
+	if err != nil {
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+	response["auth"] = 1
+
+	if r.FormValue("mark") != "" {
+		if err := h.mark(userID, r); err != nil {
+			log.Printf("Fever: error marking for user %d: %v", userID, err)
+			writeJSON(w, http.StatusOK, response)
+			return
+		}
+	}
+
+	feeds, err := h.feedService.GetFeedsByUserID(userID)
+	if err != nil {
+		h.writeError(w, userID, err)
+		return
+	}
+	response["last_refreshed_on_time"] = lastRefreshedOnTime(feeds)
+
+	_, wantGroups := r.Form["groups"]
+	_, wantFeeds := r.Form["feeds"]
+	if wantGroups || wantFeeds {
+		if err := h.addGroupsAndFeeds(userID, feeds, wantGroups, wantFeeds, response); err != nil {
+			h.writeError(w, userID, err)
+			return
+		}
+	}
+
+	if _, ok := r.Form["favicons"]; ok {
+		favicons, err := h.favicons(userID)
+		if err != nil {
+			h.writeError(w, userID, err)
+			return
+		}
+		response["favicons"] = favicons
+	}
+
+	if _, ok := r.Form["items"]; ok {
+		items, total, err := h.items(userID, r)
+		if err != nil {
+			h.writeError(w, userID, err)
+			return
+		}
+		response["items"] = items
+		response["total_items"] = total
+	}
+
+	if _, ok := r.Form["links"]; ok {
+		response["links"] = []interface{}{}
+	}
+
+	_, wantUnread := r.Form["unread_item_ids"]
+	if wantUnread || r.FormValue("mark") != "" {
+		ids, err := h.feedService.GetItemIDs(userID, domain.ItemStatusUnread)
+		if err != nil {
+			h.writeError(w, userID, err)
+			return
+		}
+		response["unread_item_ids"] = joinIDs(ids)
+	}
+
+	_, wantSaved := r.Form["saved_item_ids"]
+	if wantSaved || r.FormValue("mark") == "item" {
+		ids, err := h.feedService.GetItemIDs(userID, domain.ItemStatusStarred)
+		if err != nil {
+			h.writeError(w, userID, err)
+			return
+		}
+		response["saved_item_ids"] = joinIDs(ids)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *FeverHandler) writeError(w http.ResponseWriter, userID int, err error) {
+	log.Printf("Fever: error serving user %d: %v", userID, err)
+	writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+		"api_version": feverAPIVersion,
+		"auth":        1,
+	})
+}
+
+// addGroupsAndFeeds fills the groups, feeds and feeds_groups sections. Fever
+// groups map onto folders; feeds outside a folder belong to no group.
+func (h *FeverHandler) addGroupsAndFeeds(userID int, feeds []domain.Feed, wantGroups, wantFeeds bool, response map[string]interface{}) error {
+	feedIDsByFolder := make(map[int][]int)
+	for _, feed := range feeds {
+		if feed.FolderID != 0 {
+			feedIDsByFolder[feed.FolderID] = append(feedIDsByFolder[feed.FolderID], feed.ID)
+		}
+	}
+
+	feedsGroups := make([]feverFeedsGroup, 0, len(feedIDsByFolder))
+
+	if wantGroups {
+		folders, err := h.feedService.GetFoldersByUserID(userID)
+		if err != nil {
+			return err
+		}
+
+		groups := make([]feverGroup, 0, len(folders))
+		for _, folder := range folders {
+			groups = append(groups, feverGroup{ID: folder.ID, Title: folder.Name})
+			if ids, ok := feedIDsByFolder[folder.ID]; ok {
+				feedsGroups = append(feedsGroups, feverFeedsGroup{GroupID: folder.ID, FeedIDs: joinIDs(ids)})
+			}
+		}
+		response["groups"] = groups
+	}
+
+	if wantFeeds {
+		icons, err := h.feedService.GetFeedIcons(userID)
+		if err != nil {
+			return err
+		}
+
+		hasIcon := make(map[int]bool, len(icons))
+		for _, icon := range icons {
+			hasIcon[icon.FeedID] = true
+		}
+
+		feverFeeds := make([]feverFeed, 0, len(feeds))
+		for _, feed := range feeds {
+			f := feverFeed{
+				ID:      feed.ID,
+				Title:   feed.Name,
+				URL:     feed.URL,
+				SiteURL: feed.SiteURL,
+			}
+			if hasIcon[feed.ID] {
+				f.FaviconID = feed.ID
+			}
+			if feed.LastRefreshedAt != nil {
+				f.LastUpdatedOnTime = feed.LastRefreshedAt.Unix()
+			}
+			feverFeeds = append(feverFeeds, f)
+		}
+		response["feeds"] = feverFeeds
+
+		if !wantGroups {
+			for folderID, ids := range feedIDsByFolder {
+				feedsGroups = append(feedsGroups, feverFeedsGroup{GroupID: folderID, FeedIDs: joinIDs(ids)})
+			}
+		}
+	}
+
+	response["feeds_groups"] = feedsGroups
+	return nil
+}
+
+// favicons uses the feed ID as the favicon ID, since each feed has at most
+// one icon.
+func (h *FeverHandler) favicons(userID int) ([]feverFavicon, error) {
+	icons, err := h.feedService.GetFeedIcons(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	favicons := make([]feverFavicon, 0, len(icons))
+	for _, icon := range icons {
+		favicons = append(favicons, feverFavicon{
+			ID:   icon.FeedID,
+			Data: icon.MimeType + ";base64," + base64.StdEncoding.EncodeToString(icon.Data),
+		})
+	}
+	return favicons, nil
+}
+
+// items honours with_ids, since_id and max_id in that order of precedence,
+// returning at most feverPageSize items.
+func (h *FeverHandler) items(userID int, r *http.Request) ([]feverItem, int, error) {
+	var (
+		items []domain.FeedItem
+		err   error
+	)
+
+	switch {
+	case r.FormValue("with_ids") != "":
+		ids := parseIDs(r.FormValue("with_ids"))
+		if len(ids) > feverPageSize {
+			ids = ids[:feverPageSize]
+		}
+		items, err = h.feedService.GetItemsByIDs(userID, ids)
+	case r.FormValue("since_id") != "":
+		sinceID, _ := strconv.Atoi(r.FormValue("since_id"))
+		items, err = h.feedService.GetItemsSinceID(userID, sinceID, feverPageSize)
+	default:
+		maxID, _ := strconv.Atoi(r.FormValue("max_id"))
+		items, err = h.feedService.GetItemsBeforeID(userID, maxID, feverPageSize)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := h.feedService.CountItems(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	feverItems := make([]feverItem, 0, len(items))
+	for _, item := range items {
+		feverItems = append(feverItems, feverItem{
+			ID:            item.ID,
+			FeedID:        item.FeedID,
+			Title:         item.Title,
+			HTML:          "<p>" + html.EscapeString(item.Description) + "</p>",
+			URL:           item.Link,
+			IsSaved:       boolToInt(item.IsStarred),
+			IsRead:        boolToInt(item.IsRead),
+			CreatedOnTime: item.PublishedAt.Unix(),
+		})
+	}
+	return feverItems, total, nil
+}
+
+// mark applies mark=item|feed|group with as=read|unread|saved|unsaved.
+// Group 0 is Fever's "Kindling" super group and covers every feed.
+func (h *FeverHandler) mark(userID int, r *http.Request) error {
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		return err
+	}
+	as := r.FormValue("as")
+
+	switch r.FormValue("mark") {
+	case "item":
+		switch as {
+		case "read", "unread":
+			return h.feedService.SetItemRead(id, userID, as == "read")
+		case "saved", "unsaved":
+			return h.feedService.SetItemStarred(id, userID, as == "saved")
+		}
+	case "feed", "group":
+		if as != "read" {
+			return nil
+		}
+		before := time.Now()
+		if value, err := strconv.ParseInt(r.FormValue("before"), 10, 64); err == nil && value > 0 {
+			before = time.Unix(value, 0)
+		}
+
+		// MarkReadBefore treats a zero feed and folder as every feed, which
+		// only Kindling may ask for.
+		if r.FormValue("mark") == "feed" {
+			if id <= 0 {
+				return domain.ErrInvalidFeedID
+			}
+			_, err = h.feedService.MarkReadBefore(userID, id, 0, before)
+		} else if id >= 0 {
+			_, err = h.feedService.MarkReadBefore(userID, 0, id, before)
+		}
+		return err
+	}
+	return nil
+}
+
+func lastRefreshedOnTime(feeds []domain.Feed) int64 {
+	var latest int64
+	for _, feed := range feeds {
+		if feed.LastRefreshedAt != nil && feed.LastRefreshedAt.Unix() > latest {
+			latest = feed.LastRefreshedAt.Unix()
+		}
+	}
+	return latest
+}
+
+func joinIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseIDs(value string) []int {
+	var ids []int
+	for _, part := range strings.Split(value, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,720 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/middleware"
+	"rss-reader/internal/service"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Google Reader stream and tag identifiers. Clients may send either the "-"
+// shorthand or a concrete user ID, so only the suffix after the user part is
+// matched.
+const (
+	greaderReadingList = "user/-/state/com.google/reading-list"
+	greaderRead        = "user/-/state/com.google/read"
+	greaderStarred     = "user/-/state/com.google/starred"
+	greaderKeptUnread  = "user/-/state/com.google/kept-unread"
+	greaderLabelPrefix = "user/-/label/"
+	greaderFeedPrefix  = "feed/"
+	greaderItemPrefix  = "tag:google.com,2005:reader/item/"
+
+	greaderDefaultCount = 20
+	greaderMaxCount     = 1000
+)
+
+// GReaderHandler implements the subset of the Google Reader API spoken by
+// GReader-compatible clients. Clients sign in through ClientLogin with the
+// account email and a personal API token as the password; folders are exposed
+// as labels and read/starred flags as states.
+type GReaderHandler struct {
+	feedService     *service.FeedService
+	apiTokenService *service.APITokenService
+	authService     *service.AuthService
+	apiAuth         *middleware.APIAuthMiddleware
+}
+
+func NewGReaderHandler(
+	feedService *service.FeedService,
+	apiTokenService *service.APITokenService,
+	authService *service.AuthService,
+	apiAuth *middleware.APIAuthMiddleware,
+) *GReaderHandler {
+	return &GReaderHandler{
+		feedService:     feedService,
+		apiTokenService: apiTokenService,
+		authService:     authService,
+		apiAuth:         apiAuth,
+	}
+}
+
+type greaderCategory struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+type greaderSubscription struct {
+	ID         string            `json:"id"`
+	Title      string            `json:"title"`
+	Categories []greaderCategory `json:"categories"`
+	URL        string            `json:"url"`
+	HTMLURL    string            `json:"htmlUrl"`
+}
+
+type greaderLink struct {
+	Href string `json:"href"`
+	Type string `json:"type,omitempty"`
+}
+
+type greaderContent struct {
+	Direction string `json:"direction"`
+	Content   string `json:"content"`
+}
+
+type greaderOrigin struct {
+	StreamID string `json:"streamId"`
+	Title    string `json:"title"`
+	HTMLURL  string `json:"htmlUrl"`
+}
+
+type greaderItem struct {
+	ID            string         `json:"id"`
+	CrawlTimeMsec string         `json:"crawlTimeMsec"`
+	TimestampUsec string         `json:"timestampUsec"`
+	Published     int64          `json:"published"`
+	Updated       int64          `json:"updated"`
+	Title         string         `json:"title"`
+	Author        string         `json:"author"`
+	Canonical     []greaderLink  `json:"canonical"`
+	Alternate     []greaderLink  `json:"alternate"`
+	Summary       greaderContent `json:"summary"`
+	Categories    []string       `json:"categories"`
+	Origin        greaderOrigin  `json:"origin"`
+}
+
+// ClientLogin exchanges Email and Passwd (a personal API token) for the auth
+// token that clients send on every later request.
+func (h *GReaderHandler) ClientLogin(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	email := strings.TrimSpace(r.FormValue("Email"))
+	token := r.FormValue("Passwd")
+
+	userID, err := h.apiTokenService.Authenticate(token)
+	if err != nil {
+		http.Error(w, "Error=BadAuthentication", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.authService.GetUserByID(userID)
+	if err != nil || !strings.EqualFold(user.Email, email) {
+		http.Error(w, "Error=BadAuthentication", http.StatusUnauthorized)
+		return
+	}
+
+	if r.FormValue("output") == "json" {
+		writeJSON(w, http.StatusOK, map[string]string{"SID": token, "LSID": token, "Auth": token})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "SID=%s\nLSID=%s\nAuth=%s\n", token, token, token)
+}
+
+// Token returns the action token clients attach to write requests. Requests
+// are already authenticated by header, so the value is not checked.
+func (h *GReaderHandler) Token(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "feedstream-%d\n", userID)
+}
+
+func (h *GReaderHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	user, err := h.authService.GetUserByID(userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	id := strconv.Itoa(user.ID)
+	writeJSON(w, http.StatusOK, map[string]string{
+		"userId":        id,
+		"userName":      user.Email,
+		"userProfileId": id,
+		"userEmail":     user.Email,
+	})
+}
+
+func (h *GReaderHandler) SubscriptionList(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	feeds, err := h.feedService.GetFeedsByUserID(userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	subscriptions := make([]greaderSubscription, 0, len(feeds))
+	for _, feed := range feeds {
+		subscription := greaderSubscription{
+			ID:         greaderFeedID(feed.ID),
+			Title:      feed.Name,
+			Categories: []greaderCategory{},
+			URL:        feed.URL,
+			HTMLURL:    feed.SiteURL,
+		}
+		if feed.Folder != "" {
+			subscription.Categories = append(subscription.Categories, greaderCategory{
+				ID:    greaderLabelPrefix + feed.Folder,
+				Label: feed.Folder,
+			})
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"subscriptions": subscriptions})
+}
+
+// SubscriptionEdit handles ac=subscribe, unsubscribe and edit. Titles come in
+// t, and folder moves as a (add label) and r (remove label).
+func (h *GReaderHandler) SubscriptionEdit(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	switch r.FormValue("ac") {
+	case "subscribe", "unsubscribe", "edit":
+	default:
+		http.Error(w, "Unsupported action", http.StatusBadRequest)
+		return
+	}
+
+	for _, stream := range r.Form["s"] {
+		if err := h.editSubscription(userID, r, stream); err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+
+	writeGReaderOK(w)
+}
+
+func (h *GReaderHandler) editSubscription(userID int, r *http.Request, stream string) error {
+	target := strings.TrimPrefix(stream, greaderFeedPrefix)
+	title := strings.TrimSpace(r.FormValue("t"))
+	addLabel := greaderLabelName(r.FormValue("a"))
+
+	switch r.FormValue("ac") {
+	case "subscribe":
+		if title == "" {
+			title = target
+		}
+		_, err := h.feedService.CreateFeed(title, target, addLabel, userID)
+		return err
+	case "unsubscribe":
+		feedID, err := strconv.Atoi(target)
+		if err != nil {
+			return domain.ErrFeedNotFound
+		}
+		return h.feedService.DeleteFeed(feedID, userID)
+	case "edit":
+		feedID, err := strconv.Atoi(target)
+		if err != nil {
+			return domain.ErrFeedNotFound
+		}
+		feed, err := h.feedService.GetFeedByID(feedID, userID)
+		if err != nil {
+			return err
+		}
+
+		name, folder := feed.Name, feed.Folder
+		if title != "" {
+			name = title
+		}
+		if removeLabel := greaderLabelName(r.FormValue("r")); removeLabel != "" && removeLabel == folder {
+			folder = ""
+		}
+		if addLabel != "" {
+			folder = addLabel
+		}
+		return h.feedService.UpdateFeed(feed.ID, name, feed.URL, folder, userID)
+	}
+	return nil
+}
+
+func (h *GReaderHandler) QuickAdd(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	feedURL := strings.TrimPrefix(strings.TrimSpace(r.FormValue("quickadd")), greaderFeedPrefix)
+	feed, err := h.feedService.CreateFeed(feedURL, feedURL, "", userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"numResults": 1,
+		"query":      feedURL,
+		"streamId":   greaderFeedID(feed.ID),
+		"streamName": feed.Name,
+	})
+}
+
+func (h *GReaderHandler) TagList(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	folders, err := h.feedService.GetFoldersByUserID(userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	tags := []map[string]string{{"id": greaderStarred}}
+	for _, folder := range folders {
+		tags = append(tags, map[string]string{
+			"id":   greaderLabelPrefix + folder.Name,
+			"type": "folder",
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tags": tags})
+}
+
+// UnreadCount reports unread items per feed and per label, plus the
+// reading-list total.
+func (h *GReaderHandler) UnreadCount(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	feeds, err := h.feedService.GetFeedsByUserID(userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	feedCounts, err := h.feedService.GetUnreadCounts(userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	type counter struct {
+		count  int
+		newest time.Time
+	}
+	counts := make(map[string]*counter)
+	add := func(id string, count int, newest time.Time) {
+		c, ok := counts[id]
+		if !ok {
+			c = &counter{}
+			counts[id] = c
+		}
+		c.count += count
+		if newest.After(c.newest) {
+			c.newest = newest
+		}
+	}
+
+	folderByFeed := make(map[int]string, len(feeds))
+	for _, feed := range feeds {
+		folderByFeed[feed.ID] = feed.Folder
+	}
+
+	total := 0
+	for _, feedCount := range feedCounts {
+		total += feedCount.Count
+		add(greaderReadingList, feedCount.Count, feedCount.NewestAt)
+		add(greaderFeedID(feedCount.FeedID), feedCount.Count, feedCount.NewestAt)
+		if folder := folderByFeed[feedCount.FeedID]; folder != "" {
+			add(greaderLabelPrefix+folder, feedCount.Count, feedCount.NewestAt)
+		}
+	}
+
+	unreadCounts := make([]map[string]interface{}, 0, len(counts))
+	for id, c := range counts {
+		unreadCounts = append(unreadCounts, map[string]interface{}{
+			"id":                      id,
+			"count":                   c.count,
+			"newestItemTimestampUsec": strconv.FormatInt(c.newest.UnixMicro(), 10),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"max":          total,
+		"unreadcounts": unreadCounts,
+	})
+}
+
+// StreamItemIDs lists item references for a stream. Short IDs are the
+// decimal item IDs.
+func (h *GReaderHandler) StreamItemIDs(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	items, continuation, err := h.streamItems(userID, r, r.URL.Query().Get("s"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	refs := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		refs = append(refs, map[string]interface{}{
+			"id":              strconv.Itoa(item.ID),
+			"directStreamIds": []string{},
+			"timestampUsec":   strconv.FormatInt(item.PublishedAt.UnixMicro(), 10),
+		})
+	}
+
+	response := map[string]interface{}{"itemRefs": refs}
+	if continuation != "" {
+		response["continuation"] = continuation
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// StreamContents returns full items for the stream named in the path (or the
+// s parameter), defaulting to the reading list.
+func (h *GReaderHandler) StreamContents(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	stream := mux.Vars(r)["stream"]
+	if stream == "" {
+		stream = r.URL.Query().Get("s")
+	}
+
+	items, continuation, err := h.streamItems(userID, r, stream)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	response, err := h.itemsResponse(userID, stream, items)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if continuation != "" {
+		response["continuation"] = continuation
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// StreamItemsContents returns the items named by one or more i parameters.
+func (h *GReaderHandler) StreamItemsContents(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	items, err := h.feedService.GetItemsByIDs(userID, parseGReaderItemIDs(r.Form["i"]))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	response, err := h.itemsResponse(userID, greaderReadingList, items)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// EditTag adds (a) or removes (r) the read and starred states on items.
+func (h *GReaderHandler) EditTag(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	ids := parseGReaderItemIDs(r.Form["i"])
+	for _, id := range ids {
+		for _, tag := range r.Form["a"] {
+			if err := h.applyTag(userID, id, greaderStreamSuffix(tag), true); err != nil {
+				h.writeError(w, err)
+				return
+			}
+		}
+		for _, tag := range r.Form["r"] {
+			if err := h.applyTag(userID, id, greaderStreamSuffix(tag), false); err != nil {
+				h.writeError(w, err)
+				return
+			}
+		}
+	}
+
+	writeGReaderOK(w)
+}
+
+func (h *GReaderHandler) applyTag(userID, itemID int, tag string, add bool) error {
+	switch tag {
+	case greaderRead:
+		return h.feedService.SetItemRead(itemID, userID, add)
+	case greaderKeptUnread:
+		return h.feedService.SetItemRead(itemID, userID, !add)
+	case greaderStarred:
+		return h.feedService.SetItemStarred(itemID, userID, add)
+	}
+	return nil
+}
+
+// MarkAllAsRead marks a stream read up to ts (microseconds), or up to now.
+func (h *GReaderHandler) MarkAllAsRead(w http.ResponseWriter, r *http.Request) {
+	userID, _ := h.apiAuth.GetUserID(r)
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := h.streamFilter(userID, r.FormValue("s"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	// MarkReadBefore covers feeds and folders only; marking a state stream
+	// such as starred would mark every item read.
+	if filter.Status != "" {
+		h.writeError(w, domain.ErrInvalidItemFilter)
+		return
+	}
+
+	before := time.Now()
+	if ts, err := strconv.ParseInt(r.FormValue("ts"), 10, 64); err == nil && ts > 0 {
+		before = time.UnixMicro(ts)
+	}
+
+	if _, err := h.feedService.MarkReadBefore(userID, filter.FeedID, filter.FolderID, before); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeGReaderOK(w)
+}
+
+// streamItems reads one page of a stream, honouring n (count), r=o (oldest
+// first), ot/nt (time bounds in seconds), xt (exclude state), it (include
+// state) and c (continuation).
+func (h *GReaderHandler) streamItems(userID int, r *http.Request, stream string) ([]domain.FeedItem, string, error) {
+	query := r.URL.Query()
+
+	filter, err := h.streamFilter(userID, stream)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch greaderStreamSuffix(query.Get("xt")) {
+	case greaderRead:
+		err = filter.NarrowStatus(domain.ItemStatusUnread)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	switch greaderStreamSuffix(query.Get("it")) {
+	case greaderRead:
+		err = filter.NarrowStatus(domain.ItemStatusRead)
+	case greaderStarred:
+		err = filter.NarrowStatus(domain.ItemStatusStarred)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if query.Get("r") == "o" {
+		filter.Sort = domain.ItemSortOldest
+	}
+	if ot, err := strconv.ParseInt(query.Get("ot"), 10, 64); err == nil && ot > 0 {
+		filter.From = time.Unix(ot, 0)
+	}
+	if nt, err := strconv.ParseInt(query.Get("nt"), 10, 64); err == nil && nt > 0 {
+		filter.To = time.Unix(nt, 0)
+	}
+
+	count := greaderDefaultCount
+	if n, err := strconv.Atoi(query.Get("n")); err == nil && n > 0 {
+		count = min(n, greaderMaxCount)
+	}
+
+	var cursor *domain.ItemCursor
+	if value := query.Get("c"); value != "" {
+		cursor, err = domain.DecodeItemCursor(value)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return h.feedService.ListItems(userID, filter, cursor, count)
+}
+
+// streamFilter maps a stream ID onto an item filter.
+func (h *GReaderHandler) streamFilter(userID int, stream string) (domain.ItemFilter, error) {
+	var filter domain.ItemFilter
+	stream = greaderStreamSuffix(stream)
+
+	switch {
+	case stream == "" || stream == greaderReadingList:
+	case stream == greaderStarred:
+		filter.Status = domain.ItemStatusStarred
+	case stream == greaderRead:
+		filter.Status = domain.ItemStatusRead
+	case strings.HasPrefix(stream, greaderFeedPrefix):
+		feedID, err := strconv.Atoi(strings.TrimPrefix(stream, greaderFeedPrefix))
+		if err != nil || feedID <= 0 {
+			return filter, domain.ErrFeedNotFound
+		}
+		filter.FeedID = feedID
+	case strings.HasPrefix(stream, greaderLabelPrefix):
+		folderID, err := h.folderIDByName(userID, strings.TrimPrefix(stream, greaderLabelPrefix))
+		if err != nil {
+			return filter, err
+		}
+		filter.FolderID = folderID
+	default:
+		return filter, domain.ErrInvalidItemFilter
+	}
+
+	return filter, nil
+}
+
+func (h *GReaderHandler) folderIDByName(userID int, name string) (int, error) {
+	folders, err := h.feedService.GetFoldersByUserID(userID)
+	if err != nil {
+		return 0, err
+	}
+	for _, folder := range folders {
+		if folder.Name == name {
+			return folder.ID, nil
+		}
+	}
+	return 0, domain.ErrFolderNotFound
+}
+
+func (h *GReaderHandler) itemsResponse(userID int, stream string, items []domain.FeedItem) (map[string]interface{}, error) {
+	feeds, err := h.feedService.GetFeedsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	feedsByID := make(map[int]domain.Feed, len(feeds))
+	for _, feed := range feeds {
+		feedsByID[feed.ID] = feed
+	}
+
+	greaderItems := make([]greaderItem, 0, len(items))
+	for _, item := range items {
+		feed := feedsByID[item.FeedID]
+
+		categories := []string{greaderReadingList}
+		if item.IsRead {
+			categories = append(categories, greaderRead)
+		}
+		if item.IsStarred {
+			categories = append(categories, greaderStarred)
+		}
+		if feed.Folder != "" {
+			categories = append(categories, greaderLabelPrefix+feed.Folder)
+		}
+
+		greaderItems = append(greaderItems, greaderItem{
+			ID:            fmt.Sprintf("%s%016x", greaderItemPrefix, item.ID),
+			CrawlTimeMsec: strconv.FormatInt(item.PublishedAt.UnixMilli(), 10),
+			TimestampUsec: strconv.FormatInt(item.PublishedAt.UnixMicro(), 10),
+			Published:     item.PublishedAt.Unix(),
+			Updated:       item.PublishedAt.Unix(),
+			Title:         item.Title,
+			Canonical:     []greaderLink{{Href: item.Link}},
+			Alternate:     []greaderLink{{Href: item.Link, Type: "text/html"}},
+			Summary:       greaderContent{Direction: "ltr", Content: item.Description},
+			Categories:    categories,
+			Origin: greaderOrigin{
+				StreamID: greaderFeedID(item.FeedID),
+				Title:    item.FeedName,
+				HTMLURL:  feed.SiteURL,
+			},
+		})
+	}
+
+	if stream == "" {
+		stream = greaderReadingList
+	}
+	return map[string]interface{}{
+		"id":      stream,
+		"updated": time.Now().Unix(),
+		"items":   greaderItems,
+	}, nil
+}
+
+func (h *GReaderHandler) writeError(w http.ResponseWriter, err error) {
+	status, message := apiErrorStatus(err)
+	http.Error(w, message, status)
+}
+
+func writeGReaderOK(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, "OK")
+}
+
+func greaderFeedID(feedID int) string {
+	return greaderFeedPrefix + strconv.Itoa(feedID)
+}
+
+// greaderStreamSuffix rewrites "user/<id>/..." to the "user/-/..." form used
+// by the constants above.
+func greaderStreamSuffix(stream string) string {
+	if !strings.HasPrefix(stream, "user/") {
+		return stream
+	}
+	parts := strings.SplitN(stream, "/", 3)
+	if len(parts) < 3 {
+		return stream
+	}
+	return "user/-/" + parts[2]
+}
+
+func greaderLabelName(tag string) string {
+	tag = greaderStreamSuffix(tag)
+	if !strings.HasPrefix(tag, greaderLabelPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(tag, greaderLabelPrefix)
+}
+
+// parseGReaderItemIDs accepts the long form (tag:...item/<16 hex digits>) and
+// the short decimal form.
+func parseGReaderItemIDs(values []string) []int {
+	var ids []int
+	for _, value := range values {
+		if hex, ok := strings.CutPrefix(value, greaderItemPrefix); ok {
+			if id, err := strconv.ParseInt(hex, 16, 64); err == nil {
+				ids = append(ids, int(id))
+			}
+			continue
+		}
+		if id, err := strconv.ParseInt(value, 10, 64); err == nil {
+			ids = append(ids, int(id))
+		}
+	}
+	return ids
+}
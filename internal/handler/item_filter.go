@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/url"
+	"rss-reader/internal/domain"
+	"strconv"
+	"time"
+)
+
+const filterDateLayout = "2006-01-02"
+
+// parseItemFilter reads the shareable filter parameters (feed, folder, status,
+// from, to, sort) from a query string. Dates are whole local days and "to" is
+// inclusive.
+func parseItemFilter(values url.Values) (domain.ItemFilter, error) {
+	filter := domain.ItemFilter{
+		Status: values.Get("status"),
+		Sort:   values.Get("sort"),
+	}
+
+	if value := values.Get("feed"); value != "" {
+		feedID, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, domain.ErrInvalidItemFilter
+		}
+		filter.FeedID = feedID
+	}
+
+	if value := values.Get("folder"); value != "" {
+		folderID, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, domain.ErrInvalidItemFilter
+		}
+		filter.FolderID = folderID
+	}
+
+	if value := values.Get("from"); value != "" {
+		from, err := time.ParseInLocation(filterDateLayout, value, time.Local)
+		if err != nil {
+			return filter, domain.ErrInvalidItemFilter
+		}
+		filter.From = from
+	}
+
+	if value := values.Get("to"); value != "" {
+		to, err := time.ParseInLocation(filterDateLayout, value, time.Local)
+		if err != nil {
+			return filter, domain.ErrInvalidItemFilter
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	return filter, filter.Validate()
+}
+
+// encodeItemFilter is the inverse of parseItemFilter, omitting defaults so
+// shared URLs stay short.
+func encodeItemFilter(filter domain.ItemFilter) url.Values {
+	values := url.Values{}
+
+	if filter.FeedID > 0 {
+		values.Set("feed", strconv.Itoa(filter.FeedID))
+	}
+	if filter.FolderID > 0 {
+		values.Set("folder", strconv.Itoa(filter.FolderID))
+	}
+	if filter.Status != "" && filter.Status != domain.ItemStatusAll {
+		values.Set("status", filter.Status)
+	}
+	if !filter.From.IsZero() {
+		values.Set("from", filter.From.Format(filterDateLayout))
+	}
+	if !filter.To.IsZero() {
+		values.Set("to", filter.To.AddDate(0, 0, -1).Format(filterDateLayout))
+	}
+	if filter.Sort != "" && filter.Sort != domain.ItemSortNewest {
+		values.Set("sort", filter.Sort)
+	}
+
+	return values
+}
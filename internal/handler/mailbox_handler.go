@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"rss-reader/pkg/email"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+)
+
+// MailboxHandler shows the messages captured by the mailbox email backend.
+// It is only mounted in development and needs no login, since it is how
+// sign-in codes are read when no real email provider is configured.
+type MailboxHandler struct {
+	mailbox         *email.MailboxService
+	mailboxTemplate *template.Template
+}
+
+func NewMailboxHandler(mailbox *email.MailboxService) *MailboxHandler {
+	mailboxTemplate, err := template.ParseFiles("templates/dev_mailbox.html")
+	if err != nil {
+		log.Fatalf("Failed to parse dev_mailbox template: %v", err)
+	}
+
+	return &MailboxHandler{
+		mailbox:         mailbox,
+		mailboxTemplate: mailboxTemplate,
+	}
+}
+
+// Mailbox lists captured messages and shows the one selected with ?id=,
+// or the newest one.
+func (h *MailboxHandler) Mailbox(w http.ResponseWriter, r *http.Request) {
+	messages, err := h.mailbox.Messages()
+	if err != nil {
+		log.Printf("Error reading mailbox: %v", err)
+		http.Error(w, "Error reading mailbox", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Messages":  messages,
+		"csrfField": csrf.TemplateField(r),
+	}
+
+	if id := r.URL.Query().Get("id"); id != "" {
+		message, err := h.mailbox.Get(id)
+		if err != nil {
+			if err != email.ErrMailboxMessageNotFound {
+				log.Printf("Error reading mailbox message: %v", err)
+			}
+			data["Error"] = "Message not found."
+		} else {
+			data["Selected"] = message
+		}
+	} else if len(messages) > 0 {
+		data["Selected"] = &messages[0]
+	}
+
+	if err := h.mailboxTemplate.Execute(w, data); err != nil {
+		log.Printf("Error executing template: %v", err)
+		http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		return
+	}
+}
+
+// MessageHTML serves the HTML body of a message for display in a sandboxed
+// frame on the mailbox page.
+func (h *MailboxHandler) MessageHTML(w http.ResponseWriter, r *http.Request) {
+	message, err := h.mailbox.Get(mux.Vars(r)["id"])
+	if err != nil {
+		if err != email.ErrMailboxMessageNotFound {
+			log.Printf("Error reading mailbox message: %v", err)
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+	w.Header().Set("Content-Security-Policy", "sandbox; default-src 'none'; style-src 'unsafe-inline'; img-src data: http: https:;")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(message.HTML))
+}
+
+func (h *MailboxHandler) Clear(w http.ResponseWriter, r *http.Request) {
+	if err := h.mailbox.Clear(); err != nil {
+		log.Printf("Error clearing mailbox: %v", err)
+		http.Error(w, "Error clearing mailbox", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/dev/mailbox", http.StatusSeeOther)
+}
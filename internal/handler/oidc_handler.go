@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/middleware"
+	"rss-reader/internal/service"
+)
+
+// oidcRequestKey is the session key holding the state, nonce and PKCE
+// verifier of a single sign-on in progress.
+const oidcRequestKey = "oidc_request"
+
+// OIDCHandler signs users in through the configured OpenID Connect
+// provider. Failures send the browser back to the login page.
+type OIDCHandler struct {
+	oidcService    *service.OIDCService
+	authService    *service.AuthService
+	authMiddleware *middleware.AuthMiddleware
+}
+
+func NewOIDCHandler(oidcService *service.OIDCService, authService *service.AuthService, authMiddleware *middleware.AuthMiddleware) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService:    oidcService,
+		authService:    authService,
+		authMiddleware: authMiddleware,
+	}
+}
+
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	authURL, request, err := h.oidcService.BeginLogin(r.Context())
+	if err != nil {
+		log.Printf("Error starting single sign-on: %v", err)
+		http.Redirect(w, r, "/login?error=sso", http.StatusFound)
+		return
+	}
+
+	encoded, err := json.Marshal(request)
+	if err != nil {
+		log.Printf("Error encoding single sign-on request: %v", err)
+		http.Redirect(w, r, "/login?error=sso", http.StatusFound)
+		return
+	}
+
+	session, err := h.authMiddleware.GetSession(r)
+	if err == nil {
+		session.Values[oidcRequestKey] = string(encoded)
+		err = session.Save(r, w)
+	}
+	if err != nil {
+		log.Printf("Error saving single sign-on request: %v", err)
+		http.Redirect(w, r, "/login?error=sso", http.StatusFound)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback completes a sign-on when the provider redirects back. The saved
+// request is removed first, so each one can only be completed once.
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	session, err := h.authMiddleware.GetSession(r)
+	if err != nil {
+		http.Redirect(w, r, "/login?error=sso", http.StatusFound)
+		return
+	}
+
+	encoded, _ := session.Values[oidcRequestKey].(string)
+	delete(session.Values, oidcRequestKey)
+	if err := session.Save(r, w); err != nil {
+		log.Printf("Warning: failed to clear single sign-on request: %v", err)
+	}
+
+	var request service.OIDCAuthRequest
+	if encoded == "" || json.Unmarshal([]byte(encoded), &request) != nil {
+		http.Redirect(w, r, "/login?error=sso", http.StatusFound)
+		return
+	}
+
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		log.Printf("Identity provider refused sign-on: %s %s", providerError, query.Get("error_description"))
+		http.Redirect(w, r, "/login?error=sso", http.StatusFound)
+		return
+	}
+
+	email, err := h.oidcService.FinishLogin(r.Context(), request, query.Get("state"), query.Get("code"))
+	if err != nil {
+		if err == domain.ErrOIDCEmailUnverified {
+			http.Redirect(w, r, "/login?error=sso-unverified", http.StatusFound)
+			return
+		}
+		log.Printf("Single sign-on failed: %v", err)
+		http.Redirect(w, r, "/login?error=sso", http.StatusFound)
+		return
+	}
+
+	user, err := h.authService.GetOrCreateUser(email)
+	if err != nil {
+		log.Printf("Error getting user for single sign-on %s: %v", email, err)
+		if err == domain.ErrUserDisabled {
+			http.Redirect(w, r, "/login?error=disabled", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, "/login?error=sso", http.StatusFound)
+		return
+	}
+
+	if err := h.authMiddleware.SetUserSession(w, r, user.ID); err != nil {
+		log.Printf("Failed to set session for user %d: %v", user.ID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("User %s logged in with single sign-on", email)
+	http.Redirect(w, r, "/feeds", http.StatusFound)
+}
@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/middleware"
+	"rss-reader/internal/service"
+	"strconv"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+)
+
+// Session keys holding the WebAuthn challenge between the begin and finish
+// requests of a ceremony.
+const (
+	passkeyRegistrationKey = "passkey_registration"
+	passkeyLoginKey        = "passkey_login"
+	maxPasskeyResponseSize = 64 << 10
+)
+
+// PasskeyHandler serves passkey management for signed-in users and the
+// passkey sign-in endpoints used by the login page. The browser side of both
+// ceremonies lives in static/js/passkey.js.
+type PasskeyHandler struct {
+	passkeyService   *service.PasskeyService
+	authMiddleware   *middleware.AuthMiddleware
+	passkeysTemplate *template.Template
+}
+
+func NewPasskeyHandler(passkeyService *service.PasskeyService, authMiddleware *middleware.AuthMiddleware) *PasskeyHandler {
+	passkeysTemplate, err := template.ParseFiles("templates/passkeys.html")
+	if err != nil {
+		log.Fatalf("Failed to parse passkeys template: %v", err)
+	}
+
+	return &PasskeyHandler{
+		passkeyService:   passkeyService,
+		authMiddleware:   authMiddleware,
+		passkeysTemplate: passkeysTemplate,
+	}
+}
+
+func (h *PasskeyHandler) Passkeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	data := make(map[string]interface{})
+	switch r.URL.Query().Get("status") {
+	case "added":
+		data["Message"] = "Passkey added. You can now sign in with it."
+	case "deleted":
+		data["Message"] = "Passkey removed."
+	}
+	h.showPasskeysPage(w, r, userID, data)
+}
+
+func (h *PasskeyHandler) showPasskeysPage(w http.ResponseWriter, r *http.Request, userID int, data map[string]interface{}) {
+	passkeys, err := h.passkeyService.GetPasskeys(userID)
+	if err != nil {
+		log.Printf("Error getting passkeys for user %d: %v", userID, err)
+		http.Error(w, "Error getting passkeys", http.StatusInternalServerError)
+		return
+	}
+
+	data["Passkeys"] = passkeys
+	data["CSRFToken"] = csrf.Token(r)
+	data["csrfField"] = csrf.TemplateField(r)
+
+	if err := h.passkeysTemplate.Execute(w, data); err != nil {
+		log.Printf("Error executing template: %v", err)
+		http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *PasskeyHandler) BeginRegistration(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	creation, session, err := h.passkeyService.BeginRegistration(userID)
+	if err != nil {
+		log.Printf("Error starting passkey registration for user %d: %v", userID, err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Could not start passkey registration"})
+		return
+	}
+
+	if err := h.saveCeremony(w, r, passkeyRegistrationKey, session); err != nil {
+		log.Printf("Error saving passkey registration for user %d: %v", userID, err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Could not start passkey registration"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, creation)
+}
+
+// FinishRegistration takes the credential from navigator.credentials.create
+// as the JSON body and the passkey's name in the query string.
+func (h *PasskeyHandler) FinishRegistration(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	session, ok := h.takeCeremony(w, r, passkeyRegistrationKey)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Passkey registration expired, please try again"})
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxPasskeyResponseSize)
+	_, err := h.passkeyService.FinishRegistration(userID, r.URL.Query().Get("name"), *session, body)
+	if err != nil {
+		message := "Could not add passkey"
+		switch err {
+		case domain.ErrPasskeyVerification:
+			message = "The passkey could not be verified"
+		case domain.ErrInvalidPasskey:
+			message = "Passkey names can be at most 100 characters"
+		default:
+			log.Printf("Error finishing passkey registration for user %d: %v", userID, err)
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": message})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"redirect": "/passkeys?status=added"})
+}
+
+func (h *PasskeyHandler) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	passkeyID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid passkey ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.passkeyService.DeletePasskey(passkeyID, userID); err != nil {
+		log.Printf("Error deleting passkey %d: %v", passkeyID, err)
+		http.Error(w, "Error deleting passkey", http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, "/passkeys?status=deleted", http.StatusFound)
+}
+
+func (h *PasskeyHandler) BeginLogin(w http.ResponseWriter, r *http.Request) {
+	assertion, session, err := h.passkeyService.BeginLogin()
+	if err != nil {
+		log.Printf("Error starting passkey login: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Could not start passkey sign-in"})
+		return
+	}
+
+	if err := h.saveCeremony(w, r, passkeyLoginKey, session); err != nil {
+		log.Printf("Error saving passkey login: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Could not start passkey sign-in"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, assertion)
+}
+
+// FinishLogin takes the assertion from navigator.credentials.get and signs
+// the passkey's owner in.
+func (h *PasskeyHandler) FinishLogin(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.takeCeremony(w, r, passkeyLoginKey)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Passkey sign-in expired, please try again"})
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxPasskeyResponseSize)
+	user, err := h.passkeyService.FinishLogin(*session, body)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Passkey sign-in failed. Use an email code instead."})
+		return
+	}
+
+	if err := h.authMiddleware.SetUserSession(w, r, user.ID); err != nil {
+		log.Printf("Failed to set session for user %d: %v", user.ID, err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Internal server error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"redirect": "/feeds"})
+}
+
+// saveCeremony keeps the challenge in the signed session cookie, so the
+// finish request can only complete a ceremony this browser started.
+func (h *PasskeyHandler) saveCeremony(w http.ResponseWriter, r *http.Request, key string, data *webauthn.SessionData) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	session, err := h.authMiddleware.GetSession(r)
+	if err != nil {
+		return err
+	}
+	session.Values[key] = string(encoded)
+	return session.Save(r, w)
+}
+
+// takeCeremony returns and removes a saved challenge, so each one can only
+// be answered once.
+func (h *PasskeyHandler) takeCeremony(w http.ResponseWriter, r *http.Request, key string) (*webauthn.SessionData, bool) {
+	session, err := h.authMiddleware.GetSession(r)
+	if err != nil {
+		return nil, false
+	}
+
+	encoded, ok := session.Values[key].(string)
+	if !ok {
+		return nil, false
+	}
+	delete(session.Values, key)
+	if err := session.Save(r, w); err != nil {
+		log.Printf("Warning: failed to clear passkey challenge: %v", err)
+	}
+
+	var data webauthn.SessionData
+	if err := json.Unmarshal([]byte(encoded), &data); err != nil {
+		return nil, false
+	}
+	return &data, true
+}
@@ -0,0 +1,283 @@
+package handler
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/middleware"
+	"rss-reader/internal/service"
+	"rss-reader/pkg/webpush"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+)
+
+type PushHandler struct {
+	pushService    *service.PushService
+	feedService    *service.FeedService
+	localPush      *webpush.LocalService
+	authMiddleware *middleware.AuthMiddleware
+	pushTemplate   *template.Template
+}
+
+// NewPushHandler serves the notification settings page. localPush is the
+// development push-service stand-in and is nil in other environments.
+func NewPushHandler(
+	pushService *service.PushService,
+	feedService *service.FeedService,
+	localPush *webpush.LocalService,
+	authMiddleware *middleware.AuthMiddleware,
+) *PushHandler {
+	pushTemplate, err := template.ParseFiles("templates/push.html")
+	if err != nil {
+		log.Fatalf("Failed to parse push template: %v", err)
+	}
+
+	return &PushHandler{
+		pushService:    pushService,
+		feedService:    feedService,
+		localPush:      localPush,
+		authMiddleware: authMiddleware,
+		pushTemplate:   pushTemplate,
+	}
+}
+
+func (h *PushHandler) Notifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	h.showPushPage(w, r, userID, nil)
+}
+
+func (h *PushHandler) showPushPage(w http.ResponseWriter, r *http.Request, userID int, data map[string]interface{}) {
+	subscriptions, err := h.pushService.GetSubscriptions(userID)
+	if err != nil {
+		log.Printf("Error getting push subscriptions for user %d: %v", userID, err)
+		http.Error(w, "Error getting notification settings", http.StatusInternalServerError)
+		return
+	}
+
+	settings, err := h.pushService.GetSettings(userID)
+	if err != nil {
+		log.Printf("Error getting push settings for user %d: %v", userID, err)
+		http.Error(w, "Error getting notification settings", http.StatusInternalServerError)
+		return
+	}
+
+	feedIDs, err := h.pushService.GetFeedIDs(userID)
+	if err != nil {
+		log.Printf("Error getting push feeds for user %d: %v", userID, err)
+		http.Error(w, "Error getting notification settings", http.StatusInternalServerError)
+		return
+	}
+
+	feeds, err := h.feedService.GetFeedsByUserID(userID)
+	if err != nil {
+		log.Printf("Error getting feeds for user %d: %v", userID, err)
+		http.Error(w, "Error getting notification settings", http.StatusInternalServerError)
+		return
+	}
+
+	enabledFeeds := make(map[int]bool, len(feedIDs))
+	for _, feedID := range feedIDs {
+		enabledFeeds[feedID] = true
+	}
+
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data["Subscriptions"] = subscriptions
+	data["Settings"] = settings
+	data["Feeds"] = feeds
+	data["EnabledFeeds"] = enabledFeeds
+	data["Hours"] = digestHours
+	data["PublicKey"] = h.pushService.PublicKey()
+	data["LocalPush"] = h.localPush != nil
+	data["CSRFToken"] = csrf.Token(r)
+	data["csrfField"] = csrf.TemplateField(r)
+
+	if err := h.pushTemplate.Execute(w, data); err != nil {
+		log.Printf("Error executing template: %v", err)
+		http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Subscribe stores the PushSubscription posted by push.js.
+func (h *PushHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := h.pushService.Subscribe(userID, r.FormValue("endpoint"), r.FormValue("p256dh"),
+		r.FormValue("auth"), r.UserAgent())
+	if err != nil {
+		log.Printf("Error saving push subscription for user %d: %v", userID, err)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Invalid subscription",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
+
+func (h *PushHandler) RemoveDevice(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	subscriptionID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid device ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.pushService.RemoveSubscription(subscriptionID, userID); err != nil {
+		log.Printf("Error removing push subscription %d: %v", subscriptionID, err)
+		http.Error(w, "Error removing device", http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, "/notifications", http.StatusFound)
+}
+
+func (h *PushHandler) UpdateFeeds(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	var feedIDs []int
+	for _, value := range r.Form["feed_id"] {
+		feedID, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid feed ID", http.StatusBadRequest)
+			return
+		}
+		feedIDs = append(feedIDs, feedID)
+	}
+
+	if err := h.pushService.SetFeedIDs(userID, feedIDs); err != nil {
+		log.Printf("Error saving push feeds for user %d: %v", userID, err)
+		h.showPushPage(w, r, userID, map[string]interface{}{
+			"Error": "Could not save notification feeds.",
+		})
+		return
+	}
+
+	h.showPushPage(w, r, userID, map[string]interface{}{
+		"Message": "Notification feeds saved.",
+	})
+}
+
+func (h *PushHandler) UpdateQuietHours(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	start, startErr := strconv.Atoi(r.FormValue("quiet_start"))
+	end, endErr := strconv.Atoi(r.FormValue("quiet_end"))
+	settings := &domain.PushSettings{
+		UserID:     userID,
+		QuietStart: start,
+		QuietEnd:   end,
+		Timezone:   strings.TrimSpace(r.FormValue("timezone")),
+	}
+
+	if startErr != nil || endErr != nil || h.pushService.UpdateSettings(settings) != nil {
+		h.showPushPage(w, r, userID, map[string]interface{}{
+			"Error": "Please choose quiet hours and a valid timezone such as Europe/Berlin.",
+		})
+		return
+	}
+
+	h.showPushPage(w, r, userID, map[string]interface{}{
+		"Message": "Quiet hours saved.",
+	})
+}
+
+func (h *PushHandler) SendTest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	sent, err := h.pushService.SendTest(userID)
+	if err != nil {
+		log.Printf("Error sending test push for user %d: %v", userID, err)
+		h.showPushPage(w, r, userID, map[string]interface{}{
+			"Error": "Could not send a test notification.",
+		})
+		return
+	}
+
+	if sent == 0 {
+		h.showPushPage(w, r, userID, map[string]interface{}{
+			"Error": "No device accepted the test notification.",
+		})
+		return
+	}
+
+	h.showPushPage(w, r, userID, map[string]interface{}{
+		"Message": "Test notification sent to " + strconv.Itoa(sent) + " device(s).",
+	})
+}
+
+// AddLocalDevice subscribes a simulated device on the development push
+// service; what it receives is listed at /dev/push.
+func (h *PushHandler) AddLocalDevice(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if h.localPush == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	subscription, err := h.localPush.NewSubscription()
+	if err == nil {
+		err = h.pushService.Subscribe(userID, subscription.Endpoint, subscription.P256dh,
+			subscription.Auth, "Local test device")
+	}
+	if err != nil {
+		log.Printf("Error adding local push device for user %d: %v", userID, err)
+		http.Error(w, "Error adding device", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/notifications", http.StatusFound)
+}
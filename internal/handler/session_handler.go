@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"rss-reader/internal/middleware"
+	"rss-reader/internal/service"
+	"strconv"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+)
+
+// SessionHandler lists the browsers signed in to the user's account and
+// signs them out.
+type SessionHandler struct {
+	sessionService   *service.SessionService
+	authMiddleware   *middleware.AuthMiddleware
+	sessionsTemplate *template.Template
+}
+
+func NewSessionHandler(sessionService *service.SessionService, authMiddleware *middleware.AuthMiddleware) *SessionHandler {
+	sessionsTemplate, err := template.ParseFiles("templates/sessions.html")
+	if err != nil {
+		log.Fatalf("Failed to parse sessions template: %v", err)
+	}
+
+	return &SessionHandler{
+		sessionService:   sessionService,
+		authMiddleware:   authMiddleware,
+		sessionsTemplate: sessionsTemplate,
+	}
+}
+
+func (h *SessionHandler) Sessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	sessions, err := h.sessionService.GetSessions(userID, h.authMiddleware.SessionToken(r))
+	if err != nil {
+		log.Printf("Error getting sessions for user %d: %v", userID, err)
+		http.Error(w, "Error getting sessions", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Sessions":  sessions,
+		"csrfField": csrf.TemplateField(r),
+	}
+	switch r.URL.Query().Get("status") {
+	case "revoked":
+		data["Message"] = "Session revoked."
+	case "revoked-others":
+		data["Message"] = "All other sessions have been signed out."
+	}
+
+	if err := h.sessionsTemplate.Execute(w, data); err != nil {
+		log.Printf("Error executing template: %v", err)
+		http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	sessionID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.sessionService.Revoke(sessionID, userID); err != nil {
+		log.Printf("Error revoking session %d: %v", sessionID, err)
+		http.Error(w, "Error revoking session", http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, "/sessions?status=revoked", http.StatusFound)
+}
+
+func (h *SessionHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if _, err := h.sessionService.RevokeOthers(userID, h.authMiddleware.SessionToken(r)); err != nil {
+		log.Printf("Error revoking sessions for user %d: %v", userID, err)
+		http.Error(w, "Error revoking sessions", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/sessions?status=revoked-others", http.StatusFound)
+}
@@ -0,0 +1,444 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/middleware"
+	"rss-reader/internal/service"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+)
+
+const maxArchiveSize = 50 << 20
+
+type SettingsHandler struct {
+	retentionService *service.RetentionService
+	apiTokenService  *service.APITokenService
+	feverService     *service.FeverService
+	archiveService   *service.ArchiveService
+	digestService    *service.DigestService
+	mailer           *service.Mailer
+	authMiddleware   *middleware.AuthMiddleware
+	settingsTemplate *template.Template
+	appURL           string
+}
+
+func NewSettingsHandler(
+	retentionService *service.RetentionService,
+	apiTokenService *service.APITokenService,
+	feverService *service.FeverService,
+	archiveService *service.ArchiveService,
+	digestService *service.DigestService,
+	mailer *service.Mailer,
+	authMiddleware *middleware.AuthMiddleware,
+	appURL string,
+) *SettingsHandler {
+	settingsTemplate, err := template.ParseFiles("templates/settings.html")
+	if err != nil {
+		log.Fatalf("Failed to parse settings template: %v", err)
+	}
+
+	return &SettingsHandler{
+		retentionService: retentionService,
+		apiTokenService:  apiTokenService,
+		feverService:     feverService,
+		archiveService:   archiveService,
+		digestService:    digestService,
+		mailer:           mailer,
+		authMiddleware:   authMiddleware,
+		settingsTemplate: settingsTemplate,
+		appURL:           appURL,
+	}
+}
+
+func (h *SettingsHandler) Settings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if r.Method == "GET" {
+		h.showSettingsPage(w, r, userID, nil)
+		return
+	}
+
+	if r.Method == "POST" {
+		h.handleSettingsPost(w, r, userID)
+		return
+	}
+
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+}
+
+// showSettingsPage renders the settings page; data carries per-request extras
+// such as Message, Error or a freshly created NewToken.
+func (h *SettingsHandler) showSettingsPage(w http.ResponseWriter, r *http.Request, userID int, data map[string]interface{}) {
+	settings, err := h.retentionService.GetUserSettings(userID)
+	if err != nil {
+		log.Printf("Error getting settings for user %d: %v", userID, err)
+		http.Error(w, "Error getting settings", http.StatusInternalServerError)
+		return
+	}
+
+	tokens, err := h.apiTokenService.ListTokens(userID)
+	if err != nil {
+		log.Printf("Error getting API tokens for user %d: %v", userID, err)
+		http.Error(w, "Error getting settings", http.StatusInternalServerError)
+		return
+	}
+
+	feverEnabled, err := h.feverService.IsEnabled(userID)
+	if err != nil {
+		log.Printf("Error checking Fever API for user %d: %v", userID, err)
+		http.Error(w, "Error getting settings", http.StatusInternalServerError)
+		return
+	}
+
+	digest, err := h.digestService.GetSettings(userID)
+	if err != nil {
+		log.Printf("Error getting digest settings for user %d: %v", userID, err)
+		http.Error(w, "Error getting settings", http.StatusInternalServerError)
+		return
+	}
+
+	locale, err := h.mailer.UserLocale(userID)
+	if err != nil {
+		log.Printf("Error getting email language for user %d: %v", userID, err)
+		http.Error(w, "Error getting settings", http.StatusInternalServerError)
+		return
+	}
+
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data["Settings"] = settings
+	data["RetentionDefaults"] = h.retentionService.Defaults()
+	data["LastCleanup"] = h.retentionService.LastReport()
+	data["APITokens"] = tokens
+	data["FeverEnabled"] = feverEnabled
+	data["Digest"] = digest
+	data["DigestHours"] = digestHours
+	data["Weekdays"] = weekdays
+	data["Locale"] = locale
+	data["Locales"] = h.mailer.Locales()
+	data["DefaultLocale"] = h.mailer.DefaultLocale()
+	data["FeverURL"] = h.appURL + "/fever/"
+	data["csrfField"] = csrf.TemplateField(r)
+
+	if err := h.settingsTemplate.Execute(w, data); err != nil {
+		log.Printf("Error executing template: %v", err)
+		http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *SettingsHandler) handleSettingsPost(w http.ResponseWriter, r *http.Request, userID int) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := parseRetentionForm(r)
+	if err != nil {
+		h.showSettingsPage(w, r, userID, map[string]interface{}{
+			"Error": "Retention values must be whole numbers of zero or more.",
+		})
+		return
+	}
+
+	if err := h.retentionService.UpdateUserRetention(userID, policy); err != nil {
+		log.Printf("Error updating retention for user %d: %v", userID, err)
+		h.showSettingsPage(w, r, userID, map[string]interface{}{
+			"Error": "Could not save retention settings.",
+		})
+		return
+	}
+
+	h.showSettingsPage(w, r, userID, map[string]interface{}{
+		"Message": "Settings saved.",
+	})
+}
+
+func (h *SettingsHandler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	plaintext, token, err := h.apiTokenService.CreateToken(userID, r.FormValue("name"))
+	if err != nil {
+		log.Printf("Error creating API token for user %d: %v", userID, err)
+		h.showSettingsPage(w, r, userID, map[string]interface{}{
+			"Error": "Could not create API token. Please give it a name.",
+		})
+		return
+	}
+
+	h.showSettingsPage(w, r, userID, map[string]interface{}{
+		"Message":      "API token created. Copy it now, it will not be shown again.",
+		"NewToken":     plaintext,
+		"NewTokenName": token.Name,
+	})
+}
+
+func (h *SettingsHandler) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	tokenID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.apiTokenService.RevokeToken(tokenID, userID); err != nil {
+		log.Printf("Error revoking API token %d: %v", tokenID, err)
+		http.Error(w, "Error revoking token", http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, "/settings", http.StatusFound)
+}
+
+func (h *SettingsHandler) SetFeverPassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.feverService.SetPassword(userID, r.FormValue("password")); err != nil {
+		log.Printf("Error setting Fever password for user %d: %v", userID, err)
+		message := "Could not set Fever password."
+		if err == domain.ErrWeakPassword {
+			message = "Fever password must be at least 8 characters."
+		}
+		h.showSettingsPage(w, r, userID, map[string]interface{}{
+			"Error": message,
+		})
+		return
+	}
+
+	h.showSettingsPage(w, r, userID, map[string]interface{}{
+		"Message": "Fever API enabled. Sign in from your client with your email and this password.",
+	})
+}
+
+func (h *SettingsHandler) DisableFever(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if err := h.feverService.Disable(userID); err != nil {
+		log.Printf("Error disabling Fever API for user %d: %v", userID, err)
+		http.Error(w, "Error disabling Fever API", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/settings", http.StatusFound)
+}
+
+var (
+	digestHours = func() []int {
+		hours := make([]int, 24)
+		for i := range hours {
+			hours[i] = i
+		}
+		return hours
+	}()
+	weekdays = []time.Weekday{
+		time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+	}
+)
+
+func (h *SettingsHandler) UpdateDigest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.digestService.GetSettings(userID)
+	if err != nil {
+		log.Printf("Error getting digest settings for user %d: %v", userID, err)
+		http.Error(w, "Error getting settings", http.StatusInternalServerError)
+		return
+	}
+
+	hour, hourErr := strconv.Atoi(r.FormValue("send_hour"))
+	weekday, weekdayErr := strconv.Atoi(r.FormValue("weekday"))
+	if hourErr != nil || weekdayErr != nil {
+		h.showSettingsPage(w, r, userID, map[string]interface{}{
+			"Error": "Please choose a valid digest time.",
+		})
+		return
+	}
+
+	settings.Frequency = r.FormValue("frequency")
+	settings.SendHour = hour
+	settings.Weekday = time.Weekday(weekday)
+	settings.Timezone = strings.TrimSpace(r.FormValue("timezone"))
+
+	if err := h.digestService.UpdateSettings(settings); err != nil {
+		log.Printf("Error updating digest for user %d: %v", userID, err)
+		message := "Could not save digest settings."
+		if err == domain.ErrInvalidDigestSettings {
+			message = "Please choose a frequency, time and a valid timezone such as Europe/Berlin."
+		}
+		h.showSettingsPage(w, r, userID, map[string]interface{}{
+			"Error": message,
+		})
+		return
+	}
+
+	h.showSettingsPage(w, r, userID, map[string]interface{}{
+		"Message": "Digest settings saved.",
+	})
+}
+
+func (h *SettingsHandler) UpdateLocale(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.mailer.SetUserLocale(userID, r.FormValue("locale")); err != nil {
+		log.Printf("Error updating email language for user %d: %v", userID, err)
+		message := "Could not save email language."
+		if err == domain.ErrInvalidLocale {
+			message = "Please choose one of the listed languages."
+		}
+		h.showSettingsPage(w, r, userID, map[string]interface{}{
+			"Error": message,
+		})
+		return
+	}
+
+	h.showSettingsPage(w, r, userID, map[string]interface{}{
+		"Message": "Email language saved.",
+	})
+}
+
+// ExportArchive downloads the full account archive.
+func (h *SettingsHandler) ExportArchive(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	archive, err := h.archiveService.Export(userID)
+	if err != nil {
+		log.Printf("Error exporting archive for user %d: %v", userID, err)
+		http.Error(w, "Error exporting account", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("feedstream-archive-%s.json", archive.ExportedAt.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(archive); err != nil {
+		log.Printf("Error encoding archive: %v", err)
+	}
+}
+
+// RestoreArchive merges an uploaded account archive into the current account.
+func (h *SettingsHandler) RestoreArchive(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxArchiveSize)
+	file, _, err := r.FormFile("archive")
+	if err != nil {
+		h.showSettingsPage(w, r, userID, map[string]interface{}{
+			"Error": "Please choose an archive file to restore.",
+		})
+		return
+	}
+	defer file.Close()
+
+	report, err := h.archiveService.Restore(userID, file)
+	if err != nil {
+		log.Printf("Error restoring archive for user %d: %v", userID, err)
+		message := "Could not restore the archive. Nothing was changed."
+		if errors.Is(err, domain.ErrUnsupportedArchive) {
+			message = "This file is not a FeedStream archive this version can read."
+		}
+		h.showSettingsPage(w, r, userID, map[string]interface{}{
+			"Error": message,
+		})
+		return
+	}
+
+	h.showSettingsPage(w, r, userID, map[string]interface{}{
+		"Message": fmt.Sprintf("Archive restored: %d feeds and %d folders added, %d feeds updated, %d items added, %d items updated and %d alerts added.",
+			report.FeedsCreated, report.FoldersCreated, report.FeedsUpdated, report.ItemsCreated, report.ItemsUpdated, report.AlertsCreated),
+	})
+}
+
+// parseRetentionForm reads the retention_days and retention_max_items fields,
+// treating blank inputs as zero ("inherit").
+func parseRetentionForm(r *http.Request) (domain.RetentionPolicy, error) {
+	var policy domain.RetentionPolicy
+
+	if value := r.FormValue("retention_days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			return policy, err
+		}
+		policy.MaxAgeDays = days
+	}
+
+	if value := r.FormValue("retention_max_items"); value != "" {
+		items, err := strconv.Atoi(value)
+		if err != nil {
+			return policy, err
+		}
+		policy.MaxItems = items
+	}
+
+	return policy, policy.Validate()
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/middleware"
+	"rss-reader/internal/service"
+
+	"github.com/gorilla/csrf"
+)
+
+// TwoFactorHandler serves enrolment and management of the authenticator app
+// second factor. The sign-in prompt itself lives in AuthHandler.
+type TwoFactorHandler struct {
+	totpService       *service.TOTPService
+	authMiddleware    *middleware.AuthMiddleware
+	twoFactorTemplate *template.Template
+}
+
+func NewTwoFactorHandler(totpService *service.TOTPService, authMiddleware *middleware.AuthMiddleware) *TwoFactorHandler {
+	twoFactorTemplate, err := template.ParseFiles("templates/two_factor.html")
+	if err != nil {
+		log.Fatalf("Failed to parse two-factor template: %v", err)
+	}
+
+	return &TwoFactorHandler{
+		totpService:       totpService,
+		authMiddleware:    authMiddleware,
+		twoFactorTemplate: twoFactorTemplate,
+	}
+}
+
+func (h *TwoFactorHandler) TwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	data := make(map[string]interface{})
+	if r.URL.Query().Get("status") == "disabled" {
+		data["Message"] = "Two-factor authentication is off."
+	}
+	h.showTwoFactorPage(w, r, userID, data)
+}
+
+// showTwoFactorPage renders the page for the user's current state: the QR
+// code to scan while enrolling, or the recovery codes and disable form once
+// enabled. data may carry Message, Error or freshly issued RecoveryCodes.
+func (h *TwoFactorHandler) showTwoFactorPage(w http.ResponseWriter, r *http.Request, userID int, data map[string]interface{}) {
+	enabled, err := h.totpService.IsEnabled(userID)
+	if err != nil {
+		log.Printf("Error checking two-factor authentication for user %d: %v", userID, err)
+		http.Error(w, "Error getting two-factor settings", http.StatusInternalServerError)
+		return
+	}
+
+	if enabled {
+		left, err := h.totpService.RecoveryCodesLeft(userID)
+		if err != nil {
+			log.Printf("Error counting recovery codes for user %d: %v", userID, err)
+			http.Error(w, "Error getting two-factor settings", http.StatusInternalServerError)
+			return
+		}
+		data["RecoveryCodesLeft"] = left
+	} else {
+		enrollment, err := h.totpService.Enrollment(userID)
+		if err != nil {
+			log.Printf("Error starting two-factor enrolment for user %d: %v", userID, err)
+			http.Error(w, "Error getting two-factor settings", http.StatusInternalServerError)
+			return
+		}
+		data["Secret"] = enrollment.Secret
+		data["QRCode"] = template.URL(enrollment.QRCode)
+	}
+
+	data["Enabled"] = enabled
+	data["csrfField"] = csrf.TemplateField(r)
+
+	if err := h.twoFactorTemplate.Execute(w, data); err != nil {
+		log.Printf("Error executing template: %v", err)
+		http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Confirm enables two-factor authentication once the user enters a code
+// from the app they scanned the QR code into.
+func (h *TwoFactorHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	codes, err := h.totpService.ConfirmEnrollment(userID, r.FormValue("code"))
+	if err != nil {
+		h.showCodeError(w, r, userID, err)
+		return
+	}
+
+	h.showTwoFactorPage(w, r, userID, map[string]interface{}{
+		"Message":       "Two-factor authentication is on.",
+		"RecoveryCodes": codes,
+	})
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	codes, err := h.totpService.RegenerateRecoveryCodes(userID, r.FormValue("code"))
+	if err != nil {
+		h.showCodeError(w, r, userID, err)
+		return
+	}
+
+	h.showTwoFactorPage(w, r, userID, map[string]interface{}{
+		"Message":       "New recovery codes created. The old ones no longer work.",
+		"RecoveryCodes": codes,
+	})
+}
+
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if err := h.totpService.Disable(userID, r.FormValue("code")); err != nil {
+		h.showCodeError(w, r, userID, err)
+		return
+	}
+
+	http.Redirect(w, r, "/two-factor?status=disabled", http.StatusFound)
+}
+
+func (h *TwoFactorHandler) showCodeError(w http.ResponseWriter, r *http.Request, userID int, err error) {
+	message := "Invalid code. Please try again."
+	if err != domain.ErrInvalidTOTPCode {
+		log.Printf("Two-factor change failed for user %d: %v", userID, err)
+		message = "Could not check the code. Please wait a few minutes and try again."
+	}
+	h.showTwoFactorPage(w, r, userID, map[string]interface{}{"Error": message})
+}
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/middleware"
+	"rss-reader/internal/service"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+)
+
+type WebhookHandler struct {
+	webhookService   *service.WebhookService
+	feedService      *service.FeedService
+	alertService     *service.AlertService
+	authMiddleware   *middleware.AuthMiddleware
+	webhooksTemplate *template.Template
+}
+
+// webhookView pairs a webhook with a readable description of its scope.
+type webhookView struct {
+	domain.Webhook
+	Scope string
+}
+
+func NewWebhookHandler(
+	webhookService *service.WebhookService,
+	feedService *service.FeedService,
+	alertService *service.AlertService,
+	authMiddleware *middleware.AuthMiddleware,
+) *WebhookHandler {
+	webhooksTemplate, err := template.ParseFiles("templates/webhooks.html")
+	if err != nil {
+		log.Fatalf("Failed to parse webhooks template: %v", err)
+	}
+
+	return &WebhookHandler{
+		webhookService:   webhookService,
+		feedService:      feedService,
+		alertService:     alertService,
+		authMiddleware:   authMiddleware,
+		webhooksTemplate: webhooksTemplate,
+	}
+}
+
+func (h *WebhookHandler) Webhooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if r.Method == "POST" {
+		h.createWebhook(w, r, userID)
+		return
+	}
+
+	h.showWebhooksPage(w, r, userID, nil)
+}
+
+func (h *WebhookHandler) showWebhooksPage(w http.ResponseWriter, r *http.Request, userID int, data map[string]interface{}) {
+	webhooks, err := h.webhookService.GetWebhooks(userID)
+	if err != nil {
+		log.Printf("Error getting webhooks for user %d: %v", userID, err)
+		http.Error(w, "Error getting webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	deliveries, err := h.webhookService.GetRecentDeliveries(userID)
+	if err != nil {
+		log.Printf("Error getting webhook deliveries for user %d: %v", userID, err)
+		http.Error(w, "Error getting webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	feeds, err := h.feedService.GetFeedsByUserID(userID)
+	if err != nil {
+		log.Printf("Error getting feeds for user %d: %v", userID, err)
+		http.Error(w, "Error getting webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	folders, err := h.feedService.GetFoldersByUserID(userID)
+	if err != nil {
+		log.Printf("Error getting folders for user %d: %v", userID, err)
+		http.Error(w, "Error getting webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	alerts, err := h.alertService.GetAlerts(userID)
+	if err != nil {
+		log.Printf("Error getting alerts for user %d: %v", userID, err)
+		http.Error(w, "Error getting webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	feedNames := make(map[int]string, len(feeds))
+	for _, feed := range feeds {
+		feedNames[feed.ID] = feed.Name
+	}
+	folderNames := make(map[int]string, len(folders))
+	for _, folder := range folders {
+		folderNames[folder.ID] = folder.Name
+	}
+	alertNames := make(map[int]string, len(alerts))
+	for _, alert := range alerts {
+		alertNames[alert.ID] = alert.Name
+	}
+
+	views := make([]webhookView, len(webhooks))
+	for i, hook := range webhooks {
+		views[i] = webhookView{Webhook: hook, Scope: "all feeds"}
+		if hook.FeedID != 0 {
+			views[i].Scope = "feed " + feedNames[hook.FeedID]
+		} else if hook.FolderID != 0 {
+			views[i].Scope = "folder " + folderNames[hook.FolderID]
+		} else if hook.AlertID != 0 {
+			views[i].Scope = "alert " + alertNames[hook.AlertID]
+		}
+	}
+
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data["Webhooks"] = views
+	data["Deliveries"] = deliveries
+	data["Feeds"] = feeds
+	data["Folders"] = folders
+	data["Alerts"] = alerts
+	data["csrfField"] = csrf.TemplateField(r)
+
+	if err := h.webhooksTemplate.Execute(w, data); err != nil {
+		log.Printf("Error executing template: %v", err)
+		http.Error(w, "Error rendering page", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *WebhookHandler) createWebhook(w http.ResponseWriter, r *http.Request, userID int) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	scope, ok := parseWebhookScope(r.FormValue("scope"))
+	if !ok {
+		http.Error(w, "Invalid scope", http.StatusBadRequest)
+		return
+	}
+
+	hook, err := h.webhookService.CreateWebhook(userID, r.FormValue("name"), r.FormValue("url"),
+		scope.FeedID, scope.FolderID, scope.AlertID)
+	if err != nil {
+		log.Printf("Error creating webhook for user %d: %v", userID, err)
+		message := "Could not create webhook. Please give it a name."
+		if err == domain.ErrInvalidWebhookURL {
+			message = "Please enter a valid http or https URL."
+		}
+		h.showWebhooksPage(w, r, userID, map[string]interface{}{
+			"Error": message,
+		})
+		return
+	}
+
+	h.showWebhooksPage(w, r, userID, map[string]interface{}{
+		"Message":        "Webhook created. Copy the signing secret now, it will not be shown again.",
+		"NewSecret":      hook.Secret,
+		"NewWebhookName": hook.Name,
+	})
+}
+
+// parseWebhookScope reads the scope select: "" for all feeds, "feed:<id>",
+// "folder:<id>" or "alert:<id>". The result has at most one ID set.
+func parseWebhookScope(value string) (domain.Webhook, bool) {
+	var scope domain.Webhook
+	if value == "" {
+		return scope, true
+	}
+
+	kind, rawID, found := strings.Cut(value, ":")
+	id, err := strconv.Atoi(rawID)
+	if !found || err != nil || id <= 0 {
+		return scope, false
+	}
+
+	switch kind {
+	case "feed":
+		scope.FeedID = id
+	case "folder":
+		scope.FolderID = id
+	case "alert":
+		scope.AlertID = id
+	default:
+		return scope, false
+	}
+	return scope, true
+}
+
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	webhookID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(webhookID, userID); err != nil {
+		log.Printf("Error deleting webhook %d: %v", webhookID, err)
+		http.Error(w, "Error deleting webhook", http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, "/webhooks", http.StatusFound)
+}
+
+func (h *WebhookHandler) SendTest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authMiddleware.GetUserID(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	webhookID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.webhookService.SendTest(webhookID, userID); err != nil {
+		log.Printf("Error sending test event to webhook %d: %v", webhookID, err)
+		http.Error(w, "Error sending test event", http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, "/webhooks", http.StatusFound)
+}
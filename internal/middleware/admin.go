@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+)
+
+// AdminChecker reports whether a user has the admin role.
+type AdminChecker interface {
+	IsAdmin(userID int) (bool, error)
+}
+
+// AdminMiddleware restricts routes to administrators. It runs after
+// RequireAuth, so the request already has a signed-in user.
+type AdminMiddleware struct {
+	authMiddleware *AuthMiddleware
+	checker        AdminChecker
+}
+
+func NewAdminMiddleware(authMiddleware *AuthMiddleware, checker AdminChecker) *AdminMiddleware {
+	return &AdminMiddleware{
+		authMiddleware: authMiddleware,
+		checker:        checker,
+	}
+}
+
+func (m *AdminMiddleware) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := m.authMiddleware.GetUserID(r)
+		if !ok {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+
+		admin, err := m.checker.IsAdmin(userID)
+		if err != nil {
+			log.Printf("Error checking admin role for user %d: %v", userID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !admin {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+type contextKey string
+
+const apiUserIDKey contextKey = "api_user_id"
+
+// TokenAuthenticator resolves a bearer token to a user ID.
+type TokenAuthenticator interface {
+	Authenticate(token string) (int, error)
+}
+
+type APIAuthMiddleware struct {
+	authenticator TokenAuthenticator
+}
+
+func NewAPIAuthMiddleware(authenticator TokenAuthenticator) *APIAuthMiddleware {
+	return &APIAuthMiddleware{
+		authenticator: authenticator,
+	}
+}
+
+func (m *APIAuthMiddleware) RequireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			writeAPIUnauthorized(w)
+			return
+		}
+
+		userID, err := m.authenticator.Authenticate(token)
+		if err != nil {
+			writeAPIUnauthorized(w)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), apiUserIDKey, userID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireGoogleLogin authenticates Google Reader API clients, which send the
+// token obtained from ClientLogin as "Authorization: GoogleLogin auth=<token>".
+func (m *APIAuthMiddleware) RequireGoogleLogin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token, found := strings.CutPrefix(header, "GoogleLogin auth=")
+		if !found || token == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		userID, err := m.authenticator.Authenticate(token)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), apiUserIDKey, userID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (m *APIAuthMiddleware) GetUserID(r *http.Request) (int, bool) {
+	userID, ok := r.Context().Value(apiUserIDKey).(int)
+	return userID, ok
+}
+
+func writeAPIUnauthorized(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]string{"error": "invalid or missing API token"})
+}
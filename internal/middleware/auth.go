@@ -7,10 +7,10 @@ import (
 )
 
 type AuthMiddleware struct {
-	store *sessions.CookieStore
+	store *SessionStore
 }
 
-func NewAuthMiddleware(store *sessions.CookieStore) *AuthMiddleware {
+func NewAuthMiddleware(store *SessionStore) *AuthMiddleware {
 	return &AuthMiddleware{
 		store: store,
 	}
@@ -50,6 +50,10 @@ func (m *AuthMiddleware) SetUserSession(w http.ResponseWriter, r *http.Request,
 		return err
 	}
 
+	if err := m.store.Renew(session); err != nil {
+		return err
+	}
+
 	session.Values["authenticated"] = true
 	session.Values["user_id"] = userID
 
@@ -62,12 +66,21 @@ func (m *AuthMiddleware) ClearSession(w http.ResponseWriter, r *http.Request) er
 		return err
 	}
 
-	session.Values["authenticated"] = false
-	delete(session.Values, "user_id")
+	session.Options.MaxAge = -1
 
 	return session.Save(r, w)
 }
 
+// SessionToken returns the token identifying the current browser's
+// session, empty if it has none yet.
+func (m *AuthMiddleware) SessionToken(r *http.Request) string {
+	session, err := m.store.Get(r, "session")
+	if err != nil {
+		return ""
+	}
+	return session.ID
+}
+
 func (m *AuthMiddleware) GetSession(r *http.Request) (*sessions.Session, error) {
 	return m.store.Get(r, "session")
 }
\ No newline at end of file
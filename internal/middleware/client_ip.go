@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustedProxies decides which peers may report the client's address in
+// X-Forwarded-For. The header is ignored unless the request comes from one
+// of them, as anyone else could set it to dodge per-IP limits.
+type TrustedProxies struct {
+	networks []*net.IPNet
+}
+
+// ParseTrustedProxies accepts IP addresses and CIDR ranges. An empty list
+// trusts no proxy.
+func ParseTrustedProxies(entries []string) (*TrustedProxies, error) {
+	proxies := &TrustedProxies{}
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		proxies.networks = append(proxies.networks, network)
+	}
+	return proxies, nil
+}
+
+// ClientIP returns the address of the client that made the request. When
+// the peer is a trusted proxy, X-Forwarded-For is read from the right,
+// skipping further trusted proxies, so addresses a client prepends itself
+// are never used.
+func (p *TrustedProxies) ClientIP(r *http.Request) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+	if !p.trusts(ip) {
+		return ip
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !p.trusts(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+func (p *TrustedProxies) trusts(address string) bool {
+	if p == nil {
+		return false
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
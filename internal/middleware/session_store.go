@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/gob"
+	"fmt"
+	"net/http"
+	"rss-reader/internal/domain"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+// anonymousSessionMaxAge bounds how long the database keeps a session that
+// never signed in. Such sessions only carry sign-in state — the login link
+// binding, a passkey ceremony, OIDC state or a pending two-factor step —
+// all of which expire sooner, so anonymous clients cannot fill the sessions
+// table for the full session lifetime.
+const anonymousSessionMaxAge = 15 * time.Minute
+
+// SessionBackend persists sessions by the random token held in the cookie.
+type SessionBackend interface {
+	Load(token, ip string) (*domain.Session, error)
+	Create(token string, session *domain.Session) error
+	Update(token string, session *domain.Session) error
+	Delete(token string) error
+}
+
+// SessionStore is a sessions.Store that keeps session values in the
+// database and only a random token in the cookie. Deleting the session's
+// row signs the browser out on its next request.
+type SessionStore struct {
+	backend SessionBackend
+	proxies *TrustedProxies
+	Options *sessions.Options
+}
+
+func NewSessionStore(backend SessionBackend, proxies *TrustedProxies, options *sessions.Options) *SessionStore {
+	return &SessionStore{
+		backend: backend,
+		proxies: proxies,
+		Options: options,
+	}
+}
+
+func (s *SessionStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+func (s *SessionStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	options := *s.Options
+	session.Options = &options
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil || cookie.Value == "" {
+		return session, nil
+	}
+
+	record, err := s.backend.Load(cookie.Value, s.proxies.ClientIP(r))
+	if err != nil {
+		if err == domain.ErrSessionNotFound {
+			return session, nil
+		}
+		return session, err
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(record.Data)).Decode(&session.Values); err != nil {
+		return session, fmt.Errorf("failed to decode session: %w", err)
+	}
+	session.ID = cookie.Value
+	session.IsNew = false
+	return session, nil
+}
+
+func (s *SessionStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.backend.Delete(session.ID); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(session.Values); err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	record := &domain.Session{
+		Data:      data.Bytes(),
+		ExpiresAt: time.Now().Add(anonymousSessionMaxAge),
+	}
+	if authenticated, _ := session.Values["authenticated"].(bool); authenticated {
+		record.UserID, _ = session.Values["user_id"].(int)
+		record.ExpiresAt = time.Now().Add(time.Duration(session.Options.MaxAge) * time.Second)
+	}
+
+	if session.ID == "" {
+		token, err := newSessionToken()
+		if err != nil {
+			return err
+		}
+		record.UserAgent = r.UserAgent()
+		record.IP = s.proxies.ClientIP(r)
+		if err := s.backend.Create(token, record); err != nil {
+			return err
+		}
+		session.ID = token
+	} else if err := s.backend.Update(session.ID, record); err != nil {
+		// A session revoked mid-request stays revoked rather than being
+		// written back.
+		return err
+	}
+
+	http.SetCookie(w, sessions.NewCookie(session.Name(), session.ID, session.Options))
+	return nil
+}
+
+// Renew discards the session's stored record so that the next Save issues a
+// new token. Calling it at login stops a token planted in the browser
+// beforehand from being used to ride the signed-in session.
+func (s *SessionStore) Renew(session *sessions.Session) error {
+	if session.ID != "" {
+		if err := s.backend.Delete(session.ID); err != nil {
+			return err
+		}
+	}
+	session.ID = ""
+	session.IsNew = true
+	return nil
+}
+
+func newSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"rss-reader/pkg/ratelimit"
+	"strconv"
+	"time"
+)
+
+// Throttle limits how often a single client IP may call the routes it
+// wraps. Each group of routes gets its own Throttle and limiter, so heavy
+// use of one does not eat into the budget of another.
+type Throttle struct {
+	name     string
+	limiter  ratelimit.Limiter
+	proxies  *TrustedProxies
+	requests int
+	window   time.Duration
+}
+
+// NewThrottle allows requests per window for each client IP. A
+// non-positive requests disables the throttle.
+func NewThrottle(name string, limiter ratelimit.Limiter, proxies *TrustedProxies, requests int, window time.Duration) *Throttle {
+	return &Throttle{
+		name:     name,
+		limiter:  limiter,
+		proxies:  proxies,
+		requests: requests,
+		window:   window,
+	}
+}
+
+// Allow counts r against its client IP's budget and reports whether it was
+// within it, for handlers that skip the throttled work rather than refuse
+// the request.
+func (t *Throttle) Allow(r *http.Request) bool {
+	if t.requests <= 0 {
+		return true
+	}
+	return t.limiter.Allow(t.proxies.ClientIP(r), t.requests, t.window)
+}
+
+func (t *Throttle) Limit(next http.Handler) http.Handler {
+	if t.requests <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := t.proxies.ClientIP(r)
+		if !t.limiter.Allow(ip, t.requests, t.window) {
+			retryAfter := t.limiter.RetryAfter(ip, t.requests, t.window)
+			if retryAfter <= 0 {
+				retryAfter = time.Second
+			}
+			log.Printf("Throttled %s request from %s to %s", t.name, ip, r.URL.Path)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			http.Error(w, "Too many requests, please try again later", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+)
+
+// AdminRepository answers instance-wide questions for the admin area, across
+// all users.
+type AdminRepository interface {
+	GetUserOverviews() ([]domain.UserOverview, error)
+	GetStorageUsage() (*domain.StorageUsage, error)
+	GetFailingFeeds(limit int) ([]domain.FailingFeed, error)
+}
+
+type adminRepository struct {
+	db *sql.DB
+}
+
+func NewAdminRepository(db *sql.DB) AdminRepository {
+	return &adminRepository{db: db}
+}
+
+// withColumns lets scanUser and scanFeed read rows that carry extra columns
+// after their own, which are scanned into extra.
+type withColumns struct {
+	row   rowScanner
+	extra []interface{}
+}
+
+func (w withColumns) Scan(dest ...interface{}) error {
+	return w.row.Scan(append(dest, w.extra...)...)
+}
+
+// GetUserOverviews lists every user with their feed and item counts. Storage
+// is the on-disk size of the user's items, which make up nearly all of it.
+func (r *adminRepository) GetUserOverviews() ([]domain.UserOverview, error) {
+	rows, err := r.db.Query(
+		`SELECT u.id, u.email, u.locale, u.role, u.disabled_at, u.created_at,
+			COALESCE(f.feed_count, 0), COALESCE(i.item_count, 0), COALESCE(i.item_bytes, 0), s.last_seen_at
+		FROM users u
+		LEFT JOIN (
+			SELECT user_id, COUNT(*) AS feed_count FROM feeds GROUP BY user_id
+		) f ON f.user_id = u.id
+		LEFT JOIN (
+			SELECT fe.user_id, COUNT(*) AS item_count, SUM(pg_column_size(fi.*)) AS item_bytes
+			FROM feed_items fi
+			JOIN feeds fe ON fe.id = fi.feed_id
+			GROUP BY fe.user_id
+		) i ON i.user_id = u.id
+		LEFT JOIN (
+			SELECT user_id, MAX(last_seen_at) AS last_seen_at FROM sessions WHERE user_id IS NOT NULL GROUP BY user_id
+		) s ON s.user_id = u.id
+		ORDER BY u.created_at`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []domain.UserOverview
+	for rows.Next() {
+		var user domain.UserOverview
+		var lastSeenAt sql.NullTime
+		row := withColumns{rows, []interface{}{&user.FeedCount, &user.ItemCount, &user.StorageBytes, &lastSeenAt}}
+		if err := scanUser(row, &user.User); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		if lastSeenAt.Valid {
+			user.LastSeenAt = &lastSeenAt.Time
+		}
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return users, nil
+}
+
+func (r *adminRepository) GetStorageUsage() (*domain.StorageUsage, error) {
+	usage := &domain.StorageUsage{}
+
+	if err := r.db.QueryRow("SELECT pg_database_size(current_database())").Scan(&usage.DatabaseBytes); err != nil {
+		return nil, fmt.Errorf("failed to get database size: %w", err)
+	}
+
+	// Row counts are the planner's estimates, which avoids scanning the
+	// large tables; they are -1 for tables not yet analysed.
+	rows, err := r.db.Query(
+		`SELECT c.relname, GREATEST(c.reltuples, 0)::BIGINT, pg_total_relation_size(c.oid)
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind = 'r' AND n.nspname = current_schema()
+		ORDER BY pg_total_relation_size(c.oid) DESC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table sizes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table domain.TableUsage
+		if err := rows.Scan(&table.Name, &table.Rows, &table.Bytes); err != nil {
+			return nil, fmt.Errorf("failed to scan table size: %w", err)
+		}
+		usage.Tables = append(usage.Tables, table)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating table sizes: %w", err)
+	}
+
+	return usage, nil
+}
+
+// GetFailingFeeds returns feeds whose latest refresh failed, those failing
+// longest first.
+func (r *adminRepository) GetFailingFeeds(limit int) ([]domain.FailingFeed, error) {
+	rows, err := r.db.Query(
+		`SELECT `+feedColumns+`, u.email
+		FROM feeds f
+		LEFT JOIN folders fo ON fo.id = f.folder_id
+		JOIN users u ON u.id = f.user_id
+		WHERE f.failure_count > 0
+		ORDER BY f.failure_count DESC, f.last_failed_at DESC
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get failing feeds: %w", err)
+	}
+	defer rows.Close()
+
+	var feeds []domain.FailingFeed
+	for rows.Next() {
+		var feed domain.FailingFeed
+		if err := scanFeed(withColumns{rows, []interface{}{&feed.OwnerEmail}}, &feed.Feed); err != nil {
+			return nil, fmt.Errorf("failed to scan feed: %w", err)
+		}
+		feeds = append(feeds, feed)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating failing feeds: %w", err)
+	}
+
+	return feeds, nil
+}
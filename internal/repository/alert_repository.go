@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type AlertRepository interface {
+	Create(alert *domain.Alert) error
+	GetAllByUserID(userID int) ([]domain.Alert, error)
+	Delete(alertID, userID int) error
+	RecordMatches(alertID int, itemIDs []int) error
+	GetDue(now time.Time) ([]domain.Alert, error)
+	GetPendingItems(alertID, limit int) ([]domain.FeedItem, error)
+	MarkSent(alertID int, itemIDs []int, sentAt time.Time) error
+}
+
+type alertRepository struct {
+	db *sql.DB
+}
+
+func NewAlertRepository(db *sql.DB) AlertRepository {
+	return &alertRepository{db: db}
+}
+
+const alertColumns = `id, user_id, name, pattern, is_regex, batch_minutes, last_sent_at, created_at`
+
+func scanAlert(row rowScanner, alert *domain.Alert) error {
+	var lastSentAt sql.NullTime
+
+	err := row.Scan(&alert.ID, &alert.UserID, &alert.Name, &alert.Pattern, &alert.IsRegex,
+		&alert.BatchMinutes, &lastSentAt, &alert.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if lastSentAt.Valid {
+		alert.LastSentAt = &lastSentAt.Time
+	}
+	return nil
+}
+
+func scanAlerts(rows *sql.Rows) ([]domain.Alert, error) {
+	defer rows.Close()
+
+	var alerts []domain.Alert
+	for rows.Next() {
+		var alert domain.Alert
+		if err := scanAlert(rows, &alert); err != nil {
+			return nil, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+func (r *alertRepository) Create(alert *domain.Alert) error {
+	err := r.db.QueryRow(`
+		INSERT INTO alerts (user_id, name, pattern, is_regex, batch_minutes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`,
+		alert.UserID, alert.Name, alert.Pattern, alert.IsRegex, alert.BatchMinutes,
+	).Scan(&alert.ID, &alert.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create alert: %w", err)
+	}
+
+	return nil
+}
+
+func (r *alertRepository) GetAllByUserID(userID int) ([]domain.Alert, error) {
+	rows, err := r.db.Query(
+		"SELECT "+alertColumns+" FROM alerts WHERE user_id = $1 ORDER BY name, id",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alerts: %w", err)
+	}
+
+	return scanAlerts(rows)
+}
+
+func (r *alertRepository) Delete(alertID, userID int) error {
+	result, err := r.db.Exec("DELETE FROM alerts WHERE id = $1 AND user_id = $2", alertID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrAlertNotFound
+	}
+
+	return nil
+}
+
+// RecordMatches queues items for the alert's next email. Items already
+// queued or sent are ignored, so an item is never reported twice.
+func (r *alertRepository) RecordMatches(alertID int, itemIDs []int) error {
+	_, err := r.db.Exec(`
+		INSERT INTO alert_matches (alert_id, item_id)
+		SELECT $1, unnest($2::int[])
+		ON CONFLICT DO NOTHING`,
+		alertID, pq.Array(itemIDs))
+
+	if err != nil {
+		return fmt.Errorf("failed to record alert matches: %w", err)
+	}
+
+	return nil
+}
+
+// GetDue returns alerts with queued matches whose batching window has
+// passed.
+func (r *alertRepository) GetDue(now time.Time) ([]domain.Alert, error) {
+	rows, err := r.db.Query(`
+		SELECT `+alertColumns+`
+		FROM alerts a
+		WHERE (a.last_sent_at IS NULL OR a.last_sent_at + a.batch_minutes * INTERVAL '1 minute' <= $1)
+		AND EXISTS (
+			SELECT 1 FROM alert_matches m
+			WHERE m.alert_id = a.id AND m.sent_at IS NULL
+		)
+		ORDER BY a.id`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due alerts: %w", err)
+	}
+
+	return scanAlerts(rows)
+}
+
+func (r *alertRepository) GetPendingItems(alertID, limit int) ([]domain.FeedItem, error) {
+	rows, err := r.db.Query(`
+		SELECT `+itemColumns+`
+		FROM alert_matches m
+		JOIN feed_items i ON i.id = m.item_id
+		JOIN feeds f ON i.feed_id = f.id
+		WHERE m.alert_id = $1 AND m.sent_at IS NULL
+		ORDER BY i.published_at DESC, i.id DESC
+		LIMIT $2`, alertID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert matches: %w", err)
+	}
+
+	return scanFeedItems(rows)
+}
+
+// MarkSent records that the items went out in an email and starts the
+// alert's next batching window.
+func (r *alertRepository) MarkSent(alertID int, itemIDs []int, sentAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin alert update: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE alert_matches SET sent_at = $1
+		WHERE alert_id = $2 AND item_id = ANY($3)`,
+		sentAt, alertID, pq.Array(itemIDs))
+	if err != nil {
+		return fmt.Errorf("failed to mark alert matches sent: %w", err)
+	}
+
+	_, err = tx.Exec("UPDATE alerts SET last_sent_at = $1 WHERE id = $2", sentAt, alertID)
+	if err != nil {
+		return fmt.Errorf("failed to update alert send time: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit alert update: %w", err)
+	}
+
+	return nil
+}
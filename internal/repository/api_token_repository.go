@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+)
+
+type APITokenRepository interface {
+	Create(token *domain.APIToken) error
+	GetByHash(tokenHash string) (*domain.APIToken, error)
+	GetAllByUserID(userID int) ([]domain.APIToken, error)
+	Delete(tokenID, userID int) error
+	TouchLastUsed(tokenID int) error
+}
+
+type apiTokenRepository struct {
+	db *sql.DB
+}
+
+func NewAPITokenRepository(db *sql.DB) APITokenRepository {
+	return &apiTokenRepository{db: db}
+}
+
+func (r *apiTokenRepository) Create(token *domain.APIToken) error {
+	err := r.db.QueryRow(
+		"INSERT INTO api_tokens (user_id, name, prefix, token_hash) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		token.UserID, token.Name, token.Prefix, token.TokenHash,
+	).Scan(&token.ID, &token.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash only finds tokens of users who are not disabled.
+func (r *apiTokenRepository) GetByHash(tokenHash string) (*domain.APIToken, error) {
+	token := &domain.APIToken{}
+	var lastUsedAt sql.NullTime
+
+	err := r.db.QueryRow(
+		`SELECT t.id, t.user_id, t.name, t.prefix, t.token_hash, t.created_at, t.last_used_at
+		FROM api_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1 AND u.disabled_at IS NULL`,
+		tokenHash,
+	).Scan(&token.ID, &token.UserID, &token.Name, &token.Prefix, &token.TokenHash, &token.CreatedAt, &lastUsedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrAPITokenNotFound
+		}
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return token, nil
+}
+
+func (r *apiTokenRepository) GetAllByUserID(userID int) ([]domain.APIToken, error) {
+	rows, err := r.db.Query(
+		"SELECT id, user_id, name, prefix, created_at, last_used_at FROM api_tokens WHERE user_id = $1 ORDER BY created_at DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []domain.APIToken
+	for rows.Next() {
+		var token domain.APIToken
+		var lastUsedAt sql.NullTime
+		if err := rows.Scan(&token.ID, &token.UserID, &token.Name, &token.Prefix, &token.CreatedAt, &lastUsedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan API token: %w", err)
+		}
+		if lastUsedAt.Valid {
+			token.LastUsedAt = &lastUsedAt.Time
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (r *apiTokenRepository) Delete(tokenID, userID int) error {
+	result, err := r.db.Exec("DELETE FROM api_tokens WHERE id = $1 AND user_id = $2", tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete API token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrAPITokenNotFound
+	}
+
+	return nil
+}
+
+func (r *apiTokenRepository) TouchLastUsed(tokenID int) error {
+	_, err := r.db.Exec("UPDATE api_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1", tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to update API token usage: %w", err)
+	}
+	return nil
+}
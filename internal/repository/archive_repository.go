@@ -0,0 +1,407 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+	"time"
+)
+
+type ArchiveRepository interface {
+	Export(userID int) (*domain.Archive, error)
+	Restore(userID int, archive *domain.Archive, unsubscribeToken string) (*domain.RestoreReport, error)
+}
+
+type archiveRepository struct {
+	db *sql.DB
+}
+
+func NewArchiveRepository(db *sql.DB) ArchiveRepository {
+	return &archiveRepository{db: db}
+}
+
+func (r *archiveRepository) Export(userID int) (*domain.Archive, error) {
+	archive := &domain.Archive{
+		Format:     domain.ArchiveFormat,
+		Version:    domain.ArchiveVersion,
+		ExportedAt: time.Now().UTC(),
+		Folders:    []domain.ArchiveFolder{},
+		Feeds:      []domain.ArchiveFeed{},
+		Items:      []domain.ArchiveItem{},
+		Alerts:     []domain.ArchiveAlert{},
+	}
+
+	err := r.db.QueryRow(
+		"SELECT retention_days, retention_max_items FROM user_settings WHERE user_id = $1",
+		userID,
+	).Scan(&archive.Preferences.Retention.MaxAgeDays, &archive.Preferences.Retention.MaxItems)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to export preferences: %w", err)
+	}
+
+	digest := &domain.ArchiveDigest{}
+	err = r.db.QueryRow(
+		"SELECT frequency, send_hour, weekday, timezone FROM digest_subscriptions WHERE user_id = $1",
+		userID,
+	).Scan(&digest.Frequency, &digest.SendHour, &digest.Weekday, &digest.Timezone)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to export digest settings: %w", err)
+	}
+	if err == nil {
+		archive.Preferences.Digest = digest
+	}
+
+	push := &domain.ArchivePush{FeedURLs: []string{}}
+	err = r.db.QueryRow(
+		"SELECT quiet_start, quiet_end, timezone FROM push_settings WHERE user_id = $1",
+		userID,
+	).Scan(&push.QuietStart, &push.QuietEnd, &push.Timezone)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to export notification settings: %w", err)
+	}
+	hasPushSettings := err == nil
+
+	pushRows, err := r.db.Query(`
+		SELECT f.url
+		FROM push_feeds p
+		JOIN feeds f ON f.id = p.feed_id
+		WHERE p.user_id = $1
+		ORDER BY f.url`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export notification feeds: %w", err)
+	}
+	defer pushRows.Close()
+
+	for pushRows.Next() {
+		var feedURL string
+		if err := pushRows.Scan(&feedURL); err != nil {
+			return nil, fmt.Errorf("failed to scan notification feed: %w", err)
+		}
+		push.FeedURLs = append(push.FeedURLs, feedURL)
+	}
+	if err = pushRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification feeds: %w", err)
+	}
+	if hasPushSettings || len(push.FeedURLs) > 0 {
+		if !hasPushSettings {
+			push.Timezone = "UTC"
+		}
+		archive.Preferences.Push = push
+	}
+
+	folderRows, err := r.db.Query("SELECT name FROM folders WHERE user_id = $1 ORDER BY name", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export folders: %w", err)
+	}
+	defer folderRows.Close()
+
+	for folderRows.Next() {
+		var folder domain.ArchiveFolder
+		if err := folderRows.Scan(&folder.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan folder: %w", err)
+		}
+		archive.Folders = append(archive.Folders, folder)
+	}
+	if err = folderRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating folders: %w", err)
+	}
+
+	feedRows, err := r.db.Query(`
+		SELECT f.name, f.url, f.site_url, COALESCE(fo.name, ''), f.retention_days, f.retention_max_items
+		FROM feeds f
+		LEFT JOIN folders fo ON fo.id = f.folder_id
+		WHERE f.user_id = $1
+		ORDER BY f.name`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export feeds: %w", err)
+	}
+	defer feedRows.Close()
+
+	for feedRows.Next() {
+		var feed domain.ArchiveFeed
+		if err := feedRows.Scan(&feed.Name, &feed.URL, &feed.SiteURL, &feed.Folder,
+			&feed.Retention.MaxAgeDays, &feed.Retention.MaxItems); err != nil {
+			return nil, fmt.Errorf("failed to scan feed: %w", err)
+		}
+		archive.Feeds = append(archive.Feeds, feed)
+	}
+	if err = feedRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feeds: %w", err)
+	}
+
+	itemRows, err := r.db.Query(`
+		SELECT f.url, i.title, i.link,
+			CASE WHEN i.is_starred THEN COALESCE(i.description, '') ELSE '' END,
+			COALESCE(i.published_at, i.created_at), i.is_read, i.is_starred
+		FROM feed_items i
+		JOIN feeds f ON i.feed_id = f.id
+		WHERE f.user_id = $1 AND (i.is_read OR i.is_starred)
+		ORDER BY f.url, i.published_at DESC, i.id DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export items: %w", err)
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item domain.ArchiveItem
+		if err := itemRows.Scan(&item.FeedURL, &item.Title, &item.Link, &item.Description,
+			&item.PublishedAt, &item.IsRead, &item.IsStarred); err != nil {
+			return nil, fmt.Errorf("failed to scan item: %w", err)
+		}
+		archive.Items = append(archive.Items, item)
+	}
+	if err = itemRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating items: %w", err)
+	}
+
+	alertRows, err := r.db.Query(
+		"SELECT name, pattern, is_regex, batch_minutes FROM alerts WHERE user_id = $1 ORDER BY created_at, id",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export alerts: %w", err)
+	}
+	defer alertRows.Close()
+
+	for alertRows.Next() {
+		var alert domain.ArchiveAlert
+		if err := alertRows.Scan(&alert.Name, &alert.Pattern, &alert.IsRegex, &alert.BatchMinutes); err != nil {
+			return nil, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		archive.Alerts = append(archive.Alerts, alert)
+	}
+	if err = alertRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alerts: %w", err)
+	}
+
+	return archive, nil
+}
+
+// Restore merges an archive into the account in a single transaction. The
+// merge only fills gaps: existing feeds keep their name and only pick up a
+// folder, site URL or retention they lack, preferences are applied only
+// where unset, alerts are added unless one with the same pattern exists, and
+// read/starred flags are only ever turned on. That makes a repeated restore a
+// no-op. unsubscribeToken is used if the digest settings are restored.
+func (r *archiveRepository) Restore(userID int, archive *domain.Archive, unsubscribeToken string) (*domain.RestoreReport, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin restore: %w", err)
+	}
+	defer tx.Rollback()
+
+	report := &domain.RestoreReport{}
+
+	retention := archive.Preferences.Retention
+	if !retention.IsZero() {
+		_, err := tx.Exec(`
+			INSERT INTO user_settings (user_id, retention_days, retention_max_items)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id) DO UPDATE SET
+			retention_days = CASE WHEN user_settings.retention_days = 0 THEN EXCLUDED.retention_days ELSE user_settings.retention_days END,
+			retention_max_items = CASE WHEN user_settings.retention_max_items = 0 THEN EXCLUDED.retention_max_items ELSE user_settings.retention_max_items END`,
+			userID, retention.MaxAgeDays, retention.MaxItems)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore preferences: %w", err)
+		}
+	}
+
+	if digest := archive.Preferences.Digest; digest != nil {
+		_, err := tx.Exec(`
+			INSERT INTO digest_subscriptions (user_id, frequency, send_hour, weekday, timezone, unsubscribe_token)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (user_id) DO NOTHING`,
+			userID, digest.Frequency, digest.SendHour, int(digest.Weekday), digest.Timezone, unsubscribeToken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore digest settings: %w", err)
+		}
+	}
+
+	push := archive.Preferences.Push
+	if push != nil {
+		_, err := tx.Exec(`
+			INSERT INTO push_settings (user_id, quiet_start, quiet_end, timezone)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id) DO NOTHING`,
+			userID, push.QuietStart, push.QuietEnd, push.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore notification settings: %w", err)
+		}
+	}
+
+	for _, alert := range archive.Alerts {
+		result, err := tx.Exec(`
+			INSERT INTO alerts (user_id, name, pattern, is_regex, batch_minutes)
+			SELECT $1, $2, $3, $4, $5
+			WHERE NOT EXISTS (
+				SELECT 1 FROM alerts WHERE user_id = $1 AND pattern = $3 AND is_regex = $4
+			)`,
+			userID, alert.Name, alert.Pattern, alert.IsRegex, alert.BatchMinutes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore alert %s: %w", alert.Name, err)
+		}
+		if created, _ := result.RowsAffected(); created > 0 {
+			report.AlertsCreated++
+		}
+	}
+
+	folderNames := make([]string, 0, len(archive.Folders)+len(archive.Feeds))
+	for _, folder := range archive.Folders {
+		folderNames = append(folderNames, folder.Name)
+	}
+	for _, feed := range archive.Feeds {
+		folderNames = append(folderNames, feed.Folder)
+	}
+
+	for _, name := range folderNames {
+		if name == "" {
+			continue
+		}
+		result, err := tx.Exec(
+			"INSERT INTO folders (name, user_id) VALUES ($1, $2) ON CONFLICT (user_id, name) DO NOTHING",
+			name, userID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore folder %s: %w", name, err)
+		}
+		if created, _ := result.RowsAffected(); created > 0 {
+			report.FoldersCreated++
+		}
+	}
+
+	folderIDs, err := queryNameIDs(tx, "SELECT name, id FROM folders WHERE user_id = $1", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load folders: %w", err)
+	}
+	feedIDs, err := queryNameIDs(tx, "SELECT url, id FROM feeds WHERE user_id = $1", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feeds: %w", err)
+	}
+
+	for _, feed := range archive.Feeds {
+		folderID := folderIDs[feed.Folder]
+
+		if feedID, ok := feedIDs[feed.URL]; ok {
+			result, err := tx.Exec(`
+				UPDATE feeds SET
+				folder_id = COALESCE(folder_id, NULLIF($1, 0)),
+				site_url = CASE WHEN site_url = '' THEN $2 ELSE site_url END,
+				retention_days = CASE WHEN retention_days = 0 THEN $3 ELSE retention_days END,
+				retention_max_items = CASE WHEN retention_max_items = 0 THEN $4 ELSE retention_max_items END
+				WHERE id = $5 AND (
+					(folder_id IS NULL AND $1 <> 0) OR
+					(site_url = '' AND $2 <> '') OR
+					(retention_days = 0 AND $3 <> 0) OR
+					(retention_max_items = 0 AND $4 <> 0)
+				)`,
+				folderID, feed.SiteURL, feed.Retention.MaxAgeDays, feed.Retention.MaxItems, feedID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to restore feed %s: %w", feed.URL, err)
+			}
+			if updated, _ := result.RowsAffected(); updated > 0 {
+				report.FeedsUpdated++
+			}
+			continue
+		}
+
+		name := feed.Name
+		if name == "" {
+			name = feed.URL
+		}
+
+		var feedID int
+		err := tx.QueryRow(`
+			INSERT INTO feeds (name, url, folder_id, user_id, site_url, retention_days, retention_max_items)
+			VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7)
+			RETURNING id`,
+			name, feed.URL, folderID, userID, feed.SiteURL, feed.Retention.MaxAgeDays, feed.Retention.MaxItems,
+		).Scan(&feedID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore feed %s: %w", feed.URL, err)
+		}
+		feedIDs[feed.URL] = feedID
+		report.FeedsCreated++
+	}
+
+	if push != nil {
+		for _, feedURL := range push.FeedURLs {
+			feedID, ok := feedIDs[feedURL]
+			if !ok {
+				continue
+			}
+			_, err := tx.Exec(
+				"INSERT INTO push_feeds (user_id, feed_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+				userID, feedID,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to restore notification feed %s: %w", feedURL, err)
+			}
+		}
+	}
+
+	for _, item := range archive.Items {
+		feedID, ok := feedIDs[item.FeedURL]
+		if !ok {
+			continue
+		}
+
+		title := item.Title
+		if title == "" {
+			title = item.Link
+		}
+
+		// The conditional DO UPDATE returns no row when nothing changes;
+		// xmax = 0 distinguishes a fresh insert from an update.
+		var inserted bool
+		err := tx.QueryRow(`
+			INSERT INTO feed_items (title, description, link, feed_id, published_at, is_new, is_read, is_starred)
+			VALUES ($1, $2, $3, $4, $5, FALSE, $6, $7)
+			ON CONFLICT (link, feed_id) DO UPDATE SET
+			is_read = feed_items.is_read OR EXCLUDED.is_read,
+			is_starred = feed_items.is_starred OR EXCLUDED.is_starred
+			WHERE (EXCLUDED.is_read AND NOT feed_items.is_read)
+				OR (EXCLUDED.is_starred AND NOT feed_items.is_starred)
+			RETURNING (xmax = 0)`,
+			title, item.Description, item.Link, feedID, item.PublishedAt, item.IsRead, item.IsStarred,
+		).Scan(&inserted)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore item %s: %w", item.Link, err)
+		}
+
+		if inserted {
+			report.ItemsCreated++
+		} else {
+			report.ItemsUpdated++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit restore: %w", err)
+	}
+
+	return report, nil
+}
+
+func queryNameIDs(tx *sql.Tx, query string, userID int) (map[string]int, error) {
+	rows, err := tx.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int)
+	for rows.Next() {
+		var (
+			name string
+			id   int
+		)
+		if err := rows.Scan(&name, &id); err != nil {
+			return nil, err
+		}
+		ids[name] = id
+	}
+	return ids, rows.Err()
+}
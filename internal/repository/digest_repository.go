@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type DigestRepository interface {
+	GetByUserID(userID int) (*domain.DigestSettings, error)
+	GetEnabled() ([]domain.DigestSettings, error)
+	Upsert(settings *domain.DigestSettings) error
+	Unsubscribe(token string) error
+	GetUnsentItems(userID int, since time.Time, limit int) ([]domain.FeedItem, error)
+	MarkSent(userID int, itemIDs []int, sentAt time.Time) error
+}
+
+type digestRepository struct {
+	db *sql.DB
+}
+
+func NewDigestRepository(db *sql.DB) DigestRepository {
+	return &digestRepository{db: db}
+}
+
+const digestColumns = `user_id, frequency, send_hour, weekday, timezone, unsubscribe_token, enabled_at, last_sent_at`
+
+func scanDigestSettings(row rowScanner, settings *domain.DigestSettings) error {
+	var lastSentAt sql.NullTime
+
+	err := row.Scan(&settings.UserID, &settings.Frequency, &settings.SendHour, &settings.Weekday,
+		&settings.Timezone, &settings.UnsubscribeToken, &settings.EnabledAt, &lastSentAt)
+	if err != nil {
+		return err
+	}
+
+	if lastSentAt.Valid {
+		settings.LastSentAt = &lastSentAt.Time
+	}
+	return nil
+}
+
+func (r *digestRepository) GetByUserID(userID int) (*domain.DigestSettings, error) {
+	settings := &domain.DigestSettings{}
+
+	err := scanDigestSettings(r.db.QueryRow(
+		"SELECT "+digestColumns+" FROM digest_subscriptions WHERE user_id = $1",
+		userID,
+	), settings)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrDigestNotFound
+		}
+		return nil, fmt.Errorf("failed to get digest settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+func (r *digestRepository) GetEnabled() ([]domain.DigestSettings, error) {
+	rows, err := r.db.Query(
+		"SELECT " + digestColumns + " FROM digest_subscriptions WHERE frequency <> 'off' ORDER BY user_id",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digest subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []domain.DigestSettings
+	for rows.Next() {
+		var settings domain.DigestSettings
+		if err := scanDigestSettings(rows, &settings); err != nil {
+			return nil, fmt.Errorf("failed to scan digest subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, settings)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating digest subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// Upsert saves the schedule. An existing unsubscribe token is kept so links
+// in digests already sent keep working, and enabled_at only moves when a
+// digest that was off is switched on.
+func (r *digestRepository) Upsert(settings *domain.DigestSettings) error {
+	_, err := r.db.Exec(`
+		INSERT INTO digest_subscriptions (user_id, frequency, send_hour, weekday, timezone, unsubscribe_token, enabled_at)
+		VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id) DO UPDATE SET
+		enabled_at = CASE
+			WHEN digest_subscriptions.frequency = 'off' AND EXCLUDED.frequency <> 'off' THEN CURRENT_TIMESTAMP
+			ELSE digest_subscriptions.enabled_at
+		END,
+		frequency = EXCLUDED.frequency,
+		send_hour = EXCLUDED.send_hour,
+		weekday = EXCLUDED.weekday,
+		timezone = EXCLUDED.timezone,
+		updated_at = CURRENT_TIMESTAMP`,
+		settings.UserID, settings.Frequency, settings.SendHour, int(settings.Weekday),
+		settings.Timezone, settings.UnsubscribeToken)
+
+	if err != nil {
+		return fmt.Errorf("failed to save digest settings: %w", err)
+	}
+
+	return nil
+}
+
+func (r *digestRepository) Unsubscribe(token string) error {
+	result, err := r.db.Exec(`
+		UPDATE digest_subscriptions SET frequency = 'off', updated_at = CURRENT_TIMESTAMP
+		WHERE unsubscribe_token = $1`, token)
+	if err != nil {
+		return fmt.Errorf("failed to unsubscribe from digest: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrDigestNotFound
+	}
+
+	return nil
+}
+
+// GetUnsentItems returns unread items added since the given time that have
+// not appeared in an earlier digest, newest first.
+func (r *digestRepository) GetUnsentItems(userID int, since time.Time, limit int) ([]domain.FeedItem, error) {
+	rows, err := r.db.Query(`
+		SELECT `+itemColumns+`
+		FROM feed_items i
+		JOIN feeds f ON i.feed_id = f.id
+		WHERE f.user_id = $1
+		AND NOT i.is_read
+		AND i.created_at >= $2
+		AND NOT EXISTS (
+			SELECT 1 FROM digest_sent_items s
+			WHERE s.user_id = f.user_id AND s.item_id = i.id
+		)
+		ORDER BY i.published_at DESC, i.id DESC
+		LIMIT $3`, userID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digest items: %w", err)
+	}
+
+	return scanFeedItems(rows)
+}
+
+// MarkSent records the items included in a digest and the send time.
+func (r *digestRepository) MarkSent(userID int, itemIDs []int, sentAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin digest update: %w", err)
+	}
+	defer tx.Rollback()
+
+	if len(itemIDs) > 0 {
+		_, err = tx.Exec(`
+			INSERT INTO digest_sent_items (user_id, item_id, sent_at)
+			SELECT $1, unnest($2::int[]), $3
+			ON CONFLICT DO NOTHING`,
+			userID, pq.Array(itemIDs), sentAt)
+		if err != nil {
+			return fmt.Errorf("failed to record digest items: %w", err)
+		}
+	}
+
+	_, err = tx.Exec("UPDATE digest_subscriptions SET last_sent_at = $1 WHERE user_id = $2", sentAt, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update digest send time: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit digest update: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+)
+
+type FeedIconRepository interface {
+	GetByFeedID(feedID int) (*domain.FeedIcon, error)
+	GetAllByUserID(userID int) ([]domain.FeedIcon, error)
+	Upsert(icon *domain.FeedIcon) error
+}
+
+type feedIconRepository struct {
+	db *sql.DB
+}
+
+func NewFeedIconRepository(db *sql.DB) FeedIconRepository {
+	return &feedIconRepository{db: db}
+}
+
+func (r *feedIconRepository) GetByFeedID(feedID int) (*domain.FeedIcon, error) {
+	icon := &domain.FeedIcon{}
+
+	err := r.db.QueryRow(
+		"SELECT feed_id, mime_type, COALESCE(data, ''::bytea), fetched_at FROM feed_icons WHERE feed_id = $1",
+		feedID,
+	).Scan(&icon.FeedID, &icon.MimeType, &icon.Data, &icon.FetchedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrFeedIconNotFound
+		}
+		return nil, fmt.Errorf("failed to get feed icon: %w", err)
+	}
+
+	return icon, nil
+}
+
+func (r *feedIconRepository) GetAllByUserID(userID int) ([]domain.FeedIcon, error) {
+	rows, err := r.db.Query(`
+		SELECT i.feed_id, i.mime_type, i.data, i.fetched_at
+		FROM feed_icons i
+		JOIN feeds f ON f.id = i.feed_id
+		WHERE f.user_id = $1 AND i.data IS NOT NULL AND length(i.data) > 0
+		ORDER BY i.feed_id`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed icons: %w", err)
+	}
+	defer rows.Close()
+
+	var icons []domain.FeedIcon
+	for rows.Next() {
+		var icon domain.FeedIcon
+		if err := rows.Scan(&icon.FeedID, &icon.MimeType, &icon.Data, &icon.FetchedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan feed icon: %w", err)
+		}
+		icons = append(icons, icon)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feed icons: %w", err)
+	}
+
+	return icons, nil
+}
+
+func (r *feedIconRepository) Upsert(icon *domain.FeedIcon) error {
+	_, err := r.db.Exec(`
+		INSERT INTO feed_icons (feed_id, mime_type, data, fetched_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (feed_id) DO UPDATE SET
+		mime_type = EXCLUDED.mime_type,
+		data = EXCLUDED.data,
+		fetched_at = EXCLUDED.fetched_at`,
+		icon.FeedID, icon.MimeType, icon.Data)
+
+	if err != nil {
+		return fmt.Errorf("failed to save feed icon: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+)
+
+type FeedImportRepository interface {
+	Apply(userID int, decisions []domain.FeedImportDecision) (*domain.FeedImportReport, error)
+}
+
+type feedImportRepository struct {
+	db *sql.DB
+}
+
+func NewFeedImportRepository(db *sql.DB) FeedImportRepository {
+	return &feedImportRepository{db: db}
+}
+
+// Apply carries out every decision in a single transaction, so an import
+// either lands completely or not at all. The preview may be stale by now, so
+// an add or overwrite that would give the user two feeds with the same URL
+// fails the import with ErrFeedAlreadyExists.
+func (r *feedImportRepository) Apply(userID int, decisions []domain.FeedImportDecision) (*domain.FeedImportReport, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin import: %w", err)
+	}
+	defer tx.Rollback()
+
+	folderIDs := make(map[string]int)
+	folderID := func(name string) (int, error) {
+		if name == "" {
+			return 0, nil
+		}
+		if id, ok := folderIDs[name]; ok {
+			return id, nil
+		}
+
+		var id int
+		err := tx.QueryRow(`
+			INSERT INTO folders (name, user_id) VALUES ($1, $2)
+			ON CONFLICT (user_id, name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id`,
+			name, userID,
+		).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get or create folder: %w", err)
+		}
+
+		folderIDs[name] = id
+		return id, nil
+	}
+
+	report := &domain.FeedImportReport{}
+	for _, decision := range decisions {
+		if decision.Action == domain.FeedImportActionSkip {
+			report.Skipped++
+			continue
+		}
+
+		entry := decision.Entry
+		entryFolderID, err := folderID(entry.Folder)
+		if err != nil {
+			return nil, err
+		}
+
+		switch decision.Action {
+		case domain.FeedImportActionAdd:
+			result, err := tx.Exec(`
+				INSERT INTO feeds (name, url, folder_id, user_id, site_url)
+				SELECT $1, $2, NULLIF($3, 0), $4, $5
+				WHERE NOT EXISTS (SELECT 1 FROM feeds WHERE user_id = $4 AND url = $2)`,
+				entry.Name, entry.URL, entryFolderID, userID, entry.SiteURL,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to import feed %s: %w", entry.URL, err)
+			}
+
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get rows affected: %w", err)
+			}
+			if rowsAffected == 0 {
+				return nil, fmt.Errorf("%w: %s", domain.ErrFeedAlreadyExists, entry.URL)
+			}
+			report.Added++
+		case domain.FeedImportActionRename:
+			result, err := tx.Exec(
+				"UPDATE feeds SET name = $1 WHERE id = $2 AND user_id = $3",
+				entry.Name, decision.ExistingFeedID, userID,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to rename feed %d: %w", decision.ExistingFeedID, err)
+			}
+
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get rows affected: %w", err)
+			}
+			if rowsAffected == 0 {
+				return nil, domain.ErrFeedNotFound
+			}
+			report.Renamed++
+		case domain.FeedImportActionOverwrite:
+			var exists bool
+			err := tx.QueryRow(
+				"SELECT EXISTS (SELECT 1 FROM feeds WHERE user_id = $1 AND url = $2 AND id != $3)",
+				userID, entry.URL, decision.ExistingFeedID,
+			).Scan(&exists)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check feed %s: %w", entry.URL, err)
+			}
+			if exists {
+				return nil, fmt.Errorf("%w: %s", domain.ErrFeedAlreadyExists, entry.URL)
+			}
+
+			result, err := tx.Exec(`
+				UPDATE feeds SET name = $1, url = $2, folder_id = NULLIF($3, 0),
+				site_url = CASE WHEN $4 = '' THEN site_url ELSE $4 END
+				WHERE id = $5 AND user_id = $6`,
+				entry.Name, entry.URL, entryFolderID, entry.SiteURL, decision.ExistingFeedID, userID,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to overwrite feed %d: %w", decision.ExistingFeedID, err)
+			}
+
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get rows affected: %w", err)
+			}
+			if rowsAffected == 0 {
+				return nil, domain.ErrFeedNotFound
+			}
+			report.Overwritten++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	return report, nil
+}
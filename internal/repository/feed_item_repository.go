@@ -6,14 +6,26 @@ import (
 	"rss-reader/internal/domain"
 	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type FeedItemRepository interface {
-	Create(item *domain.FeedItem) error
-	GetByUserIDPaginated(userID int, daysOffset int) ([]domain.FeedItem, error)
-	HasMoreItems(userID int, daysOffset int) (bool, error)
+	Create(item *domain.FeedItem) (bool, error)
+	GetPageByUserID(userID int, filter domain.ItemFilter, cursor *domain.ItemCursor, limit int) ([]domain.FeedItem, error)
+	HasMoreItems(userID int, filter domain.ItemFilter, cursor *domain.ItemCursor) (bool, error)
+	GetByIDs(userID int, ids []int) ([]domain.FeedItem, error)
+	GetSinceID(userID, sinceID, limit int) ([]domain.FeedItem, error)
+	GetBeforeID(userID, maxID, limit int) ([]domain.FeedItem, error)
+	GetIDsByStatus(userID int, status string) ([]int, error)
+	CountByUserID(userID int) (int, error)
+	GetUnreadCounts(userID int) ([]domain.UnreadCount, error)
+	SetRead(itemID, userID int, read bool) error
+	SetStarred(itemID, userID int, starred bool) error
+	MarkReadBefore(userID, feedID, folderID int, before time.Time) (int64, error)
 	MarkAllAsOld(userID int) error
-	DeleteOlderThan(days int) (int64, error)
+	DeleteExpired(defaults domain.RetentionPolicy) (int64, error)
+	DeleteOverLimit(defaults domain.RetentionPolicy) (int64, error)
 }
 
 type feedItemRepository struct {
@@ -24,10 +36,21 @@ func NewFeedItemRepository(db *sql.DB) FeedItemRepository {
 	return &feedItemRepository{db: db}
 }
 
-func (r *feedItemRepository) Create(item *domain.FeedItem) error {
-	_, err := r.db.Exec(`
+// Create inserts the item, or updates it when the feed already has an item
+// with the same link. It sets item.ID and reports whether a new row was
+// inserted. Items published no later than the feed's trimmed_before are
+// skipped, so that items removed by retention are not added again while
+// they are still in the feed.
+func (r *feedItemRepository) Create(item *domain.FeedItem) (bool, error) {
+	var inserted bool
+	err := r.db.QueryRow(`
 		INSERT INTO feed_items (title, description, link, feed_id, published_at)
-		VALUES ($1, $2, $3, $4, $5)
+		SELECT $1, $2, $3, f.id, $5
+		FROM feeds f
+		WHERE f.id = $4
+		AND (f.trimmed_before IS NULL OR $5 > f.trimmed_before OR EXISTS (
+			SELECT 1 FROM feed_items WHERE link = $3 AND feed_id = $4
+		))
 		ON CONFLICT (link, feed_id) DO UPDATE SET
 		title = EXCLUDED.title,
 		description = EXCLUDED.description,
@@ -36,82 +59,348 @@ func (r *feedItemRepository) Create(item *domain.FeedItem) error {
 			WHEN feed_items.title != EXCLUDED.title OR
 				 feed_items.description != EXCLUDED.description THEN TRUE
 			ELSE feed_items.is_new
-		END`,
-		item.Title, item.Description, item.Link, item.FeedID, item.PublishedAt)
+		END
+		RETURNING id, (xmax = 0)`,
+		item.Title, item.Description, item.Link, item.FeedID, item.PublishedAt,
+	).Scan(&item.ID, &inserted)
 
 	if err != nil {
-		if isDuplicateError(err) {
-			return nil
+		if err == sql.ErrNoRows || isDuplicateError(err) {
+			return false, nil
 		}
-		return fmt.Errorf("failed to create feed item: %w", err)
+		return false, fmt.Errorf("failed to create feed item: %w", err)
 	}
 
-	return nil
+	return inserted, nil
 }
 
-func (r *feedItemRepository) GetByUserIDPaginated(userID int, daysOffset int) ([]domain.FeedItem, error) {
-	endDate := time.Now().AddDate(0, 0, -daysOffset)
-	startDate := endDate.AddDate(0, 0, -60)
+// GetPageByUserID returns up to limit items matching filter that sort
+// strictly after the cursor. A nil cursor starts from the beginning.
+func (r *feedItemRepository) GetPageByUserID(userID int, filter domain.ItemFilter, cursor *domain.ItemCursor, limit int) ([]domain.FeedItem, error) {
+	conditions, args := buildItemConditions(userID, filter, cursor)
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT `+itemColumns+`
+		FROM feed_items i
+		JOIN feeds f ON i.feed_id = f.id
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), itemOrderBy(filter.SortOrder()), len(args))
 
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed items: %w", err)
+	}
+
+	return scanFeedItems(rows)
+}
+
+func (r *feedItemRepository) HasMoreItems(userID int, filter domain.ItemFilter, cursor *domain.ItemCursor) (bool, error) {
+	conditions, args := buildItemConditions(userID, filter, cursor)
+
+	var exists bool
+	err := r.db.QueryRow(fmt.Sprintf(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM feed_items i
+			JOIN feeds f ON i.feed_id = f.id
+			WHERE %s
+		)
+	`, strings.Join(conditions, " AND ")), args...).Scan(&exists)
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check for more items: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (r *feedItemRepository) GetByIDs(userID int, ids []int) ([]domain.FeedItem, error) {
+	rows, err := r.db.Query(`
+		SELECT `+itemColumns+`
+		FROM feed_items i
+		JOIN feeds f ON i.feed_id = f.id
+		WHERE f.user_id = $1 AND i.id = ANY($2)
+		ORDER BY i.id`, userID, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed items: %w", err)
+	}
+
+	return scanFeedItems(rows)
+}
+
+// GetSinceID returns the oldest items with an ID greater than sinceID, in
+// ascending ID order, for clients syncing forwards.
+func (r *feedItemRepository) GetSinceID(userID, sinceID, limit int) ([]domain.FeedItem, error) {
 	rows, err := r.db.Query(`
-		SELECT i.id, i.title, i.description, i.link, f.name,
-			   i.published_at AT TIME ZONE 'UTC' as published_at, i.is_new
+		SELECT `+itemColumns+`
 		FROM feed_items i
 		JOIN feeds f ON i.feed_id = f.id
-		WHERE f.user_id = $1
-		AND i.published_at <= $2
-		AND i.published_at >= $3
-		ORDER BY i.published_at DESC
-	`, userID, endDate, startDate)
+		WHERE f.user_id = $1 AND i.id > $2
+		ORDER BY i.id ASC
+		LIMIT $3`, userID, sinceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed items: %w", err)
+	}
+
+	return scanFeedItems(rows)
+}
 
+// GetBeforeID returns the newest items with an ID lower than maxID, in
+// descending ID order, for clients paging backwards. A maxID of 0 starts
+// from the newest item.
+func (r *feedItemRepository) GetBeforeID(userID, maxID, limit int) ([]domain.FeedItem, error) {
+	rows, err := r.db.Query(`
+		SELECT `+itemColumns+`
+		FROM feed_items i
+		JOIN feeds f ON i.feed_id = f.id
+		WHERE f.user_id = $1 AND ($2 = 0 OR i.id < $2)
+		ORDER BY i.id DESC
+		LIMIT $3`, userID, maxID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get feed items: %w", err)
 	}
+
+	return scanFeedItems(rows)
+}
+
+func (r *feedItemRepository) GetIDsByStatus(userID int, status string) ([]int, error) {
+	condition := "TRUE"
+	switch status {
+	case domain.ItemStatusUnread:
+		condition = "NOT i.is_read"
+	case domain.ItemStatusStarred:
+		condition = "i.is_starred"
+	case domain.ItemStatusRead:
+		condition = "i.is_read"
+	}
+
+	rows, err := r.db.Query(fmt.Sprintf(`
+		SELECT i.id
+		FROM feed_items i
+		JOIN feeds f ON i.feed_id = f.id
+		WHERE f.user_id = $1 AND %s
+		ORDER BY i.id`, condition), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed item IDs: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan feed item ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feed item IDs: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (r *feedItemRepository) CountByUserID(userID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM feed_items i
+		JOIN feeds f ON i.feed_id = f.id
+		WHERE f.user_id = $1`, userID).Scan(&count)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to count feed items: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *feedItemRepository) GetUnreadCounts(userID int) ([]domain.UnreadCount, error) {
+	rows, err := r.db.Query(`
+		SELECT i.feed_id, COUNT(*), MAX(i.published_at)
+		FROM feed_items i
+		JOIN feeds f ON i.feed_id = f.id
+		WHERE f.user_id = $1 AND NOT i.is_read
+		GROUP BY i.feed_id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unread counts: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []domain.UnreadCount
+	for rows.Next() {
+		var count domain.UnreadCount
+		if err := rows.Scan(&count.FeedID, &count.Count, &count.NewestAt); err != nil {
+			return nil, fmt.Errorf("failed to scan unread count: %w", err)
+		}
+		counts = append(counts, count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating unread counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+func (r *feedItemRepository) SetRead(itemID, userID int, read bool) error {
+	return r.setFlag("is_read", itemID, userID, read)
+}
+
+func (r *feedItemRepository) SetStarred(itemID, userID int, starred bool) error {
+	return r.setFlag("is_starred", itemID, userID, starred)
+}
+
+func (r *feedItemRepository) setFlag(column string, itemID, userID int, value bool) error {
+	result, err := r.db.Exec(fmt.Sprintf(`
+		UPDATE feed_items
+		SET %s = $1
+		WHERE id = $2 AND feed_id IN (SELECT id FROM feeds WHERE user_id = $3)
+	`, column), value, itemID, userID)
+
+	if err != nil {
+		return fmt.Errorf("failed to update feed item: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrFeedItemNotFound
+	}
+
+	return nil
+}
+
+// MarkReadBefore marks every item first seen before the given time as read,
+// optionally limited to one feed or one folder (0 means all).
+func (r *feedItemRepository) MarkReadBefore(userID, feedID, folderID int, before time.Time) (int64, error) {
+	result, err := r.db.Exec(`
+		UPDATE feed_items
+		SET is_read = TRUE
+		WHERE NOT is_read
+		AND created_at < $4
+		AND feed_id IN (
+			SELECT id FROM feeds
+			WHERE user_id = $1
+			AND ($2 = 0 OR id = $2)
+			AND ($3 = 0 OR folder_id = $3)
+		)`, userID, feedID, folderID, before)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark items as read: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected, nil
+}
+
+const itemColumns = `i.id, i.title, i.description, i.link, i.feed_id, f.name,
+	i.published_at AT TIME ZONE 'UTC' as published_at, i.is_new, i.is_read, i.is_starred`
+
+func scanFeedItems(rows *sql.Rows) ([]domain.FeedItem, error) {
 	defer rows.Close()
 
 	var items []domain.FeedItem
 	for rows.Next() {
 		var item domain.FeedItem
-		var feedName string
 		err := rows.Scan(
 			&item.ID,
 			&item.Title,
 			&item.Description,
 			&item.Link,
-			&feedName,
+			&item.FeedID,
+			&item.FeedName,
 			&item.PublishedAt,
 			&item.IsNew,
+			&item.IsRead,
+			&item.IsStarred,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan feed item: %w", err)
 		}
-		item.FeedName = feedName
 		items = append(items, item)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating feed items: %w", err)
 	}
 
 	return items, nil
 }
 
-func (r *feedItemRepository) HasMoreItems(userID int, daysOffset int) (bool, error) {
-	checkDate := time.Now().AddDate(0, 0, -(daysOffset + 60))
+// buildItemConditions translates a filter and cursor into WHERE clauses over
+// feed_items i JOIN feeds f, with positional arguments starting at $1.
+func buildItemConditions(userID int, filter domain.ItemFilter, cursor *domain.ItemCursor) ([]string, []interface{}) {
+	args := []interface{}{userID}
+	conditions := []string{"f.user_id = $1"}
 
-	var count int
-	err := r.db.QueryRow(`
-		SELECT COUNT(*)
-		FROM feed_items i
-		JOIN feeds f ON i.feed_id = f.id
-		WHERE f.user_id = $1 AND i.published_at < $2
-	`, userID, checkDate).Scan(&count)
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
 
-	if err != nil {
-		return false, fmt.Errorf("failed to check for more items: %w", err)
+	if filter.FeedID > 0 {
+		conditions = append(conditions, "i.feed_id = "+arg(filter.FeedID))
+	}
+	if filter.FolderID > 0 {
+		conditions = append(conditions, "f.folder_id = "+arg(filter.FolderID))
 	}
 
-	return count > 0, nil
+	switch filter.Status {
+	case domain.ItemStatusUnread:
+		conditions = append(conditions, "NOT i.is_read")
+	case domain.ItemStatusStarred:
+		conditions = append(conditions, "i.is_starred")
+	case domain.ItemStatusRead:
+		conditions = append(conditions, "i.is_read")
+	}
+	if filter.Unread {
+		conditions = append(conditions, "NOT i.is_read")
+	}
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "i.published_at >= "+arg(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "i.published_at < "+arg(filter.To))
+	}
+
+	if cursor != nil {
+		switch filter.SortOrder() {
+		case domain.ItemSortOldest:
+			conditions = append(conditions, fmt.Sprintf("(i.published_at, i.id) > (%s, %s)",
+				arg(cursor.PublishedAt), arg(cursor.ID)))
+		case domain.ItemSortFeed:
+			name, feedID := arg(cursor.FeedName), arg(cursor.FeedID)
+			conditions = append(conditions, fmt.Sprintf(
+				"(f.name > %[1]s OR (f.name = %[1]s AND i.feed_id > %[2]s) OR "+
+					"(f.name = %[1]s AND i.feed_id = %[2]s AND (i.published_at, i.id) < (%[3]s, %[4]s)))",
+				name, feedID, arg(cursor.PublishedAt), arg(cursor.ID)))
+		default:
+			conditions = append(conditions, fmt.Sprintf("(i.published_at, i.id) < (%s, %s)",
+				arg(cursor.PublishedAt), arg(cursor.ID)))
+		}
+	}
+
+	return conditions, args
+}
+
+func itemOrderBy(sort string) string {
+	switch sort {
+	case domain.ItemSortOldest:
+		return "i.published_at ASC, i.id ASC"
+	case domain.ItemSortFeed:
+		return "f.name ASC, i.feed_id ASC, i.published_at DESC, i.id DESC"
+	default:
+		return "i.published_at DESC, i.id DESC"
+	}
 }
 
 func (r *feedItemRepository) MarkAllAsOld(userID int) error {
@@ -128,24 +417,87 @@ func (r *feedItemRepository) MarkAllAsOld(userID int) error {
 	return nil
 }
 
-func (r *feedItemRepository) DeleteOlderThan(days int) (int64, error) {
-	cutoffDate := time.Now().AddDate(0, 0, -days)
+// DeleteExpired removes items older than the effective max age of their feed,
+// resolved as feed override, then user setting, then the instance default.
+// Starred items are never removed.
+func (r *feedItemRepository) DeleteExpired(defaults domain.RetentionPolicy) (int64, error) {
+	var rowsDeleted int64
+	err := r.db.QueryRow(`
+		WITH policy AS (
+			SELECT f.id AS feed_id,
+				   COALESCE(NULLIF(f.retention_days, 0), NULLIF(s.retention_days, 0), $1) AS max_age_days
+			FROM feeds f
+			LEFT JOIN user_settings s ON s.user_id = f.user_id
+		),
+		deleted AS (
+			DELETE FROM feed_items i
+			USING policy p
+			WHERE i.feed_id = p.feed_id
+			AND NOT i.is_starred
+			AND p.max_age_days > 0
+			AND i.published_at < NOW() - make_interval(days => p.max_age_days)
+			RETURNING i.feed_id, i.published_at
+		)
+		`+markTrimmed, defaults.MaxAgeDays).Scan(&rowsDeleted)
 
-	result, err := r.db.Exec(`
-		DELETE FROM feed_items 
-		WHERE published_at < $1
-	`, cutoffDate)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired feed items: %w", err)
+	}
+
+	return rowsDeleted, nil
+}
+
+// DeleteOverLimit trims each feed down to its effective max item count,
+// keeping the most recently published items. Starred items neither count
+// towards the limit nor get removed.
+func (r *feedItemRepository) DeleteOverLimit(defaults domain.RetentionPolicy) (int64, error) {
+	var rowsDeleted int64
+	err := r.db.QueryRow(`
+		WITH ranked AS (
+			SELECT i.id,
+				   ROW_NUMBER() OVER (PARTITION BY i.feed_id ORDER BY i.published_at DESC, i.id DESC) AS position,
+				   COALESCE(NULLIF(f.retention_max_items, 0), NULLIF(s.retention_max_items, 0), $1) AS max_items
+			FROM feed_items i
+			JOIN feeds f ON i.feed_id = f.id
+			LEFT JOIN user_settings s ON s.user_id = f.user_id
+			WHERE NOT i.is_starred
+		),
+		deleted AS (
+			DELETE FROM feed_items
+			WHERE id IN (
+				SELECT id FROM ranked WHERE max_items > 0 AND position > max_items
+			)
+			RETURNING feed_id, published_at
+		)
+		`+markTrimmed, defaults.MaxItems).Scan(&rowsDeleted)
 
 	if err != nil {
-		return 0, fmt.Errorf("failed to delete old feed items: %w", err)
+		return 0, fmt.Errorf("failed to delete feed items over limit: %w", err)
 	}
 
-	rowsDeleted, _ := result.RowsAffected()
 	return rowsDeleted, nil
 }
 
+// markTrimmed ends a statement whose "deleted" CTE returns the feed_id and
+// published_at of removed items. It moves each feed's trimmed_before up to
+// the newest item removed, for Create to skip them, and returns the count of
+// removed items to QueryRow rather than RowsAffected.
+const markTrimmed = `,
+		trimmed AS (
+			UPDATE feeds f SET trimmed_before = GREATEST(f.trimmed_before, d.newest)
+			FROM (
+				SELECT feed_id, MAX(published_at) AS newest
+				FROM deleted
+				WHERE published_at IS NOT NULL
+				GROUP BY feed_id
+			) d
+			WHERE f.id = d.feed_id
+			RETURNING f.id
+		)
+		SELECT COUNT(*) FROM deleted`
+
 func isDuplicateError(err error) bool {
 	return err != nil && (strings.Contains(err.Error(), "duplicate key") ||
 		strings.Contains(err.Error(), "unique constraint") ||
 		strings.Contains(err.Error(), "UNIQUE"))
-}
\ No newline at end of file
+}
@@ -7,10 +7,14 @@ import (
 )
 
 type FeedRepository interface {
-	Create(name, url string, userID int) (*domain.Feed, error)
+	Create(name, url string, folderID, userID int) (*domain.Feed, error)
 	GetByID(feedID, userID int) (*domain.Feed, error)
 	GetAllByUserID(userID int) ([]domain.Feed, error)
-	Update(feedID int, name, url string, userID int) error
+	Update(feedID int, name, url string, folderID, userID int) error
+	UpdateRetention(feedID, userID int, policy domain.RetentionPolicy) error
+	GetByIDForAdmin(feedID int) (*domain.Feed, error)
+	MarkRefreshed(feedID int, siteURL string) error
+	MarkFailed(feedID int, message string) error
 	Delete(feedID, userID int) error
 	ExistsByURL(userID int, url string) (bool, error)
 }
@@ -23,16 +27,17 @@ func NewFeedRepository(db *sql.DB) FeedRepository {
 	return &feedRepository{db: db}
 }
 
-func (r *feedRepository) Create(name, url string, userID int) (*domain.Feed, error) {
+func (r *feedRepository) Create(name, url string, folderID, userID int) (*domain.Feed, error) {
 	feed := &domain.Feed{
-		Name:   name,
-		URL:    url,
-		UserID: userID,
+		Name:     name,
+		URL:      url,
+		UserID:   userID,
+		FolderID: folderID,
 	}
 
 	err := r.db.QueryRow(
-		"INSERT INTO feeds (name, url, user_id) VALUES ($1, $2, $3) RETURNING id, created_at",
-		name, url, userID,
+		"INSERT INTO feeds (name, url, folder_id, user_id) VALUES ($1, $2, NULLIF($3, 0), $4) RETURNING id, created_at",
+		name, url, folderID, userID,
 	).Scan(&feed.ID, &feed.CreatedAt)
 
 	if err != nil {
@@ -42,13 +47,65 @@ func (r *feedRepository) Create(name, url string, userID int) (*domain.Feed, err
 	return feed, nil
 }
 
+const feedColumns = `f.id, f.name, f.url, f.user_id, COALESCE(f.folder_id, 0), COALESCE(fo.name, ''),
+	f.site_url, f.last_refreshed_at, f.retention_days, f.retention_max_items, f.created_at,
+	f.last_error, f.failure_count, f.last_failed_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanFeed(row rowScanner, feed *domain.Feed) error {
+	var lastRefreshedAt, lastFailedAt sql.NullTime
+
+	err := row.Scan(&feed.ID, &feed.Name, &feed.URL, &feed.UserID, &feed.FolderID, &feed.Folder,
+		&feed.SiteURL, &lastRefreshedAt, &feed.Retention.MaxAgeDays, &feed.Retention.MaxItems, &feed.CreatedAt,
+		&feed.LastError, &feed.FailureCount, &lastFailedAt)
+	if err != nil {
+		return err
+	}
+
+	if lastRefreshedAt.Valid {
+		feed.LastRefreshedAt = &lastRefreshedAt.Time
+	}
+	if lastFailedAt.Valid {
+		feed.LastFailedAt = &lastFailedAt.Time
+	}
+	return nil
+}
+
 func (r *feedRepository) GetByID(feedID, userID int) (*domain.Feed, error) {
 	feed := &domain.Feed{}
 
-	err := r.db.QueryRow(
-		"SELECT id, name, url, user_id, created_at FROM feeds WHERE id = $1 AND user_id = $2",
+	err := scanFeed(r.db.QueryRow(
+		`SELECT `+feedColumns+`
+		FROM feeds f
+		LEFT JOIN folders fo ON fo.id = f.folder_id
+		WHERE f.id = $1 AND f.user_id = $2`,
 		feedID, userID,
-	).Scan(&feed.ID, &feed.Name, &feed.URL, &feed.UserID, &feed.CreatedAt)
+	), feed)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrFeedNotFound
+		}
+		return nil, fmt.Errorf("failed to get feed: %w", err)
+	}
+
+	return feed, nil
+}
+
+// GetByIDForAdmin gets a feed regardless of its owner.
+func (r *feedRepository) GetByIDForAdmin(feedID int) (*domain.Feed, error) {
+	feed := &domain.Feed{}
+
+	err := scanFeed(r.db.QueryRow(
+		`SELECT `+feedColumns+`
+		FROM feeds f
+		LEFT JOIN folders fo ON fo.id = f.folder_id
+		WHERE f.id = $1`,
+		feedID,
+	), feed)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -62,7 +119,10 @@ func (r *feedRepository) GetByID(feedID, userID int) (*domain.Feed, error) {
 
 func (r *feedRepository) GetAllByUserID(userID int) ([]domain.Feed, error) {
 	rows, err := r.db.Query(
-		"SELECT id, name, url, user_id, created_at FROM feeds WHERE user_id = $1 ORDER BY name",
+		`SELECT `+feedColumns+`
+		FROM feeds f
+		LEFT JOIN folders fo ON fo.id = f.folder_id
+		WHERE f.user_id = $1 ORDER BY f.name`,
 		userID,
 	)
 	if err != nil {
@@ -73,8 +133,7 @@ func (r *feedRepository) GetAllByUserID(userID int) ([]domain.Feed, error) {
 	var feeds []domain.Feed
 	for rows.Next() {
 		var feed domain.Feed
-		err := rows.Scan(&feed.ID, &feed.Name, &feed.URL, &feed.UserID, &feed.CreatedAt)
-		if err != nil {
+		if err := scanFeed(rows, &feed); err != nil {
 			return nil, fmt.Errorf("failed to scan feed: %w", err)
 		}
 		feeds = append(feeds, feed)
@@ -87,10 +146,35 @@ func (r *feedRepository) GetAllByUserID(userID int) ([]domain.Feed, error) {
 	return feeds, nil
 }
 
-func (r *feedRepository) Update(feedID int, name, url string, userID int) error {
+func (r *feedRepository) MarkRefreshed(feedID int, siteURL string) error {
+	_, err := r.db.Exec(
+		`UPDATE feeds SET site_url = $1, last_refreshed_at = CURRENT_TIMESTAMP,
+			last_error = '', failure_count = 0
+		WHERE id = $2`,
+		siteURL, feedID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark feed refreshed: %w", err)
+	}
+	return nil
+}
+
+func (r *feedRepository) MarkFailed(feedID int, message string) error {
+	_, err := r.db.Exec(
+		`UPDATE feeds SET last_error = $1, failure_count = failure_count + 1, last_failed_at = CURRENT_TIMESTAMP
+		WHERE id = $2`,
+		message, feedID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark feed failed: %w", err)
+	}
+	return nil
+}
+
+func (r *feedRepository) Update(feedID int, name, url string, folderID, userID int) error {
 	result, err := r.db.Exec(
-		"UPDATE feeds SET name = $1, url = $2 WHERE id = $3 AND user_id = $4",
-		name, url, feedID, userID,
+		"UPDATE feeds SET name = $1, url = $2, folder_id = NULLIF($3, 0) WHERE id = $4 AND user_id = $5",
+		name, url, folderID, feedID, userID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update feed: %w", err)
@@ -108,6 +192,27 @@ func (r *feedRepository) Update(feedID int, name, url string, userID int) error
 	return nil
 }
 
+func (r *feedRepository) UpdateRetention(feedID, userID int, policy domain.RetentionPolicy) error {
+	result, err := r.db.Exec(
+		"UPDATE feeds SET retention_days = $1, retention_max_items = $2 WHERE id = $3 AND user_id = $4",
+		policy.MaxAgeDays, policy.MaxItems, feedID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update feed retention: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrFeedNotFound
+	}
+
+	return nil
+}
+
 func (r *feedRepository) Delete(feedID, userID int) error {
 	result, err := r.db.Exec(
 		"DELETE FROM feeds WHERE id = $1 AND user_id = $2",
@@ -141,4 +246,4 @@ func (r *feedRepository) ExistsByURL(userID int, url string) (bool, error) {
 	}
 
 	return count > 0, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+)
+
+type FeverCredentialRepository interface {
+	Upsert(userID int, keyHash string) error
+	GetUserIDByKeyHash(keyHash string) (int, error)
+	ExistsForUser(userID int) (bool, error)
+	Delete(userID int) error
+}
+
+type feverCredentialRepository struct {
+	db *sql.DB
+}
+
+func NewFeverCredentialRepository(db *sql.DB) FeverCredentialRepository {
+	return &feverCredentialRepository{db: db}
+}
+
+func (r *feverCredentialRepository) Upsert(userID int, keyHash string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO fever_credentials (user_id, key_hash)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET
+		key_hash = EXCLUDED.key_hash,
+		created_at = CURRENT_TIMESTAMP`,
+		userID, keyHash)
+
+	if err != nil {
+		return fmt.Errorf("failed to save Fever credentials: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserIDByKeyHash only finds keys of users who are not disabled.
+func (r *feverCredentialRepository) GetUserIDByKeyHash(keyHash string) (int, error) {
+	var userID int
+	err := r.db.QueryRow(
+		`SELECT c.user_id FROM fever_credentials c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.key_hash = $1 AND u.disabled_at IS NULL`,
+		keyHash,
+	).Scan(&userID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, domain.ErrInvalidAPIToken
+		}
+		return 0, fmt.Errorf("failed to get Fever credentials: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (r *feverCredentialRepository) ExistsForUser(userID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM fever_credentials WHERE user_id = $1)", userID).Scan(&exists)
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check Fever credentials: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (r *feverCredentialRepository) Delete(userID int) error {
+	_, err := r.db.Exec("DELETE FROM fever_credentials WHERE user_id = $1", userID)
+
+	if err != nil {
+		return fmt.Errorf("failed to delete Fever credentials: %w", err)
+	}
+
+	return nil
+}
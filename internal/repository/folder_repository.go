@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+)
+
+type FolderRepository interface {
+	GetOrCreate(userID int, name string) (*domain.Folder, error)
+	GetByID(folderID, userID int) (*domain.Folder, error)
+	GetAllByUserID(userID int) ([]domain.Folder, error)
+}
+
+type folderRepository struct {
+	db *sql.DB
+}
+
+func NewFolderRepository(db *sql.DB) FolderRepository {
+	return &folderRepository{db: db}
+}
+
+func (r *folderRepository) GetOrCreate(userID int, name string) (*domain.Folder, error) {
+	folder := &domain.Folder{UserID: userID}
+
+	err := r.db.QueryRow(`
+		INSERT INTO folders (name, user_id) VALUES ($1, $2)
+		ON CONFLICT (user_id, name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING id, name, created_at`,
+		name, userID,
+	).Scan(&folder.ID, &folder.Name, &folder.CreatedAt)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get or create folder: %w", err)
+	}
+
+	return folder, nil
+}
+
+func (r *folderRepository) GetByID(folderID, userID int) (*domain.Folder, error) {
+	folder := &domain.Folder{}
+
+	err := r.db.QueryRow(
+		"SELECT id, name, user_id, created_at FROM folders WHERE id = $1 AND user_id = $2",
+		folderID, userID,
+	).Scan(&folder.ID, &folder.Name, &folder.UserID, &folder.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrFolderNotFound
+		}
+		return nil, fmt.Errorf("failed to get folder: %w", err)
+	}
+
+	return folder, nil
+}
+
+func (r *folderRepository) GetAllByUserID(userID int) ([]domain.Folder, error) {
+	rows, err := r.db.Query(
+		"SELECT id, name, user_id, created_at FROM folders WHERE user_id = $1 ORDER BY name",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get folders: %w", err)
+	}
+	defer rows.Close()
+
+	var folders []domain.Folder
+	for rows.Next() {
+		var folder domain.Folder
+		if err := rows.Scan(&folder.ID, &folder.Name, &folder.UserID, &folder.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan folder: %w", err)
+		}
+		folders = append(folders, folder)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating folders: %w", err)
+	}
+
+	return folders, nil
+}
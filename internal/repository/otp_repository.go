@@ -8,9 +8,11 @@ import (
 )
 
 type OTPRepository interface {
-	Store(email, otp string, expiresAt time.Time) error
+	Store(email, otpHash, linkHash string, expiresAt time.Time) error
 	GetLatestByEmail(email string) (*domain.OTP, error)
+	UseAttempt(id, maxAttempts int) (bool, error)
 	DeleteByEmail(email string) error
+	DeleteExpired(now time.Time) (int64, error)
 }
 
 type otpRepository struct {
@@ -21,10 +23,10 @@ func NewOTPRepository(db *sql.DB) OTPRepository {
 	return &otpRepository{db: db}
 }
 
-func (r *otpRepository) Store(email, otp string, expiresAt time.Time) error {
+func (r *otpRepository) Store(email, otpHash, linkHash string, expiresAt time.Time) error {
 	_, err := r.db.Exec(
-		"INSERT INTO otps (email, otp, expires_at) VALUES ($1, $2, $3)",
-		email, otp, expiresAt,
+		"INSERT INTO otps (email, otp_hash, link_hash, expires_at) VALUES ($1, $2, $3, $4)",
+		email, otpHash, linkHash, expiresAt,
 	)
 	
 	if err != nil {
@@ -38,9 +40,9 @@ func (r *otpRepository) GetLatestByEmail(email string) (*domain.OTP, error) {
 	otp := &domain.OTP{}
 	
 	err := r.db.QueryRow(
-		"SELECT id, email, otp, expires_at FROM otps WHERE email = $1 ORDER BY expires_at DESC LIMIT 1",
+		"SELECT id, email, otp_hash, link_hash, attempts, expires_at FROM otps WHERE email = $1 ORDER BY expires_at DESC LIMIT 1",
 		email,
-	).Scan(&otp.ID, &otp.Email, &otp.OTP, &otp.ExpiresAt)
+	).Scan(&otp.ID, &otp.Email, &otp.OTPHash, &otp.LinkHash, &otp.Attempts, &otp.ExpiresAt)
 	
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -52,6 +54,25 @@ func (r *otpRepository) GetLatestByEmail(email string) (*domain.OTP, error) {
 	return otp, nil
 }
 
+// UseAttempt counts an attempt against the OTP before its code is checked,
+// and reports false once maxAttempts have been used. Counting first keeps
+// concurrent guesses from going over the limit.
+func (r *otpRepository) UseAttempt(id, maxAttempts int) (bool, error) {
+	result, err := r.db.Exec(
+		"UPDATE otps SET attempts = attempts + 1 WHERE id = $1 AND attempts < $2",
+		id, maxAttempts,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to count OTP attempt: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to count OTP attempt: %w", err)
+	}
+	return rows > 0, nil
+}
+
 func (r *otpRepository) DeleteByEmail(email string) error {
 	_, err := r.db.Exec("DELETE FROM otps WHERE email = $1", email)
 	
@@ -60,4 +81,13 @@ func (r *otpRepository) DeleteByEmail(email string) error {
 	}
 	
 	return nil
+}
+
+func (r *otpRepository) DeleteExpired(now time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM otps WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired OTPs: %w", err)
+	}
+
+	return result.RowsAffected()
 }
\ No newline at end of file
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+	"strings"
+	"time"
+)
+
+type PasskeyRepository interface {
+	Create(passkey *domain.Passkey) error
+	GetAllByUserID(userID int) ([]domain.Passkey, error)
+	GetByCredentialID(credentialID []byte) (*domain.Passkey, error)
+	RecordUse(passkeyID int, signCount uint32, backupState bool, usedAt time.Time) error
+	Delete(passkeyID, userID int) error
+	GetOrCreateUserHandle(userID int, handle []byte) ([]byte, error)
+	GetUserIDByHandle(handle []byte) (int, error)
+}
+
+type passkeyRepository struct {
+	db *sql.DB
+}
+
+func NewPasskeyRepository(db *sql.DB) PasskeyRepository {
+	return &passkeyRepository{db: db}
+}
+
+const passkeyColumns = `id, user_id, name, credential_id, public_key, attestation_type, aaguid,
+	sign_count, transports, backup_eligible, backup_state, created_at, last_used_at`
+
+func scanPasskey(row rowScanner, passkey *domain.Passkey) error {
+	var signCount int64
+	var transports string
+	var lastUsedAt sql.NullTime
+
+	err := row.Scan(&passkey.ID, &passkey.UserID, &passkey.Name, &passkey.CredentialID, &passkey.PublicKey,
+		&passkey.AttestationType, &passkey.AAGUID, &signCount, &transports, &passkey.BackupEligible,
+		&passkey.BackupState, &passkey.CreatedAt, &lastUsedAt)
+	if err != nil {
+		return err
+	}
+
+	passkey.SignCount = uint32(signCount)
+	if transports != "" {
+		passkey.Transports = strings.Split(transports, ",")
+	}
+	if lastUsedAt.Valid {
+		passkey.LastUsedAt = &lastUsedAt.Time
+	}
+	return nil
+}
+
+func (r *passkeyRepository) Create(passkey *domain.Passkey) error {
+	err := r.db.QueryRow(`
+		INSERT INTO passkeys (user_id, name, credential_id, public_key, attestation_type, aaguid,
+		sign_count, transports, backup_eligible, backup_state)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at`,
+		passkey.UserID, passkey.Name, passkey.CredentialID, passkey.PublicKey, passkey.AttestationType,
+		passkey.AAGUID, int64(passkey.SignCount), strings.Join(passkey.Transports, ","),
+		passkey.BackupEligible, passkey.BackupState,
+	).Scan(&passkey.ID, &passkey.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create passkey: %w", err)
+	}
+
+	return nil
+}
+
+func (r *passkeyRepository) GetAllByUserID(userID int) ([]domain.Passkey, error) {
+	rows, err := r.db.Query(
+		"SELECT "+passkeyColumns+" FROM passkeys WHERE user_id = $1 ORDER BY created_at",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passkeys: %w", err)
+	}
+	defer rows.Close()
+
+	var passkeys []domain.Passkey
+	for rows.Next() {
+		var passkey domain.Passkey
+		if err := scanPasskey(rows, &passkey); err != nil {
+			return nil, fmt.Errorf("failed to scan passkey: %w", err)
+		}
+		passkeys = append(passkeys, passkey)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating passkeys: %w", err)
+	}
+
+	return passkeys, nil
+}
+
+func (r *passkeyRepository) GetByCredentialID(credentialID []byte) (*domain.Passkey, error) {
+	passkey := &domain.Passkey{}
+
+	err := scanPasskey(r.db.QueryRow(
+		"SELECT "+passkeyColumns+" FROM passkeys WHERE credential_id = $1",
+		credentialID,
+	), passkey)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrPasskeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get passkey: %w", err)
+	}
+
+	return passkey, nil
+}
+
+func (r *passkeyRepository) RecordUse(passkeyID int, signCount uint32, backupState bool, usedAt time.Time) error {
+	_, err := r.db.Exec(
+		"UPDATE passkeys SET sign_count = $1, backup_state = $2, last_used_at = $3 WHERE id = $4",
+		int64(signCount), backupState, usedAt, passkeyID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record passkey use: %w", err)
+	}
+
+	return nil
+}
+
+func (r *passkeyRepository) Delete(passkeyID, userID int) error {
+	result, err := r.db.Exec("DELETE FROM passkeys WHERE id = $1 AND user_id = $2", passkeyID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete passkey: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrPasskeyNotFound
+	}
+
+	return nil
+}
+
+// GetOrCreateUserHandle returns the user's WebAuthn user handle, storing the
+// given one if the user has none yet. The handle is random rather than
+// derived from the user ID or email, as the WebAuthn spec recommends.
+func (r *passkeyRepository) GetOrCreateUserHandle(userID int, handle []byte) ([]byte, error) {
+	_, err := r.db.Exec(
+		"INSERT INTO webauthn_users (user_id, handle) VALUES ($1, $2) ON CONFLICT (user_id) DO NOTHING",
+		userID, handle,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create WebAuthn user handle: %w", err)
+	}
+
+	var stored []byte
+	if err := r.db.QueryRow("SELECT handle FROM webauthn_users WHERE user_id = $1", userID).Scan(&stored); err != nil {
+		return nil, fmt.Errorf("failed to get WebAuthn user handle: %w", err)
+	}
+
+	return stored, nil
+}
+
+func (r *passkeyRepository) GetUserIDByHandle(handle []byte) (int, error) {
+	var userID int
+
+	err := r.db.QueryRow("SELECT user_id FROM webauthn_users WHERE handle = $1", handle).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, domain.ErrUserNotFound
+		}
+		return 0, fmt.Errorf("failed to get user by WebAuthn handle: %w", err)
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+	"rss-reader/pkg/webpush"
+
+	"github.com/lib/pq"
+)
+
+type PushRepository interface {
+	GetVAPIDKeys() (*webpush.VAPIDKeys, error)
+	CreateVAPIDKeys(keys *webpush.VAPIDKeys) error
+	SaveSubscription(subscription *domain.PushSubscription) error
+	GetSubscriptions(userID int) ([]domain.PushSubscription, error)
+	DeleteSubscription(subscriptionID, userID int) error
+	DeleteSubscriptionByEndpoint(endpoint string) error
+	GetSettings(userID int) (*domain.PushSettings, error)
+	SaveSettings(settings *domain.PushSettings) error
+	GetFeedIDs(userID int) ([]int, error)
+	SetFeedIDs(userID int, feedIDs []int) error
+}
+
+type pushRepository struct {
+	db *sql.DB
+}
+
+func NewPushRepository(db *sql.DB) PushRepository {
+	return &pushRepository{db: db}
+}
+
+func (r *pushRepository) GetVAPIDKeys() (*webpush.VAPIDKeys, error) {
+	keys := &webpush.VAPIDKeys{}
+
+	err := r.db.QueryRow("SELECT public_key, private_key FROM vapid_keys WHERE id = 1").
+		Scan(&keys.PublicKey, &keys.PrivateKey)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrVAPIDKeysNotFound
+		}
+		return nil, fmt.Errorf("failed to get VAPID keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// CreateVAPIDKeys stores the server's key pair unless one already exists, so
+// concurrent first starts settle on a single pair.
+func (r *pushRepository) CreateVAPIDKeys(keys *webpush.VAPIDKeys) error {
+	_, err := r.db.Exec(
+		"INSERT INTO vapid_keys (id, public_key, private_key) VALUES (1, $1, $2) ON CONFLICT (id) DO NOTHING",
+		keys.PublicKey, keys.PrivateKey,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create VAPID keys: %w", err)
+	}
+
+	return nil
+}
+
+// SaveSubscription stores a subscription. Browsers reuse an endpoint when
+// resubscribing, so the user's existing row for the endpoint is updated. A
+// browser shared by several accounts has a row for each of them.
+func (r *pushRepository) SaveSubscription(subscription *domain.PushSubscription) error {
+	err := r.db.QueryRow(`
+		INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, user_agent)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, endpoint) DO UPDATE SET
+		p256dh = EXCLUDED.p256dh,
+		auth = EXCLUDED.auth,
+		user_agent = EXCLUDED.user_agent
+		RETURNING id, created_at`,
+		subscription.UserID, subscription.Endpoint, subscription.P256dh, subscription.Auth, subscription.UserAgent,
+	).Scan(&subscription.ID, &subscription.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save push subscription: %w", err)
+	}
+
+	return nil
+}
+
+func (r *pushRepository) GetSubscriptions(userID int) ([]domain.PushSubscription, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, endpoint, p256dh, auth, user_agent, created_at
+		FROM push_subscriptions WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get push subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []domain.PushSubscription
+	for rows.Next() {
+		var subscription domain.PushSubscription
+		if err := rows.Scan(&subscription.ID, &subscription.UserID, &subscription.Endpoint, &subscription.P256dh,
+			&subscription.Auth, &subscription.UserAgent, &subscription.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan push subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating push subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+func (r *pushRepository) DeleteSubscription(subscriptionID, userID int) error {
+	result, err := r.db.Exec("DELETE FROM push_subscriptions WHERE id = $1 AND user_id = $2", subscriptionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete push subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrPushSubscriptionNotFound
+	}
+
+	return nil
+}
+
+func (r *pushRepository) DeleteSubscriptionByEndpoint(endpoint string) error {
+	if _, err := r.db.Exec("DELETE FROM push_subscriptions WHERE endpoint = $1", endpoint); err != nil {
+		return fmt.Errorf("failed to delete push subscription: %w", err)
+	}
+	return nil
+}
+
+// GetSettings returns the user's quiet hours, or none in UTC when unset.
+func (r *pushRepository) GetSettings(userID int) (*domain.PushSettings, error) {
+	settings := &domain.PushSettings{UserID: userID, Timezone: "UTC"}
+
+	err := r.db.QueryRow(
+		"SELECT quiet_start, quiet_end, timezone FROM push_settings WHERE user_id = $1",
+		userID,
+	).Scan(&settings.QuietStart, &settings.QuietEnd, &settings.Timezone)
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get push settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+func (r *pushRepository) SaveSettings(settings *domain.PushSettings) error {
+	_, err := r.db.Exec(`
+		INSERT INTO push_settings (user_id, quiet_start, quiet_end, timezone)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+		quiet_start = EXCLUDED.quiet_start,
+		quiet_end = EXCLUDED.quiet_end,
+		timezone = EXCLUDED.timezone`,
+		settings.UserID, settings.QuietStart, settings.QuietEnd, settings.Timezone)
+
+	if err != nil {
+		return fmt.Errorf("failed to save push settings: %w", err)
+	}
+
+	return nil
+}
+
+// GetFeedIDs returns the feeds the user wants notifications for.
+func (r *pushRepository) GetFeedIDs(userID int) ([]int, error) {
+	rows, err := r.db.Query("SELECT feed_id FROM push_feeds WHERE user_id = $1", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get push feeds: %w", err)
+	}
+	defer rows.Close()
+
+	var feedIDs []int
+	for rows.Next() {
+		var feedID int
+		if err := rows.Scan(&feedID); err != nil {
+			return nil, fmt.Errorf("failed to scan push feed: %w", err)
+		}
+		feedIDs = append(feedIDs, feedID)
+	}
+
+	return feedIDs, rows.Err()
+}
+
+// SetFeedIDs replaces the user's notification feeds. IDs of feeds the user
+// does not own are ignored.
+func (r *pushRepository) SetFeedIDs(userID int, feedIDs []int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin push feeds update: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM push_feeds WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("failed to clear push feeds: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO push_feeds (user_id, feed_id)
+		SELECT $1, id FROM feeds WHERE user_id = $1 AND id = ANY($2)`,
+		userID, pq.Array(feedIDs))
+	if err != nil {
+		return fmt.Errorf("failed to save push feeds: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit push feeds update: %w", err)
+	}
+
+	return nil
+}
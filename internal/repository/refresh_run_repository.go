@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+	"time"
+)
+
+type RefreshRunRepository interface {
+	Create(run *domain.RefreshRun) error
+	GetRecent(limit int) ([]domain.RefreshRun, error)
+	DeleteBefore(before time.Time) (int64, error)
+}
+
+type refreshRunRepository struct {
+	db *sql.DB
+}
+
+func NewRefreshRunRepository(db *sql.DB) RefreshRunRepository {
+	return &refreshRunRepository{db: db}
+}
+
+func (r *refreshRunRepository) Create(run *domain.RefreshRun) error {
+	err := r.db.QueryRow(
+		`INSERT INTO refresh_runs (user_id, feed_id, trigger, feeds_total, feeds_failed, items_processed, items_new, started_at, finished_at)
+		VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`,
+		run.UserID, run.FeedID, run.Trigger, run.FeedsTotal, run.FeedsFailed,
+		run.ItemsProcessed, run.ItemsNew, run.StartedAt, run.FinishedAt,
+	).Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh run: %w", err)
+	}
+	return nil
+}
+
+// GetRecent returns the latest runs across all users, newest first.
+func (r *refreshRunRepository) GetRecent(limit int) ([]domain.RefreshRun, error) {
+	rows, err := r.db.Query(
+		`SELECT rr.id, rr.user_id, u.email, COALESCE(rr.feed_id, 0), COALESCE(f.name, ''), rr.trigger,
+			rr.feeds_total, rr.feeds_failed, rr.items_processed, rr.items_new, rr.started_at, rr.finished_at
+		FROM refresh_runs rr
+		JOIN users u ON u.id = rr.user_id
+		LEFT JOIN feeds f ON f.id = rr.feed_id
+		ORDER BY rr.started_at DESC
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []domain.RefreshRun
+	for rows.Next() {
+		var run domain.RefreshRun
+		err := rows.Scan(&run.ID, &run.UserID, &run.UserEmail, &run.FeedID, &run.FeedName, &run.Trigger,
+			&run.FeedsTotal, &run.FeedsFailed, &run.ItemsProcessed, &run.ItemsNew, &run.StartedAt, &run.FinishedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan refresh run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating refresh runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+func (r *refreshRunRepository) DeleteBefore(before time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM refresh_runs WHERE started_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old refresh runs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+	"time"
+)
+
+type SessionRepository interface {
+	Create(tokenHash string, session *domain.Session) error
+	GetByTokenHash(tokenHash string) (*domain.Session, error)
+	Update(tokenHash string, session *domain.Session) error
+	Touch(sessionID int, ip string, seenAt time.Time) error
+	GetAllByUserID(userID int) ([]domain.Session, error)
+	DeleteByTokenHash(tokenHash string) error
+	Delete(sessionID, userID int) error
+	DeleteOthers(userID int, keepTokenHash string) (int64, error)
+	DeleteAllByUserID(userID int) (int64, error)
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+type sessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+const sessionColumns = "id, user_id, data, user_agent, ip, created_at, last_seen_at, expires_at"
+
+func scanSession(row rowScanner, session *domain.Session) error {
+	var userID sql.NullInt64
+
+	err := row.Scan(&session.ID, &userID, &session.Data, &session.UserAgent, &session.IP,
+		&session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	session.UserID = int(userID.Int64)
+	return nil
+}
+
+// nullUserID stores anonymous sessions, such as one holding a sign-in link
+// binding before login, without a user.
+func nullUserID(userID int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(userID), Valid: userID > 0}
+}
+
+func (r *sessionRepository) Create(tokenHash string, session *domain.Session) error {
+	err := r.db.QueryRow(`
+		INSERT INTO sessions (token_hash, user_id, data, user_agent, ip, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, last_seen_at`,
+		tokenHash, nullUserID(session.UserID), session.Data, session.UserAgent, session.IP, session.ExpiresAt,
+	).Scan(&session.ID, &session.CreatedAt, &session.LastSeenAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) GetByTokenHash(tokenHash string) (*domain.Session, error) {
+	session := &domain.Session{}
+
+	err := scanSession(r.db.QueryRow(
+		"SELECT "+sessionColumns+" FROM sessions WHERE token_hash = $1 AND expires_at > NOW()",
+		tokenHash,
+	), session)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (r *sessionRepository) Update(tokenHash string, session *domain.Session) error {
+	result, err := r.db.Exec(
+		"UPDATE sessions SET user_id = $1, data = $2, expires_at = $3, last_seen_at = NOW() WHERE token_hash = $4",
+		nullUserID(session.UserID), session.Data, session.ExpiresAt, tokenHash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) Touch(sessionID int, ip string, seenAt time.Time) error {
+	_, err := r.db.Exec("UPDATE sessions SET ip = $1, last_seen_at = $2 WHERE id = $3", ip, seenAt, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to update session last seen: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) GetAllByUserID(userID int) ([]domain.Session, error) {
+	rows, err := r.db.Query(
+		"SELECT "+sessionColumns+" FROM sessions WHERE user_id = $1 AND expires_at > NOW() ORDER BY last_seen_at DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []domain.Session
+	for rows.Next() {
+		var session domain.Session
+		if err := scanSession(rows, &session); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *sessionRepository) DeleteByTokenHash(tokenHash string) error {
+	_, err := r.db.Exec("DELETE FROM sessions WHERE token_hash = $1", tokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) Delete(sessionID, userID int) error {
+	result, err := r.db.Exec("DELETE FROM sessions WHERE id = $1 AND user_id = $2", sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) DeleteOthers(userID int, keepTokenHash string) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM sessions WHERE user_id = $1 AND token_hash <> $2", userID, keepTokenHash)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sessions: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *sessionRepository) DeleteAllByUserID(userID int) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM sessions WHERE user_id = $1", userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sessions: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *sessionRepository) DeleteExpired(now time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM sessions WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+	"time"
+)
+
+type TOTPRepository interface {
+	GetByUserID(userID int) (*domain.TOTPCredential, error)
+	SavePending(userID int, secret string) error
+	Confirm(userID int, step int64, confirmedAt time.Time) error
+	RecordStep(userID int, step int64) (bool, error)
+	Delete(userID int) error
+	ReplaceRecoveryCodes(userID int, codeHashes []string) error
+	UseRecoveryCode(userID int, codeHash string, usedAt time.Time) (bool, error)
+	CountRecoveryCodes(userID int) (int, error)
+}
+
+type totpRepository struct {
+	db *sql.DB
+}
+
+func NewTOTPRepository(db *sql.DB) TOTPRepository {
+	return &totpRepository{db: db}
+}
+
+func (r *totpRepository) GetByUserID(userID int) (*domain.TOTPCredential, error) {
+	credential := &domain.TOTPCredential{}
+	var confirmedAt sql.NullTime
+
+	err := r.db.QueryRow(
+		"SELECT user_id, secret, confirmed_at, last_used_step, created_at FROM totp_credentials WHERE user_id = $1",
+		userID,
+	).Scan(&credential.UserID, &credential.Secret, &confirmedAt, &credential.LastUsedStep, &credential.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrTOTPNotFound
+		}
+		return nil, fmt.Errorf("failed to get TOTP credential: %w", err)
+	}
+
+	if confirmedAt.Valid {
+		credential.ConfirmedAt = &confirmedAt.Time
+	}
+	return credential, nil
+}
+
+// SavePending stores a new unconfirmed secret, replacing an earlier
+// unconfirmed one. A confirmed secret is left alone.
+func (r *totpRepository) SavePending(userID int, secret string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO totp_credentials (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+		WHERE totp_credentials.confirmed_at IS NULL`,
+		userID, secret,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save TOTP credential: %w", err)
+	}
+
+	return nil
+}
+
+func (r *totpRepository) Confirm(userID int, step int64, confirmedAt time.Time) error {
+	result, err := r.db.Exec(
+		"UPDATE totp_credentials SET confirmed_at = $1, last_used_step = $2 WHERE user_id = $3 AND confirmed_at IS NULL",
+		confirmedAt, step, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to confirm TOTP credential: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrTOTPNotFound
+	}
+
+	return nil
+}
+
+// RecordStep marks a time step as used, reporting false if it or a later
+// step was already used, so each code is accepted at most once.
+func (r *totpRepository) RecordStep(userID int, step int64) (bool, error) {
+	result, err := r.db.Exec(
+		"UPDATE totp_credentials SET last_used_step = $1 WHERE user_id = $2 AND last_used_step < $1",
+		step, userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record TOTP use: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *totpRepository) Delete(userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM totp_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM totp_credentials WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("failed to delete TOTP credential: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *totpRepository) ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM totp_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, codeHash := range codeHashes {
+		if _, err := tx.Exec("INSERT INTO totp_recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, codeHash); err != nil {
+			return fmt.Errorf("failed to store recovery code: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode consumes an unused recovery code, reporting false if the
+// user has no such code.
+func (r *totpRepository) UseRecoveryCode(userID int, codeHash string, usedAt time.Time) (bool, error) {
+	result, err := r.db.Exec(
+		"UPDATE totp_recovery_codes SET used_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL",
+		usedAt, userID, codeHash,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *totpRepository) CountRecoveryCodes(userID int) (int, error) {
+	var count int
+
+	err := r.db.QueryRow(
+		"SELECT COUNT(*) FROM totp_recovery_codes WHERE user_id = $1 AND used_at IS NULL",
+		userID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return count, nil
+}
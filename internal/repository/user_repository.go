@@ -4,12 +4,16 @@ import (
 	"database/sql"
 	"fmt"
 	"rss-reader/internal/domain"
+	"time"
 )
 
 type UserRepository interface {
 	Create(email string) (*domain.User, error)
 	GetByEmail(email string) (*domain.User, error)
 	GetByID(id int) (*domain.User, error)
+	UpdateLocale(id int, locale string) error
+	SetRole(id int, role string) error
+	SetDisabled(id int, disabledAt *time.Time) error
 }
 
 type userRepository struct {
@@ -24,9 +28,9 @@ func (r *userRepository) Create(email string) (*domain.User, error) {
 	user := &domain.User{Email: email}
 	
 	err := r.db.QueryRow(
-		"INSERT INTO users (email) VALUES ($1) RETURNING id, created_at",
+		"INSERT INTO users (email) VALUES ($1) RETURNING id, locale, role, created_at",
 		email,
-	).Scan(&user.ID, &user.CreatedAt)
+	).Scan(&user.ID, &user.Locale, &user.Role, &user.CreatedAt)
 	
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
@@ -35,13 +39,29 @@ func (r *userRepository) Create(email string) (*domain.User, error) {
 	return user, nil
 }
 
+const userColumns = "id, email, locale, role, disabled_at, created_at"
+
+func scanUser(row rowScanner, user *domain.User) error {
+	var disabledAt sql.NullTime
+
+	err := row.Scan(&user.ID, &user.Email, &user.Locale, &user.Role, &disabledAt, &user.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if disabledAt.Valid {
+		user.DisabledAt = &disabledAt.Time
+	}
+	return nil
+}
+
 func (r *userRepository) GetByEmail(email string) (*domain.User, error) {
 	user := &domain.User{}
 	
-	err := r.db.QueryRow(
-		"SELECT id, email, created_at FROM users WHERE email = $1",
+	err := scanUser(r.db.QueryRow(
+		"SELECT "+userColumns+" FROM users WHERE email = $1",
 		email,
-	).Scan(&user.ID, &user.Email, &user.CreatedAt)
+	), user)
 	
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -56,10 +76,10 @@ func (r *userRepository) GetByEmail(email string) (*domain.User, error) {
 func (r *userRepository) GetByID(id int) (*domain.User, error) {
 	user := &domain.User{}
 	
-	err := r.db.QueryRow(
-		"SELECT id, email, created_at FROM users WHERE id = $1",
+	err := scanUser(r.db.QueryRow(
+		"SELECT "+userColumns+" FROM users WHERE id = $1",
 		id,
-	).Scan(&user.ID, &user.Email, &user.CreatedAt)
+	), user)
 	
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -69,4 +89,50 @@ func (r *userRepository) GetByID(id int) (*domain.User, error) {
 	}
 	
 	return user, nil
-}
\ No newline at end of file
+}
+
+// UpdateLocale sets the language of the user's emails; an empty locale
+// selects the instance default.
+func (r *userRepository) UpdateLocale(id int, locale string) error {
+	result, err := r.db.Exec("UPDATE users SET locale = $1 WHERE id = $2", locale, id)
+	if err != nil {
+		return fmt.Errorf("failed to update user locale: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *userRepository) SetRole(id int, role string) error {
+	return r.updateUser("UPDATE users SET role = $1 WHERE id = $2", role, id)
+}
+
+// SetDisabled disables the user at disabledAt, or enables them again when
+// it is nil.
+func (r *userRepository) SetDisabled(id int, disabledAt *time.Time) error {
+	return r.updateUser("UPDATE users SET disabled_at = $1 WHERE id = $2", disabledAt, id)
+}
+
+func (r *userRepository) updateUser(query string, value interface{}, id int) error {
+	result, err := r.db.Exec(query, value, id)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+)
+
+type UserSettingsRepository interface {
+	GetByUserID(userID int) (*domain.UserSettings, error)
+	Upsert(settings *domain.UserSettings) error
+}
+
+type userSettingsRepository struct {
+	db *sql.DB
+}
+
+func NewUserSettingsRepository(db *sql.DB) UserSettingsRepository {
+	return &userSettingsRepository{db: db}
+}
+
+// GetByUserID returns the stored settings, or zero-valued settings when the
+// user has never saved any.
+func (r *userSettingsRepository) GetByUserID(userID int) (*domain.UserSettings, error) {
+	settings := &domain.UserSettings{UserID: userID}
+
+	err := r.db.QueryRow(
+		"SELECT retention_days, retention_max_items FROM user_settings WHERE user_id = $1",
+		userID,
+	).Scan(&settings.Retention.MaxAgeDays, &settings.Retention.MaxItems)
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get user settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+func (r *userSettingsRepository) Upsert(settings *domain.UserSettings) error {
+	_, err := r.db.Exec(`
+		INSERT INTO user_settings (user_id, retention_days, retention_max_items)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+		retention_days = EXCLUDED.retention_days,
+		retention_max_items = EXCLUDED.retention_max_items,
+		updated_at = CURRENT_TIMESTAMP`,
+		settings.UserID, settings.Retention.MaxAgeDays, settings.Retention.MaxItems)
+
+	if err != nil {
+		return fmt.Errorf("failed to save user settings: %w", err)
+	}
+
+	return nil
+}
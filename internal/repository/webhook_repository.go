@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"rss-reader/internal/domain"
+	"time"
+)
+
+type WebhookRepository interface {
+	Create(webhook *domain.Webhook) error
+	GetByID(webhookID, userID int) (*domain.Webhook, error)
+	GetAllByUserID(userID int) ([]domain.Webhook, error)
+	Delete(webhookID, userID int) error
+	Enqueue(deliveries []domain.WebhookDelivery) error
+	GetDueDeliveries(now time.Time, limit int) ([]domain.WebhookDelivery, error)
+	GetRecentDeliveries(userID, limit int) ([]domain.WebhookDelivery, error)
+	RecordAttempt(delivery *domain.WebhookDelivery) error
+	DeleteDeliveriesBefore(cutoff time.Time) (int64, error)
+}
+
+type webhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+const webhookColumns = `w.id, w.user_id, w.name, w.url, w.secret, COALESCE(w.feed_id, 0), COALESCE(w.folder_id, 0),
+	COALESCE(w.alert_id, 0), w.created_at`
+
+func scanWebhook(row rowScanner, webhook *domain.Webhook) error {
+	return row.Scan(&webhook.ID, &webhook.UserID, &webhook.Name, &webhook.URL, &webhook.Secret,
+		&webhook.FeedID, &webhook.FolderID, &webhook.AlertID, &webhook.CreatedAt)
+}
+
+func (r *webhookRepository) Create(webhook *domain.Webhook) error {
+	err := r.db.QueryRow(`
+		INSERT INTO webhooks (user_id, name, url, secret, feed_id, folder_id, alert_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0), NULLIF($6, 0), NULLIF($7, 0))
+		RETURNING id, created_at`,
+		webhook.UserID, webhook.Name, webhook.URL, webhook.Secret, webhook.FeedID, webhook.FolderID, webhook.AlertID,
+	).Scan(&webhook.ID, &webhook.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) GetByID(webhookID, userID int) (*domain.Webhook, error) {
+	webhook := &domain.Webhook{}
+
+	err := scanWebhook(r.db.QueryRow(
+		"SELECT "+webhookColumns+" FROM webhooks w WHERE w.id = $1 AND w.user_id = $2",
+		webhookID, userID,
+	), webhook)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (r *webhookRepository) GetAllByUserID(userID int) ([]domain.Webhook, error) {
+	rows, err := r.db.Query(
+		"SELECT "+webhookColumns+" FROM webhooks w WHERE w.user_id = $1 ORDER BY w.name, w.id",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []domain.Webhook
+	for rows.Next() {
+		var webhook domain.Webhook
+		if err := scanWebhook(rows, &webhook); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (r *webhookRepository) Delete(webhookID, userID int) error {
+	result, err := r.db.Exec("DELETE FROM webhooks WHERE id = $1 AND user_id = $2", webhookID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// Enqueue queues deliveries in a single transaction, due immediately.
+func (r *webhookRepository) Enqueue(deliveries []domain.WebhookDelivery) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin webhook enqueue: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, delivery := range deliveries {
+		_, err := tx.Exec(
+			"INSERT INTO webhook_deliveries (webhook_id, event, payload) VALUES ($1, $2, $3)",
+			delivery.WebhookID, delivery.Event, delivery.Payload,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit webhook enqueue: %w", err)
+	}
+
+	return nil
+}
+
+const deliveryColumns = `d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.response_status,
+	d.last_error, d.next_attempt_at, d.created_at, d.delivered_at`
+
+func scanDeliveries(rows *sql.Rows) ([]domain.WebhookDelivery, error) {
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		var (
+			delivery    domain.WebhookDelivery
+			deliveredAt sql.NullTime
+		)
+		err := rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &delivery.Payload, &delivery.Status,
+			&delivery.Attempts, &delivery.ResponseStatus, &delivery.LastError, &delivery.NextAttemptAt,
+			&delivery.CreatedAt, &deliveredAt,
+			&delivery.Webhook.ID, &delivery.Webhook.UserID, &delivery.Webhook.Name, &delivery.Webhook.URL,
+			&delivery.Webhook.Secret, &delivery.Webhook.FeedID, &delivery.Webhook.FolderID, &delivery.Webhook.AlertID,
+			&delivery.Webhook.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		if deliveredAt.Valid {
+			delivery.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// GetDueDeliveries returns pending deliveries whose next attempt is due,
+// oldest first, with their webhook.
+func (r *webhookRepository) GetDueDeliveries(now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	rows, err := r.db.Query(`
+		SELECT `+deliveryColumns+`, `+webhookColumns+`
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		JOIN users u ON u.id = w.user_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= $1 AND u.disabled_at IS NULL
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT $2`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due webhook deliveries: %w", err)
+	}
+
+	return scanDeliveries(rows)
+}
+
+func (r *webhookRepository) GetRecentDeliveries(userID, limit int) ([]domain.WebhookDelivery, error) {
+	rows, err := r.db.Query(`
+		SELECT `+deliveryColumns+`, `+webhookColumns+`
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE w.user_id = $1
+		ORDER BY d.created_at DESC, d.id DESC
+		LIMIT $2`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	return scanDeliveries(rows)
+}
+
+func (r *webhookRepository) RecordAttempt(delivery *domain.WebhookDelivery) error {
+	_, err := r.db.Exec(`
+		UPDATE webhook_deliveries SET
+		status = $1, attempts = $2, response_status = $3, last_error = $4,
+		next_attempt_at = $5, delivered_at = $6
+		WHERE id = $7`,
+		delivery.Status, delivery.Attempts, delivery.ResponseStatus, delivery.LastError,
+		delivery.NextAttemptAt, delivery.DeliveredAt, delivery.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteDeliveriesBefore prunes finished deliveries from the log.
+func (r *webhookRepository) DeleteDeliveriesBefore(cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec(
+		"DELETE FROM webhook_deliveries WHERE status <> 'pending' AND created_at < $1",
+		cutoff,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune webhook deliveries: %w", err)
+	}
+
+	rowsDeleted, _ := result.RowsAffected()
+	return rowsDeleted, nil
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/repository"
+	"strings"
+	"time"
+)
+
+const (
+	adminFailingFeedsLimit = 50
+	adminRefreshRunsLimit  = 50
+)
+
+// AdminOverview is everything shown on the admin dashboard.
+type AdminOverview struct {
+	Users        []domain.UserOverview
+	Storage      *domain.StorageUsage
+	FailingFeeds []domain.FailingFeed
+	RefreshRuns  []domain.RefreshRun
+}
+
+// AdminService backs the instance-wide admin area.
+type AdminService struct {
+	userRepository       repository.UserRepository
+	adminRepository      repository.AdminRepository
+	refreshRunRepository repository.RefreshRunRepository
+	feedService          *FeedService
+	sessionService       *SessionService
+}
+
+func NewAdminService(
+	userRepository repository.UserRepository,
+	adminRepository repository.AdminRepository,
+	refreshRunRepository repository.RefreshRunRepository,
+	feedService *FeedService,
+	sessionService *SessionService,
+) *AdminService {
+	return &AdminService{
+		userRepository:       userRepository,
+		adminRepository:      adminRepository,
+		refreshRunRepository: refreshRunRepository,
+		feedService:          feedService,
+		sessionService:       sessionService,
+	}
+}
+
+// Bootstrap gives the admin role to each of emails, creating accounts that
+// do not exist yet so a fresh instance can be administered from its first
+// sign-in. Users are never demoted here; removing an address from the list
+// leaves its role as it is.
+func (s *AdminService) Bootstrap(emails []string) error {
+	for _, email := range emails {
+		email = strings.ToLower(strings.TrimSpace(email))
+
+		user, err := s.userRepository.GetByEmail(email)
+		if err == domain.ErrUserNotFound {
+			user, err = s.userRepository.Create(email)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to bootstrap administrator %s: %w", email, err)
+		}
+
+		if user.IsAdmin() {
+			continue
+		}
+		if err := s.userRepository.SetRole(user.ID, domain.RoleAdmin); err != nil {
+			return fmt.Errorf("failed to bootstrap administrator %s: %w", email, err)
+		}
+		log.Printf("Granted admin role to %s", email)
+	}
+	return nil
+}
+
+// IsAdmin reports whether the user may use the admin area.
+func (s *AdminService) IsAdmin(userID int) (bool, error) {
+	user, err := s.userRepository.GetByID(userID)
+	if err != nil {
+		return false, err
+	}
+	return user.IsAdmin() && !user.IsDisabled(), nil
+}
+
+func (s *AdminService) GetOverview() (*AdminOverview, error) {
+	users, err := s.adminRepository.GetUserOverviews()
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := s.adminRepository.GetStorageUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	failingFeeds, err := s.adminRepository.GetFailingFeeds(adminFailingFeedsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := s.refreshRunRepository.GetRecent(adminRefreshRunsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AdminOverview{
+		Users:        users,
+		Storage:      storage,
+		FailingFeeds: failingFeeds,
+		RefreshRuns:  runs,
+	}, nil
+}
+
+// SetUserDisabled disables or re-enables a user. Disabling signs them out
+// everywhere; their API tokens and Fever key stop working until they are
+// enabled again.
+func (s *AdminService) SetUserDisabled(adminID, userID int, disabled bool) error {
+	if disabled && adminID == userID {
+		return domain.ErrDisableSelf
+	}
+
+	var disabledAt *time.Time
+	if disabled {
+		now := time.Now()
+		disabledAt = &now
+	}
+
+	if err := s.userRepository.SetDisabled(userID, disabledAt); err != nil {
+		return err
+	}
+
+	if disabled {
+		if _, err := s.sessionService.RevokeAll(userID); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Administrator %d set user %d disabled=%t", adminID, userID, disabled)
+	return nil
+}
+
+// RefreshFeed fetches one feed now, whoever owns it.
+func (s *AdminService) RefreshFeed(adminID, feedID int) (*domain.RefreshRun, error) {
+	log.Printf("Administrator %d forced a refresh of feed %d", adminID, feedID)
+	return s.feedService.ForceRefreshFeed(feedID)
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/repository"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxAlertEmailItems = 50
+
+// AlertService matches newly ingested items against saved keyword and regex
+// alerts and emails the matches. Matches are queued and sent in batches, at
+// most one email per alert every BatchMinutes.
+type AlertService struct {
+	alertRepository repository.AlertRepository
+	userRepository  repository.UserRepository
+	mailer          *Mailer
+	flushMutex      sync.Mutex
+}
+
+func NewAlertService(
+	alertRepository repository.AlertRepository,
+	userRepository repository.UserRepository,
+	mailer *Mailer,
+) *AlertService {
+	return &AlertService{
+		alertRepository: alertRepository,
+		userRepository:  userRepository,
+		mailer:          mailer,
+	}
+}
+
+func (s *AlertService) GetAlerts(userID int) ([]domain.Alert, error) {
+	alerts, err := s.alertRepository.GetAllByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alerts: %w", err)
+	}
+	return alerts, nil
+}
+
+func (s *AlertService) CreateAlert(userID int, name, pattern string, isRegex bool, batchMinutes int) (*domain.Alert, error) {
+	alert := &domain.Alert{
+		UserID:       userID,
+		Name:         strings.TrimSpace(name),
+		Pattern:      strings.TrimSpace(pattern),
+		IsRegex:      isRegex,
+		BatchMinutes: batchMinutes,
+	}
+	if err := alert.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.alertRepository.Create(alert); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Created alert %d for user %d", alert.ID, userID)
+	return alert, nil
+}
+
+func (s *AlertService) DeleteAlert(alertID, userID int) error {
+	return s.alertRepository.Delete(alertID, userID)
+}
+
+// HandleNewItems queues the items matching each of the user's alerts and
+// sends any email that is already due.
+func (s *AlertService) HandleNewItems(userID int, items []domain.FeedItem) {
+	alerts, err := s.alertRepository.GetAllByUserID(userID)
+	if err != nil {
+		log.Printf("Warning: failed to load alerts for user %d: %v", userID, err)
+		return
+	}
+	if len(alerts) == 0 {
+		return
+	}
+
+	matched := false
+	for i := range alerts {
+		alert := &alerts[i]
+		matches, err := alert.Matcher()
+		if err != nil {
+			log.Printf("Warning: skipping alert %d: %v", alert.ID, err)
+			continue
+		}
+
+		var itemIDs []int
+		for _, item := range items {
+			if matches(item.Title) || matches(item.Description) {
+				itemIDs = append(itemIDs, item.ID)
+			}
+		}
+		if len(itemIDs) == 0 {
+			continue
+		}
+
+		if err := s.alertRepository.RecordMatches(alert.ID, itemIDs); err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		log.Printf("Alert %d matched %d new items", alert.ID, len(itemIDs))
+		matched = true
+	}
+
+	if matched {
+		go s.FlushDue(time.Now())
+	}
+}
+
+// FlushDue sends one email for every alert with queued matches whose
+// batching window has passed.
+func (s *AlertService) FlushDue(now time.Time) {
+	s.flushMutex.Lock()
+	defer s.flushMutex.Unlock()
+
+	alerts, err := s.alertRepository.GetDue(now)
+	if err != nil {
+		log.Printf("Warning: failed to load due alerts: %v", err)
+		return
+	}
+
+	for i := range alerts {
+		if err := s.sendAlert(&alerts[i], now); err != nil {
+			log.Printf("Warning: alert %d failed: %v", alerts[i].ID, err)
+		}
+	}
+}
+
+func (s *AlertService) sendAlert(alert *domain.Alert, now time.Time) error {
+	user, err := s.userRepository.GetByID(alert.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// One extra row tells us whether matches remain for the next email.
+	items, err := s.alertRepository.GetPendingItems(alert.ID, maxAlertEmailItems+1)
+	if err != nil {
+		return err
+	}
+	more := len(items) > maxAlertEmailItems
+	if more {
+		items = items[:maxAlertEmailItems]
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	err = s.mailer.SendToUser(user, EmailAlert, map[string]interface{}{
+		"Alert": alert,
+		"Items": items,
+		"More":  more,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send alert: %w", err)
+	}
+
+	itemIDs := make([]int, len(items))
+	for i, item := range items {
+		itemIDs[i] = item.ID
+	}
+	if err := s.alertRepository.MarkSent(alert.ID, itemIDs, now); err != nil {
+		return err
+	}
+
+	log.Printf("Sent alert %d with %d items to user %d", alert.ID, len(items), alert.UserID)
+	return nil
+}
+
+// Start sends batched alert emails whose window has passed every interval
+// in the background.
+func (s *AlertService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.FlushDue(time.Now())
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/repository"
+	"rss-reader/pkg/security"
+	"strings"
+	"time"
+)
+
+const apiTokenPrefix = "fs_"
+
+// apiTokenTouchInterval limits how often a token's last-used time is
+// written, since clients authenticate every request with it.
+const apiTokenTouchInterval = time.Minute
+
+type APITokenService struct {
+	apiTokenRepository repository.APITokenRepository
+	tokenGenerator     *security.TokenGenerator
+	mailer             *Mailer
+}
+
+func NewAPITokenService(
+	apiTokenRepository repository.APITokenRepository,
+	tokenGenerator *security.TokenGenerator,
+	mailer *Mailer,
+) *APITokenService {
+	return &APITokenService{
+		apiTokenRepository: apiTokenRepository,
+		tokenGenerator:     tokenGenerator,
+		mailer:             mailer,
+	}
+}
+
+// CreateToken issues a new token and returns its plaintext value, which is
+// never stored and cannot be shown again.
+func (s *APITokenService) CreateToken(userID int, name string) (string, *domain.APIToken, error) {
+	token := &domain.APIToken{
+		UserID: userID,
+		Name:   strings.TrimSpace(name),
+	}
+	if err := token.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	plaintext, err := s.tokenGenerator.Generate(apiTokenPrefix)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate API token: %w", err)
+	}
+
+	token.Prefix = plaintext[:len(apiTokenPrefix)+6]
+	token.TokenHash = security.HashToken(plaintext)
+
+	if err := s.apiTokenRepository.Create(token); err != nil {
+		return "", nil, fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	log.Printf("Created API token %d for user %d", token.ID, userID)
+	s.mailer.SendSecurityNotice(userID, SecurityEventAPITokenCreated, token.Name)
+	return plaintext, token, nil
+}
+
+func (s *APITokenService) ListTokens(userID int) ([]domain.APIToken, error) {
+	tokens, err := s.apiTokenRepository.GetAllByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+func (s *APITokenService) RevokeToken(tokenID, userID int) error {
+	if err := s.apiTokenRepository.Delete(tokenID, userID); err != nil {
+		return fmt.Errorf("failed to revoke API token: %w", err)
+	}
+	log.Printf("Revoked API token %d for user %d", tokenID, userID)
+	return nil
+}
+
+// Authenticate resolves a plaintext token to its owner's user ID.
+func (s *APITokenService) Authenticate(plaintext string) (int, error) {
+	if !strings.HasPrefix(plaintext, apiTokenPrefix) {
+		return 0, domain.ErrInvalidAPIToken
+	}
+
+	token, err := s.apiTokenRepository.GetByHash(security.HashToken(plaintext))
+	if err != nil {
+		if err == domain.ErrAPITokenNotFound {
+			return 0, domain.ErrInvalidAPIToken
+		}
+		return 0, fmt.Errorf("failed to authenticate API token: %w", err)
+	}
+
+	if token.LastUsedAt == nil || time.Since(*token.LastUsedAt) >= apiTokenTouchInterval {
+		if err := s.apiTokenRepository.TouchLastUsed(token.ID); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	return token.UserID, nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/repository"
+	"rss-reader/pkg/security"
+)
+
+type ArchiveService struct {
+	archiveRepository repository.ArchiveRepository
+	tokenGenerator    *security.TokenGenerator
+}
+
+func NewArchiveService(archiveRepository repository.ArchiveRepository, tokenGenerator *security.TokenGenerator) *ArchiveService {
+	return &ArchiveService{
+		archiveRepository: archiveRepository,
+		tokenGenerator:    tokenGenerator,
+	}
+}
+
+func (s *ArchiveService) Export(userID int) (*domain.Archive, error) {
+	archive, err := s.archiveRepository.Export(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export account: %w", err)
+	}
+	return archive, nil
+}
+
+// Restore decodes an archive and merges it into the user's account.
+func (s *ArchiveService) Restore(userID int, r io.Reader) (*domain.RestoreReport, error) {
+	var archive domain.Archive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return nil, domain.ErrUnsupportedArchive
+	}
+	if err := archive.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Unsubscribe tokens are per instance, so restored digest settings get
+	// a fresh one.
+	unsubscribeToken, err := s.tokenGenerator.Generate("")
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.archiveRepository.Restore(userID, &archive, unsubscribeToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore account: %w", err)
+	}
+
+	log.Printf("Restored archive for user %d: %d folders, %d feeds created, %d feeds updated, %d items created, %d items updated, %d alerts created",
+		userID, report.FoldersCreated, report.FeedsCreated, report.FeedsUpdated, report.ItemsCreated, report.ItemsUpdated, report.AlertsCreated)
+	return report, nil
+}
@@ -1,58 +1,70 @@
 package service
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"log"
+	"net/url"
 	"rss-reader/internal/domain"
 	"rss-reader/internal/repository"
-	"rss-reader/pkg/email"
 	"rss-reader/pkg/ratelimit"
 	"rss-reader/pkg/security"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const otpExpiry = 10 * time.Minute
+
 type AuthService struct {
 	userRepository repository.UserRepository
 	otpRepository  repository.OTPRepository
-	emailService   email.Service
+	mailer         *Mailer
 	otpGenerator   *security.OTPGenerator
-	sendLimiter    *ratelimit.Limiter
-	verifyLimiter  *ratelimit.Limiter
+	tokenGenerator *security.TokenGenerator
+	signer         *security.Signer
+	appURL         string
+	sendLimiter    ratelimit.Limiter
+	verifyLimiter  ratelimit.Limiter
 }
 
 func NewAuthService(
 	userRepository repository.UserRepository,
 	otpRepository repository.OTPRepository,
-	emailService email.Service,
+	mailer *Mailer,
 	otpGenerator *security.OTPGenerator,
+	tokenGenerator *security.TokenGenerator,
+	signer *security.Signer,
+	appURL string,
+	sendLimiter ratelimit.Limiter,
+	verifyLimiter ratelimit.Limiter,
 ) *AuthService {
 	return &AuthService{
 		userRepository: userRepository,
 		otpRepository:  otpRepository,
-		emailService:   emailService,
+		mailer:         mailer,
 		otpGenerator:   otpGenerator,
-		sendLimiter:    ratelimit.NewLimiter(),
-		verifyLimiter:  ratelimit.NewLimiter(),
+		tokenGenerator: tokenGenerator,
+		signer:         signer,
+		appURL:         appURL,
+		sendLimiter:    sendLimiter,
+		verifyLimiter:  verifyLimiter,
 	}
 }
 
-func (s *AuthService) SendOTP(email string) error {
+// SendOTP emails a sign-in code together with a sign-in link. The link only
+// works in the browser holding browserBinding, a random value the caller
+// keeps in that browser's session, so a forwarded email cannot be used to
+// sign in elsewhere; the code can still be typed on any device.
+func (s *AuthService) SendOTP(email, browserBinding string) error {
 	if !s.sendLimiter.Allow(email, 3, 15*time.Minute) {
 		log.Printf("Rate limit exceeded for OTP send to: %s", email)
 		return fmt.Errorf("too many OTP requests, please try again in 15 minutes")
 	}
 
-	_, err := s.userRepository.GetByEmail(email)
+	user, err := s.GetOrCreateUser(email)
 	if err != nil {
-		if err == domain.ErrUserNotFound {
-			_, err = s.userRepository.Create(email)
-			if err != nil {
-				return fmt.Errorf("failed to create user: %w", err)
-			}
-			log.Printf("Created new user with email: %s", email)
-		} else {
-			return fmt.Errorf("failed to get user: %w", err)
-		}
+		return err
 	}
 
 	otp, err := s.otpGenerator.Generate()
@@ -60,26 +72,26 @@ func (s *AuthService) SendOTP(email string) error {
 		return fmt.Errorf("failed to generate OTP: %w", err)
 	}
 
+	linkToken, err := s.tokenGenerator.Generate("")
+	if err != nil {
+		return fmt.Errorf("failed to generate sign-in link: %w", err)
+	}
+
 	if err := s.otpRepository.DeleteByEmail(email); err != nil {
 		log.Printf("Warning: failed to delete old OTPs for %s: %v", email, err)
 	}
 
-	expiresAt := time.Now().Add(10 * time.Minute)
-	if err := s.otpRepository.Store(email, otp, expiresAt); err != nil {
+	expiresAt := time.Now().Add(otpExpiry)
+	if err := s.otpRepository.Store(email, s.hashOTP(email, otp), security.HashToken(linkToken), expiresAt); err != nil {
 		return fmt.Errorf("failed to store OTP: %w", err)
 	}
 
-	subject := "Your OTP for FeedStream RSS Reader"
-	body := fmt.Sprintf(`
-		<html>
-		<body style="font-family: Arial, sans-serif; padding: 20px;">
-			<p>Your OTP code is:</p>
-			<p style="font-size: 32px; font-weight: bold; color: #333; letter-spacing: 4px; margin: 20px 0;">%s</p>
-			<p style="color: #666;">This code will expire in 10 minutes.</p>
-		</body>
-		</html>
-	`, otp)
-	if err := s.emailService.SendEmail(email, subject, body); err != nil {
+	err = s.mailer.SendToUser(user, EmailOTP, map[string]interface{}{
+		"Code":             otp,
+		"LoginURL":         s.loginURL(email, linkToken, expiresAt, browserBinding),
+		"ExpiresInMinutes": int(otpExpiry / time.Minute),
+	})
+	if err != nil {
 		log.Printf("Error sending OTP email to %s: %v", email, err)
 		return fmt.Errorf("failed to send OTP email: %w", err)
 	}
@@ -102,13 +114,114 @@ func (s *AuthService) VerifyOTP(email, otpCode string) (*domain.User, error) {
 		return nil, fmt.Errorf("failed to get OTP: %w", err)
 	}
 
-	if !storedOTP.IsValid(otpCode) {
-		if storedOTP.IsExpired() {
-			return nil, domain.ErrOTPExpired
-		}
+	if storedOTP.IsExpired() {
+		return nil, domain.ErrOTPExpired
+	}
+
+	allowed, err := s.otpRepository.UseAttempt(storedOTP.ID, domain.MaxOTPAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		log.Printf("OTP for %s has used all %d attempts", email, domain.MaxOTPAttempts)
+		return nil, domain.ErrOTPAttempts
+	}
+
+	if !storedOTP.Matches(s.hashOTP(email, otpCode)) {
 		return nil, domain.ErrInvalidOTP
 	}
 
+	return s.completeLogin(email)
+}
+
+// hashOTP keys the hash with the application secret, so that a leaked copy
+// of the database alone is not enough to confirm a guessed code.
+func (s *AuthService) hashOTP(email, code string) string {
+	return s.signer.Sign("otp", email, strings.TrimSpace(code))
+}
+
+// Start purges expired OTPs every interval; failed and abandoned sign-ins
+// otherwise leave them behind.
+func (s *AuthService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			purged, err := s.otpRepository.DeleteExpired(time.Now())
+			if err != nil {
+				log.Printf("Warning: %v", err)
+			} else if purged > 0 {
+				log.Printf("Purged %d expired OTPs", purged)
+			}
+
+			<-ticker.C
+		}
+	}()
+}
+
+// loginURL builds the sign-in link for an OTP. The signature covers the
+// browser binding without putting it in the URL, so only the requesting
+// browser can present a link that verifies.
+func (s *AuthService) loginURL(email, token string, expiresAt time.Time, browserBinding string) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	query := url.Values{
+		"email":   {email},
+		"token":   {token},
+		"expires": {expires},
+		"sig":     {s.signer.Sign(email, token, expires, browserBinding)},
+	}
+	return s.appURL + "/login/link?" + query.Encode()
+}
+
+// VerifyLoginLink signs the user in with the link from an OTP email. The
+// link is single use: it is consumed together with the code it was sent
+// with, and counts against the same verification limit.
+func (s *AuthService) VerifyLoginLink(email, token, expires, signature, browserBinding string) (*domain.User, error) {
+	// Check the signature first: links opened by mail scanners or in another
+	// browser fail here without using up the code or the attempt budget.
+	// A forwarded link and a tampered one look the same from here.
+	if browserBinding == "" || !s.signer.Verify(signature, email, token, expires, browserBinding) {
+		return nil, domain.ErrLoginLinkBrowser
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return nil, domain.ErrInvalidLoginLink
+	}
+	if time.Now().Unix() > expiresAt {
+		return nil, domain.ErrOTPExpired
+	}
+
+	if !s.verifyLimiter.Allow(email, 5, 15*time.Minute) {
+		log.Printf("Rate limit exceeded for OTP verification: %s", email)
+		return nil, fmt.Errorf("too many verification attempts, please wait 15 minutes and request a new OTP")
+	}
+
+	storedOTP, err := s.otpRepository.GetLatestByEmail(email)
+	if err != nil {
+		if err == domain.ErrOTPNotFound {
+			return nil, domain.ErrInvalidLoginLink
+		}
+		return nil, fmt.Errorf("failed to get OTP: %w", err)
+	}
+
+	if storedOTP.LinkHash == "" || subtle.ConstantTimeCompare([]byte(storedOTP.LinkHash), []byte(security.HashToken(token))) != 1 {
+		return nil, domain.ErrInvalidLoginLink
+	}
+	if storedOTP.IsExpired() {
+		return nil, domain.ErrOTPExpired
+	}
+	if storedOTP.IsExhausted() {
+		return nil, domain.ErrOTPAttempts
+	}
+
+	return s.completeLogin(email)
+}
+
+// completeLogin consumes the user's pending OTP and sign-in link after a
+// successful verification.
+func (s *AuthService) completeLogin(email string) (*domain.User, error) {
 	if err := s.otpRepository.DeleteByEmail(email); err != nil {
 		log.Printf("Warning: failed to delete OTP for %s: %v", email, err)
 	}
@@ -119,15 +232,43 @@ func (s *AuthService) VerifyOTP(email, otpCode string) (*domain.User, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
+	if user.IsDisabled() {
+		log.Printf("Sign-in refused for disabled user %s", email)
+		return nil, domain.ErrUserDisabled
+	}
 
 	log.Printf("User %s authenticated successfully", email)
 	return user, nil
 }
 
+// GetOrCreateUser returns the user with the given email address, creating
+// an account on first sign-in. Disabled users get ErrUserDisabled.
+func (s *AuthService) GetOrCreateUser(email string) (*domain.User, error) {
+	user, err := s.userRepository.GetByEmail(email)
+	if err != nil {
+		if err != domain.ErrUserNotFound {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+
+		user, err = s.userRepository.Create(email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
+		log.Printf("Created new user with email: %s", email)
+	}
+
+	if user.IsDisabled() {
+		log.Printf("Sign-in refused for disabled user %s", email)
+		return nil, domain.ErrUserDisabled
+	}
+
+	return user, nil
+}
+
 func (s *AuthService) GetUserByID(userID int) (*domain.User, error) {
 	user, err := s.userRepository.GetByID(userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 	return user, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"errors"
+	"rss-reader/internal/domain"
+	"rss-reader/pkg/security"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testOTPEmail = "reader@example.com"
+	testOTPCode  = "K7Q2M9XW4B"
+)
+
+// fakeOTPRepository holds OTPs in memory. UseAttempt counts attempts under
+// the lock, as the conditional UPDATE in otpRepository does in the database.
+type fakeOTPRepository struct {
+	mu   sync.Mutex
+	otps map[string]*domain.OTP
+}
+
+func (r *fakeOTPRepository) Store(email, otpHash, linkHash string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.otps[email] = &domain.OTP{
+		ID:        len(r.otps) + 1,
+		Email:     email,
+		OTPHash:   otpHash,
+		LinkHash:  linkHash,
+		ExpiresAt: expiresAt,
+	}
+	return nil
+}
+
+func (r *fakeOTPRepository) GetLatestByEmail(email string) (*domain.OTP, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	otp, ok := r.otps[email]
+	if !ok {
+		return nil, domain.ErrOTPNotFound
+	}
+	copied := *otp
+	return &copied, nil
+}
+
+func (r *fakeOTPRepository) UseAttempt(id, maxAttempts int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, otp := range r.otps {
+		if otp.ID == id && otp.Attempts < maxAttempts {
+			otp.Attempts++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeOTPRepository) DeleteByEmail(email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.otps, email)
+	return nil
+}
+
+func (r *fakeOTPRepository) DeleteExpired(now time.Time) (int64, error) {
+	return 0, errors.New("not implemented")
+}
+
+type fakeUserRepository struct {
+	users map[string]*domain.User
+}
+
+func (r *fakeUserRepository) Create(email string) (*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *fakeUserRepository) GetByEmail(email string) (*domain.User, error) {
+	user, ok := r.users[email]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) GetByID(id int) (*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *fakeUserRepository) UpdateLocale(id int, locale string) error {
+	return errors.New("not implemented")
+}
+
+func (r *fakeUserRepository) SetRole(id int, role string) error {
+	return errors.New("not implemented")
+}
+
+func (r *fakeUserRepository) SetDisabled(id int, disabledAt *time.Time) error {
+	return errors.New("not implemented")
+}
+
+// unlimited lets every request through, so that tests reach the per-OTP
+// attempt cap rather than the per-email rate limit in front of it.
+type unlimited struct{}
+
+func (unlimited) Allow(string, int, time.Duration) bool { return true }
+
+func (unlimited) RetryAfter(string, int, time.Duration) time.Duration { return 0 }
+
+func (unlimited) Reset(string) {}
+
+func newTestAuthService(t *testing.T, user *domain.User, expiresAt time.Time) (*AuthService, *fakeOTPRepository) {
+	t.Helper()
+	otps := &fakeOTPRepository{otps: make(map[string]*domain.OTP)}
+	users := &fakeUserRepository{users: map[string]*domain.User{user.Email: user}}
+	s := NewAuthService(users, otps, nil, security.NewOTPGenerator(), security.NewTokenGenerator(),
+		security.NewSigner("test-secret"), "http://localhost", unlimited{}, unlimited{})
+
+	if err := otps.Store(user.Email, s.hashOTP(user.Email, testOTPCode), "", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	return s, otps
+}
+
+func TestVerifyOTP(t *testing.T) {
+	disabledAt := time.Now()
+
+	tests := []struct {
+		name      string
+		wrong     int
+		code      string
+		expiresIn time.Duration
+		disabled  *time.Time
+		want      error
+	}{
+		{"correct code", 0, testOTPCode, time.Minute, nil, nil},
+		{"surrounding space", 0, " " + testOTPCode + " ", time.Minute, nil, nil},
+		{"wrong code", 0, "AAAAAAAAAA", time.Minute, nil, domain.ErrInvalidOTP},
+		{"lower case", 0, "k7q2m9xw4b", time.Minute, nil, domain.ErrInvalidOTP},
+		{"correct on the last attempt", domain.MaxOTPAttempts - 1, testOTPCode, time.Minute, nil, nil},
+		{"correct after the attempts ran out", domain.MaxOTPAttempts, testOTPCode, time.Minute, nil, domain.ErrOTPAttempts},
+		{"expired", 0, testOTPCode, -time.Second, nil, domain.ErrOTPExpired},
+		{"disabled user", 0, testOTPCode, time.Minute, &disabledAt, domain.ErrUserDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &domain.User{ID: 1, Email: testOTPEmail, DisabledAt: tt.disabled}
+			s, _ := newTestAuthService(t, user, time.Now().Add(tt.expiresIn))
+
+			for i := 0; i < tt.wrong; i++ {
+				if _, err := s.VerifyOTP(testOTPEmail, "AAAAAAAAAA"); !errors.Is(err, domain.ErrInvalidOTP) {
+					t.Fatalf("wrong guess %d: got %v, want %v", i+1, err, domain.ErrInvalidOTP)
+				}
+			}
+
+			got, err := s.VerifyOTP(testOTPEmail, tt.code)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("VerifyOTP(%q) = %v, want %v", tt.code, err, tt.want)
+			}
+			if tt.want == nil && got != user {
+				t.Errorf("VerifyOTP returned user %v, want %v", got, user)
+			}
+		})
+	}
+}
+
+func TestVerifyOTPNotRequested(t *testing.T) {
+	s, _ := newTestAuthService(t, &domain.User{ID: 1, Email: testOTPEmail}, time.Now().Add(time.Minute))
+
+	if _, err := s.VerifyOTP("someone-else@example.com", testOTPCode); !errors.Is(err, domain.ErrInvalidOTP) {
+		t.Errorf("got %v, want %v", err, domain.ErrInvalidOTP)
+	}
+}
+
+func TestVerifyOTPSingleUse(t *testing.T) {
+	s, _ := newTestAuthService(t, &domain.User{ID: 1, Email: testOTPEmail}, time.Now().Add(time.Minute))
+
+	if _, err := s.VerifyOTP(testOTPEmail, testOTPCode); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if _, err := s.VerifyOTP(testOTPEmail, testOTPCode); !errors.Is(err, domain.ErrInvalidOTP) {
+		t.Errorf("second use: got %v, want %v", err, domain.ErrInvalidOTP)
+	}
+}
+
+// TestVerifyOTPConcurrentGuesses sends many guesses at once, as an attacker
+// would to get past a check-then-increment attempt counter. Only
+// MaxOTPAttempts of them may be compared against the code.
+func TestVerifyOTPConcurrentGuesses(t *testing.T) {
+	const guesses = 50
+	s, otps := newTestAuthService(t, &domain.User{ID: 1, Email: testOTPEmail}, time.Now().Add(time.Minute))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, guesses)
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.VerifyOTP(testOTPEmail, "AAAAAAAAAA")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	compared, refused := 0, 0
+	for err := range errs {
+		switch {
+		case errors.Is(err, domain.ErrInvalidOTP):
+			compared++
+		case errors.Is(err, domain.ErrOTPAttempts):
+			refused++
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if compared != domain.MaxOTPAttempts || refused != guesses-domain.MaxOTPAttempts {
+		t.Errorf("%d guesses compared and %d refused, want %d and %d",
+			compared, refused, domain.MaxOTPAttempts, guesses-domain.MaxOTPAttempts)
+	}
+
+	if _, err := s.VerifyOTP(testOTPEmail, testOTPCode); !errors.Is(err, domain.ErrOTPAttempts) {
+		t.Errorf("correct code after the guesses: got %v, want %v", err, domain.ErrOTPAttempts)
+	}
+	if otp, _ := otps.GetLatestByEmail(testOTPEmail); otp.Attempts != domain.MaxOTPAttempts {
+		t.Errorf("OTP records %d attempts, want %d", otp.Attempts, domain.MaxOTPAttempts)
+	}
+}
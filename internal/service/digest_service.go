@@ -0,0 +1,248 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/repository"
+	"rss-reader/pkg/security"
+	"sort"
+	"time"
+)
+
+const maxDigestItems = 100
+
+// DigestFeed and DigestFolder group a digest's items for rendering.
+type DigestFeed struct {
+	Name  string
+	Items []domain.FeedItem
+}
+
+// DigestFolder has an empty Name for feeds outside any folder; templates
+// label it in the recipient's language.
+type DigestFolder struct {
+	Name  string
+	Feeds []DigestFeed
+}
+
+// DigestService sends opt-in daily or weekly email digests of unread items.
+// Each item is recorded when sent, so it never appears in a later digest.
+type DigestService struct {
+	digestRepository repository.DigestRepository
+	userRepository   repository.UserRepository
+	feedService      *FeedService
+	mailer           *Mailer
+	tokenGenerator   *security.TokenGenerator
+	appURL           string
+}
+
+func NewDigestService(
+	digestRepository repository.DigestRepository,
+	userRepository repository.UserRepository,
+	feedService *FeedService,
+	mailer *Mailer,
+	tokenGenerator *security.TokenGenerator,
+	appURL string,
+) *DigestService {
+	return &DigestService{
+		digestRepository: digestRepository,
+		userRepository:   userRepository,
+		feedService:      feedService,
+		mailer:           mailer,
+		tokenGenerator:   tokenGenerator,
+		appURL:           appURL,
+	}
+}
+
+// GetSettings returns the user's digest settings, or the defaults (off,
+// 8:00 UTC, Mondays for weekly) when they have never opted in.
+func (s *DigestService) GetSettings(userID int) (*domain.DigestSettings, error) {
+	settings, err := s.digestRepository.GetByUserID(userID)
+	if err == domain.ErrDigestNotFound {
+		return &domain.DigestSettings{
+			UserID:    userID,
+			Frequency: domain.DigestOff,
+			SendHour:  8,
+			Weekday:   time.Monday,
+			Timezone:  "UTC",
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digest settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (s *DigestService) UpdateSettings(settings *domain.DigestSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	if settings.UnsubscribeToken == "" {
+		token, err := s.tokenGenerator.Generate("")
+		if err != nil {
+			return fmt.Errorf("failed to generate unsubscribe token: %w", err)
+		}
+		settings.UnsubscribeToken = token
+	}
+
+	if err := s.digestRepository.Upsert(settings); err != nil {
+		return fmt.Errorf("failed to update digest settings: %w", err)
+	}
+
+	log.Printf("Digest for user %d set to %s", settings.UserID, settings.Frequency)
+	return nil
+}
+
+func (s *DigestService) Unsubscribe(token string) error {
+	if token == "" {
+		return domain.ErrDigestNotFound
+	}
+	return s.digestRepository.Unsubscribe(token)
+}
+
+// RunDue sends every digest whose scheduled slot has passed.
+func (s *DigestService) RunDue(now time.Time) {
+	subscriptions, err := s.digestRepository.GetEnabled()
+	if err != nil {
+		log.Printf("Warning: failed to load digest subscriptions: %v", err)
+		return
+	}
+
+	for i := range subscriptions {
+		settings := &subscriptions[i]
+		if !settings.IsDue(now) {
+			continue
+		}
+		if err := s.sendDigest(settings, now); err != nil {
+			log.Printf("Warning: digest for user %d failed: %v", settings.UserID, err)
+		}
+	}
+}
+
+func (s *DigestService) sendDigest(settings *domain.DigestSettings, now time.Time) error {
+	user, err := s.userRepository.GetByID(settings.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.IsDisabled() {
+		return nil
+	}
+
+	// Feeds are otherwise only fetched when the user opens the reader.
+	if _, _, err := s.feedService.RefreshFeeds(settings.UserID, domain.RefreshTriggerDigest); err != nil {
+		log.Printf("Warning: refresh before digest failed for user %d: %v", settings.UserID, err)
+	}
+
+	// The window overlaps the previous digest and digest_sent_items keeps
+	// items from appearing twice, so items beyond maxDigestItems carry over
+	// to the next digest.
+	items, err := s.digestRepository.GetUnsentItems(settings.UserID, settings.Since(now), maxDigestItems)
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		return s.digestRepository.MarkSent(settings.UserID, nil, now)
+	}
+
+	folders, err := s.groupDigestItems(settings.UserID, items)
+	if err != nil {
+		return err
+	}
+
+	location, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	err = s.mailer.SendToUser(user, EmailDigest, map[string]interface{}{
+		"Count":          len(items),
+		"Frequency":      settings.Frequency,
+		"Folders":        folders,
+		"UnsubscribeURL": s.appURL + "/digest/unsubscribe?token=" + url.QueryEscape(settings.UnsubscribeToken),
+		"FormatDate": func(t time.Time) string {
+			return t.In(location).Format("Jan 2, 15:04")
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send digest: %w", err)
+	}
+
+	itemIDs := make([]int, len(items))
+	for i, item := range items {
+		itemIDs[i] = item.ID
+	}
+	if err := s.digestRepository.MarkSent(settings.UserID, itemIDs, now); err != nil {
+		return err
+	}
+
+	log.Printf("Sent %s digest with %d items to user %d", settings.Frequency, len(items), settings.UserID)
+	return nil
+}
+
+// groupDigestItems groups items by folder, then feed, both alphabetically,
+// with feeds outside any folder last.
+func (s *DigestService) groupDigestItems(userID int, items []domain.FeedItem) ([]DigestFolder, error) {
+	feeds, err := s.feedService.GetFeedsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	folderByFeed := make(map[int]string, len(feeds))
+	for _, feed := range feeds {
+		folderByFeed[feed.ID] = feed.Folder
+	}
+
+	grouped := make(map[string]map[string][]domain.FeedItem)
+	for _, item := range items {
+		folder := folderByFeed[item.FeedID]
+		if grouped[folder] == nil {
+			grouped[folder] = make(map[string][]domain.FeedItem)
+		}
+		grouped[folder][item.FeedName] = append(grouped[folder][item.FeedName], item)
+	}
+
+	folderNames := make([]string, 0, len(grouped))
+	for name := range grouped {
+		folderNames = append(folderNames, name)
+	}
+	sort.Slice(folderNames, func(i, j int) bool {
+		if (folderNames[i] == "") != (folderNames[j] == "") {
+			return folderNames[j] == ""
+		}
+		return folderNames[i] < folderNames[j]
+	})
+
+	folders := make([]DigestFolder, 0, len(folderNames))
+	for _, name := range folderNames {
+		folder := DigestFolder{Name: name}
+
+		feedNames := make([]string, 0, len(grouped[name]))
+		for feedName := range grouped[name] {
+			feedNames = append(feedNames, feedName)
+		}
+		sort.Strings(feedNames)
+
+		for _, feedName := range feedNames {
+			folder.Feeds = append(folder.Feeds, DigestFeed{Name: feedName, Items: grouped[name][feedName]})
+		}
+		folders = append(folders, folder)
+	}
+
+	return folders, nil
+}
+
+// Start checks for due digests every interval in the background.
+func (s *DigestService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.RunDue(time.Now())
+			<-ticker.C
+		}
+	}()
+}
@@ -3,39 +3,75 @@ package service
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"rss-reader/internal/domain"
 	"rss-reader/internal/repository"
 	"rss-reader/pkg/datetime"
-	"sort"
+	"rss-reader/pkg/favicon"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/mmcdole/gofeed"
 )
 
+const (
+	iconMaxAge = 7 * 24 * time.Hour
+	// refreshRunRetention is how long refresh runs are kept for the admin
+	// area.
+	refreshRunRetention = 30 * 24 * time.Hour
+)
+
+// NewItemListener is told about the items a refresh inserted for the first
+// time. Items carry their ID and feed name.
+type NewItemListener interface {
+	HandleNewItems(userID int, items []domain.FeedItem)
+}
+
 type FeedService struct {
-	feedRepository     repository.FeedRepository
-	feedItemRepository repository.FeedItemRepository
-	dateFormatter      *datetime.Formatter
-	lastCleanup        time.Time
-	cleanupMu          sync.Mutex
+	feedRepository       repository.FeedRepository
+	feedItemRepository   repository.FeedItemRepository
+	folderRepository     repository.FolderRepository
+	feedIconRepository   repository.FeedIconRepository
+	feedImportRepository repository.FeedImportRepository
+	refreshRunRepository repository.RefreshRunRepository
+	dateFormatter        *datetime.Formatter
+	faviconFetcher       *favicon.Fetcher
+	pageSize             int
+	newItemListeners     []NewItemListener
 }
 
 func NewFeedService(
 	feedRepository repository.FeedRepository,
 	feedItemRepository repository.FeedItemRepository,
+	folderRepository repository.FolderRepository,
+	feedIconRepository repository.FeedIconRepository,
+	feedImportRepository repository.FeedImportRepository,
+	refreshRunRepository repository.RefreshRunRepository,
 	dateFormatter *datetime.Formatter,
+	faviconFetcher *favicon.Fetcher,
+	pageSize int,
 ) *FeedService {
 	return &FeedService{
-		feedRepository:     feedRepository,
-		feedItemRepository: feedItemRepository,
-		dateFormatter:      dateFormatter,
+		feedRepository:       feedRepository,
+		feedItemRepository:   feedItemRepository,
+		folderRepository:     folderRepository,
+		feedIconRepository:   feedIconRepository,
+		feedImportRepository: feedImportRepository,
+		refreshRunRepository: refreshRunRepository,
+		dateFormatter:        dateFormatter,
+		faviconFetcher:       faviconFetcher,
+		pageSize:             pageSize,
 	}
 }
 
-func (s *FeedService) CreateFeed(name, url string, userID int) (*domain.Feed, error) {
+// AddNewItemListener registers a listener called after every refresh that
+// inserted items.
+func (s *FeedService) AddNewItemListener(listener NewItemListener) {
+	s.newItemListeners = append(s.newItemListeners, listener)
+}
+
+func (s *FeedService) CreateFeed(name, url, folder string, userID int) (*domain.Feed, error) {
 	feed := &domain.Feed{
 		Name:   name,
 		URL:    url,
@@ -53,7 +89,12 @@ func (s *FeedService) CreateFeed(name, url string, userID int) (*domain.Feed, er
 		return nil, domain.ErrFeedAlreadyExists
 	}
 
-	createdFeed, err := s.feedRepository.Create(name, url, userID)
+	folderID, err := s.resolveFolder(userID, folder)
+	if err != nil {
+		return nil, err
+	}
+
+	createdFeed, err := s.feedRepository.Create(name, url, folderID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create feed: %w", err)
 	}
@@ -61,6 +102,33 @@ func (s *FeedService) CreateFeed(name, url string, userID int) (*domain.Feed, er
 	return createdFeed, nil
 }
 
+// resolveFolder returns the ID of the named folder, creating it on first use.
+// A blank name means "no folder" and resolves to 0.
+func (s *FeedService) resolveFolder(userID int, name string) (int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, nil
+	}
+
+	folder, err := s.folderRepository.GetOrCreate(userID, name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve folder: %w", err)
+	}
+	return folder.ID, nil
+}
+
+func (s *FeedService) GetFoldersByUserID(userID int) ([]domain.Folder, error) {
+	folders, err := s.folderRepository.GetAllByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get folders: %w", err)
+	}
+	return folders, nil
+}
+
+func (s *FeedService) GetFolderByID(folderID, userID int) (*domain.Folder, error) {
+	return s.folderRepository.GetByID(folderID, userID)
+}
+
 func (s *FeedService) GetFeedsByUserID(userID int) ([]domain.Feed, error) {
 	feeds, err := s.feedRepository.GetAllByUserID(userID)
 	if err != nil {
@@ -77,7 +145,7 @@ func (s *FeedService) GetFeedByID(feedID, userID int) (*domain.Feed, error) {
 	return feed, nil
 }
 
-func (s *FeedService) UpdateFeed(feedID int, name, url string, userID int) error {
+func (s *FeedService) UpdateFeed(feedID int, name, url, folder string, userID int) error {
 	feed := &domain.Feed{
 		ID:     feedID,
 		Name:   name,
@@ -88,7 +156,12 @@ func (s *FeedService) UpdateFeed(feedID int, name, url string, userID int) error
 		return err
 	}
 
-	if err := s.feedRepository.Update(feedID, name, url, userID); err != nil {
+	folderID, err := s.resolveFolder(userID, folder)
+	if err != nil {
+		return err
+	}
+
+	if err := s.feedRepository.Update(feedID, name, url, folderID, userID); err != nil {
 		return fmt.Errorf("failed to update feed: %w", err)
 	}
 
@@ -102,23 +175,9 @@ func (s *FeedService) DeleteFeed(feedID, userID int) error {
 	return nil
 }
 
-func (s *FeedService) RefreshFeeds(userID int) (int, int, error) {
-	s.cleanupMu.Lock()
-	shouldCleanup := time.Since(s.lastCleanup) > 24*time.Hour
-	if shouldCleanup {
-		s.lastCleanup = time.Now()
-	}
-	s.cleanupMu.Unlock()
-
-	if shouldCleanup {
-		deleted, err := s.feedItemRepository.DeleteOlderThan(90)
-		if err != nil {
-			log.Printf("Warning: cleanup failed: %v", err)
-		} else if deleted > 0 {
-			log.Printf("Cleaned up %d old feed items (90+ days)", deleted)
-		}
-	}
-
+// RefreshFeeds fetches all of the user's feeds and records the run, with
+// trigger saying what started it.
+func (s *FeedService) RefreshFeeds(userID int, trigger string) (int, int, error) {
 	feeds, err := s.feedRepository.GetAllByUserID(userID)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to get feeds: %w", err)
@@ -126,9 +185,40 @@ func (s *FeedService) RefreshFeeds(userID int) (int, int, error) {
 
 	log.Printf("Refreshing %d feeds for user %d", len(feeds), userID)
 
+	run := &domain.RefreshRun{UserID: userID, Trigger: trigger}
+	s.refresh(run, feeds)
+
+	log.Printf("Feed refresh complete: processed %d items, %d new/updated", run.ItemsProcessed, run.ItemsNew)
+	return run.ItemsProcessed, run.ItemsNew, nil
+}
+
+// ForceRefreshFeed fetches a single feed of any user, for administrators.
+func (s *FeedService) ForceRefreshFeed(feedID int) (*domain.RefreshRun, error) {
+	feed, err := s.feedRepository.GetByIDForAdmin(feedID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Force-refreshing feed %d (%s) of user %d", feed.ID, feed.URL, feed.UserID)
+
+	run := &domain.RefreshRun{
+		UserID:   feed.UserID,
+		FeedID:   feed.ID,
+		FeedName: feed.Name,
+		Trigger:  domain.RefreshTriggerAdmin,
+	}
+	s.refresh(run, []domain.Feed{*feed})
+	return run, nil
+}
+
+// refresh fetches feeds, which all belong to run.UserID, records the
+// outcome of each, and fills in and stores run.
+func (s *FeedService) refresh(run *domain.RefreshRun, feeds []domain.Feed) {
+	run.FeedsTotal = len(feeds)
+	run.StartedAt = time.Now()
+
 	parser := gofeed.NewParser()
-	totalItems := 0
-	newItems := 0
+	var inserted []domain.FeedItem
 
 	for _, feed := range feeds {
 		log.Printf("Processing feed: %s (%s)", feed.Name, feed.URL)
@@ -136,13 +226,27 @@ func (s *FeedService) RefreshFeeds(userID int) (int, int, error) {
 		parsedFeed, err := parser.ParseURL(feed.URL)
 		if err != nil {
 			log.Printf("Error parsing feed %s (%s): %v", feed.Name, feed.URL, err)
+			run.FeedsFailed++
+			if err := s.feedRepository.MarkFailed(feed.ID, err.Error()); err != nil {
+				log.Printf("Warning: %v", err)
+			}
 			continue
 		}
 
 		log.Printf("Feed %s has %d items", feed.Name, len(parsedFeed.Items))
 
+		if err := s.feedRepository.MarkRefreshed(feed.ID, parsedFeed.Link); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+
+		imageURL := ""
+		if parsedFeed.Image != nil {
+			imageURL = parsedFeed.Image.URL
+		}
+		go s.refreshIcon(feed.ID, parsedFeed.Link, imageURL)
+
 		for _, item := range parsedFeed.Items {
-			totalItems++
+			run.ItemsProcessed++
 
 			publishedAt, _ := s.dateFormatter.ParseRSSDate(item.Published)
 
@@ -156,6 +260,7 @@ func (s *FeedService) RefreshFeeds(userID int) (int, int, error) {
 				Description: description,
 				Link:        item.Link,
 				FeedID:      feed.ID,
+				FeedName:    feed.Name,
 				PublishedAt: s.dateFormatter.NormalizeToUTC(publishedAt),
 			}
 
@@ -164,114 +269,359 @@ func (s *FeedService) RefreshFeeds(userID int) (int, int, error) {
 				continue
 			}
 
-			err = s.feedItemRepository.Create(feedItem)
+			isNew, err := s.feedItemRepository.Create(feedItem)
 			if err != nil {
 				log.Printf("Error creating feed item '%s': %v", item.Title, err)
 			} else {
-				newItems++
+				run.ItemsNew++
+				if isNew {
+					inserted = append(inserted, *feedItem)
+				}
 			}
 		}
 	}
 
-	log.Printf("Feed refresh complete: processed %d items, %d new/updated", totalItems, newItems)
-	return totalItems, newItems, nil
+	if len(inserted) > 0 {
+		for _, listener := range s.newItemListeners {
+			listener.HandleNewItems(run.UserID, inserted)
+		}
+	}
+
+	run.FinishedAt = time.Now()
+	s.recordRun(run)
 }
 
-type FeedItemGroup struct {
-	Date  string
-	Items []domain.FeedItem
+func (s *FeedService) recordRun(run *domain.RefreshRun) {
+	if err := s.refreshRunRepository.Create(run); err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+
+	if _, err := s.refreshRunRepository.DeleteBefore(time.Now().Add(-refreshRunRetention)); err != nil {
+		log.Printf("Warning: %v", err)
+	}
 }
 
-func (s *FeedService) GetFeedItemsGroupedByDate(userID int, daysOffset int) ([]FeedItemGroup, bool, []string, error) {
-	items, err := s.feedItemRepository.GetByUserIDPaginated(userID, daysOffset)
+// refreshIcon fetches a feed's icon when it has never been fetched or the
+// stored copy is older than iconMaxAge. Failures are stored as empty icons so
+// unreachable hosts are not retried on every refresh.
+func (s *FeedService) refreshIcon(feedID int, siteURL, imageURL string) {
+	icon, err := s.feedIconRepository.GetByFeedID(feedID)
+	if err == nil && time.Since(icon.FetchedAt) < iconMaxAge {
+		return
+	}
+	if err != nil && err != domain.ErrFeedIconNotFound {
+		log.Printf("Warning: %v", err)
+		return
+	}
+
+	mimeType, data, err := s.faviconFetcher.Fetch(siteURL, imageURL)
 	if err != nil {
-		return nil, false, nil, fmt.Errorf("failed to get feed items: %w", err)
+		log.Printf("Could not fetch icon for feed %d: %v", feedID, err)
+	}
+
+	if err := s.feedIconRepository.Upsert(&domain.FeedIcon{FeedID: feedID, MimeType: mimeType, Data: data}); err != nil {
+		log.Printf("Warning: %v", err)
 	}
+}
 
-	hasMore, err := s.feedItemRepository.HasMoreItems(userID, daysOffset)
+func (s *FeedService) GetFeedIcons(userID int) ([]domain.FeedIcon, error) {
+	icons, err := s.feedIconRepository.GetAllByUserID(userID)
 	if err != nil {
-		log.Printf("Error checking for more items: %v", err)
-		hasMore = false
+		return nil, fmt.Errorf("failed to get feed icons: %w", err)
 	}
+	return icons, nil
+}
+
+type FeedItemGroup struct {
+	Key     string
+	Heading string
+	Items   []domain.FeedItem
+}
+
+type FeedItemPage struct {
+	DateGroups []FeedItemGroup
+	HasMore    bool
+	NextCursor string
+}
 
-	feedNamesMap := make(map[string]bool)
-	for _, item := range items {
-		feedNamesMap[item.FeedName] = true
+// GetFeedItemsGroupedByDate returns one page of items matching filter after
+// cursor (nil for the first page). Items are grouped by local publication
+// date, or by feed when sorting by feed, keeping the query's order.
+func (s *FeedService) GetFeedItemsGroupedByDate(userID int, filter domain.ItemFilter, cursor *domain.ItemCursor) (*FeedItemPage, error) {
+	items, nextCursor, err := s.ListItems(userID, filter, cursor, s.pageSize)
+	if err != nil {
+		return nil, err
 	}
 
-	var feedNames []string
-	for feedName := range feedNamesMap {
-		feedNames = append(feedNames, feedName)
+	page := &FeedItemPage{
+		HasMore:    nextCursor != "",
+		NextCursor: nextCursor,
 	}
-	sort.Strings(feedNames)
 
-	groupedItems := make(map[string][]domain.FeedItem)
-	dateDisplayMap := make(map[string]string)
+	byFeed := filter.SortOrder() == domain.ItemSortFeed
 
 	for i, item := range items {
 		localTime := item.PublishedAt.Local()
 		items[i].PublishedAt = localTime
 
 		groupKey := s.dateFormatter.FormatForGrouping(localTime)
-		displayDate := s.dateFormatter.FormatForDisplay(localTime)
+		heading := s.dateFormatter.FormatForDisplay(localTime)
+		if byFeed {
+			groupKey = fmt.Sprintf("feed-%d", item.FeedID)
+			heading = item.FeedName
+		}
+
+		last := len(page.DateGroups) - 1
+		if last >= 0 && page.DateGroups[last].Key == groupKey {
+			page.DateGroups[last].Items = append(page.DateGroups[last].Items, items[i])
+			continue
+		}
 
-		groupedItems[groupKey] = append(groupedItems[groupKey], items[i])
-		dateDisplayMap[groupKey] = displayDate
+		page.DateGroups = append(page.DateGroups, FeedItemGroup{
+			Key:     groupKey,
+			Heading: heading,
+			Items:   []domain.FeedItem{items[i]},
+		})
 	}
 
-	var dates []string
-	for date := range groupedItems {
-		dates = append(dates, date)
+	if cursor == nil {
+		if err := s.feedItemRepository.MarkAllAsOld(userID); err != nil {
+			log.Printf("Warning: failed to mark items as old: %v", err)
+		}
 	}
-	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
 
-	var orderedGroups []FeedItemGroup
-	for _, date := range dates {
-		displayDate := dateDisplayMap[date]
-		orderedGroups = append(orderedGroups, FeedItemGroup{
-			Date:  displayDate,
-			Items: groupedItems[date],
-		})
+	return page, nil
+}
+
+// ListItems returns up to limit items matching filter after cursor, plus the
+// encoded cursor for the next page, which is empty when nothing is left.
+func (s *FeedService) ListItems(userID int, filter domain.ItemFilter, cursor *domain.ItemCursor, limit int) ([]domain.FeedItem, string, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, "", err
+	}
+	if limit <= 0 {
+		limit = s.pageSize
+	}
+
+	items, err := s.feedItemRepository.GetPageByUserID(userID, filter, cursor, limit)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get feed items: %w", err)
 	}
 
-	if err := s.feedItemRepository.MarkAllAsOld(userID); err != nil {
-		log.Printf("Warning: failed to mark items as old: %v", err)
+	if len(items) == 0 {
+		return items, "", nil
+	}
+
+	next := domain.NewItemCursor(items[len(items)-1])
+	hasMore, err := s.feedItemRepository.HasMoreItems(userID, filter, next)
+	if err != nil {
+		log.Printf("Error checking for more items: %v", err)
+		hasMore = false
 	}
 
-	return orderedGroups, hasMore, feedNames, nil
+	if !hasMore {
+		return items, "", nil
+	}
+	return items, next.Encode(), nil
 }
 
-func (s *FeedService) ImportFeeds(userID int, feeds []struct{ Name, URL string }) (int, []string) {
-	successCount := 0
-	var errors []string
+func (s *FeedService) SetItemRead(itemID, userID int, read bool) error {
+	if err := s.feedItemRepository.SetRead(itemID, userID, read); err != nil {
+		return fmt.Errorf("failed to update read state: %w", err)
+	}
+	return nil
+}
 
-	for _, feedData := range feeds {
-		if feedData.Name == "" || feedData.URL == "" {
-			errors = append(errors, "Feed missing name or URL")
-			continue
-		}
+func (s *FeedService) SetItemStarred(itemID, userID int, starred bool) error {
+	if err := s.feedItemRepository.SetStarred(itemID, userID, starred); err != nil {
+		return fmt.Errorf("failed to update starred state: %w", err)
+	}
+	return nil
+}
 
-		exists, err := s.feedRepository.ExistsByURL(userID, feedData.URL)
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("Error checking feed %s: %v", feedData.Name, err))
+func (s *FeedService) GetItemsByIDs(userID int, ids []int) ([]domain.FeedItem, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	items, err := s.feedItemRepository.GetByIDs(userID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed items: %w", err)
+	}
+	return items, nil
+}
+
+func (s *FeedService) GetItemsSinceID(userID, sinceID, limit int) ([]domain.FeedItem, error) {
+	items, err := s.feedItemRepository.GetSinceID(userID, sinceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed items: %w", err)
+	}
+	return items, nil
+}
+
+func (s *FeedService) GetItemsBeforeID(userID, maxID, limit int) ([]domain.FeedItem, error) {
+	items, err := s.feedItemRepository.GetBeforeID(userID, maxID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed items: %w", err)
+	}
+	return items, nil
+}
+
+// GetItemIDs returns the IDs of all items with the given status
+// (domain.ItemStatusUnread, domain.ItemStatusStarred or all).
+func (s *FeedService) GetItemIDs(userID int, status string) ([]int, error) {
+	ids, err := s.feedItemRepository.GetIDsByStatus(userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed item IDs: %w", err)
+	}
+	return ids, nil
+}
+
+func (s *FeedService) CountItems(userID int) (int, error) {
+	count, err := s.feedItemRepository.CountByUserID(userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count feed items: %w", err)
+	}
+	return count, nil
+}
+
+func (s *FeedService) GetUnreadCounts(userID int) ([]domain.UnreadCount, error) {
+	counts, err := s.feedItemRepository.GetUnreadCounts(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unread counts: %w", err)
+	}
+	return counts, nil
+}
+
+// MarkReadBefore marks items added before the given time as read, limited to
+// one feed or folder when feedID or folderID is non-zero.
+func (s *FeedService) MarkReadBefore(userID, feedID, folderID int, before time.Time) (int64, error) {
+	marked, err := s.feedItemRepository.MarkReadBefore(userID, feedID, folderID, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark items as read: %w", err)
+	}
+	return marked, nil
+}
+
+// PreviewImport classifies uploaded entries as new, duplicate or invalid
+// without writing anything. Duplicates are matched on the normalized URL, so
+// http/https, "www." and trailing-slash variants of a subscription count, as
+// do repeats within the file itself.
+func (s *FeedService) PreviewImport(userID int, entries []domain.FeedImportEntry) (*domain.FeedImportPreview, error) {
+	existing, err := s.feedRepository.GetAllByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feeds: %w", err)
+	}
+
+	known := make(map[string]domain.Feed, len(existing))
+	for _, feed := range existing {
+		known[normalizeFeedURL(feed.URL)] = feed
+	}
+	inFile := make(map[string]domain.FeedImportEntry)
+
+	preview := &domain.FeedImportPreview{}
+	for _, entry := range entries {
+		entry.Name = strings.TrimSpace(entry.Name)
+		entry.URL = strings.TrimSpace(entry.URL)
+		entry.Folder = strings.TrimSpace(entry.Folder)
+		previewEntry := domain.FeedImportPreviewEntry{FeedImportEntry: entry}
+
+		if reason := validateImportURL(entry.URL); reason != "" {
+			previewEntry.Status = domain.FeedImportInvalid
+			previewEntry.Reason = reason
+			preview.Invalid++
+			preview.Entries = append(preview.Entries, previewEntry)
 			continue
 		}
+		if previewEntry.Name == "" {
+			previewEntry.Name = entry.URL
+		}
 
-		if exists {
-			errors = append(errors, fmt.Sprintf("Feed already exists: %s", feedData.Name))
-			continue
+		key := normalizeFeedURL(entry.URL)
+		if feed, ok := known[key]; ok {
+			previewEntry.Status = domain.FeedImportDuplicate
+			previewEntry.ExistingFeedID = feed.ID
+			previewEntry.ExistingName = feed.Name
+			if feed.URL == entry.URL {
+				previewEntry.Reason = "already subscribed"
+			} else {
+				previewEntry.Reason = "same feed as " + feed.URL
+			}
+			preview.Duplicates++
+		} else if first, ok := inFile[key]; ok {
+			previewEntry.Status = domain.FeedImportDuplicate
+			previewEntry.ExistingName = first.Name
+			previewEntry.Reason = fmt.Sprintf("repeats line %d", first.Line)
+			preview.Duplicates++
+		} else {
+			previewEntry.Status = domain.FeedImportNew
+			inFile[key] = previewEntry.FeedImportEntry
+			preview.New++
 		}
 
-		_, err = s.feedRepository.Create(feedData.Name, feedData.URL, userID)
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("Error creating feed %s: %v", feedData.Name, err))
-			continue
+		preview.Entries = append(preview.Entries, previewEntry)
+	}
+
+	return preview, nil
+}
+
+// CommitImport validates the user's decisions and applies them in one
+// transaction.
+func (s *FeedService) CommitImport(userID int, decisions []domain.FeedImportDecision) (*domain.FeedImportReport, error) {
+	for i := range decisions {
+		decision := &decisions[i]
+		decision.Entry.Name = strings.TrimSpace(decision.Entry.Name)
+		decision.Entry.URL = strings.TrimSpace(decision.Entry.URL)
+		decision.Entry.Folder = strings.TrimSpace(decision.Entry.Folder)
+
+		if err := decision.Validate(); err != nil {
+			return nil, err
+		}
+		if decision.Action != domain.FeedImportActionSkip && validateImportURL(decision.Entry.URL) != "" {
+			return nil, domain.ErrInvalidFeedURL
 		}
+	}
 
-		successCount++
+	report, err := s.feedImportRepository.Apply(userID, decisions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import feeds: %w", err)
 	}
 
-	return successCount, errors
+	log.Printf("Import for user %d: %d added, %d renamed, %d overwritten, %d skipped",
+		userID, report.Added, report.Renamed, report.Overwritten, report.Skipped)
+	return report, nil
+}
+
+func validateImportURL(rawURL string) string {
+	if rawURL == "" {
+		return "missing feed URL"
+	}
+	if !domain.IsHTTPURL(rawURL) {
+		return "not an http or https URL"
+	}
+	return ""
+}
+
+// normalizeFeedURL reduces a feed URL to the parts that identify the feed:
+// scheme, "www.", default ports, trailing slashes and fragments are dropped
+// and the host is lower-cased.
+func normalizeFeedURL(rawURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsed.Host == "" {
+		return strings.TrimSpace(rawURL)
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	host = strings.TrimPrefix(host, "www.")
+	if port := parsed.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	normalized := host + strings.TrimRight(parsed.EscapedPath(), "/")
+	if parsed.RawQuery != "" {
+		normalized += "?" + parsed.RawQuery
+	}
+	return normalized
 }
 
 func (s *FeedService) ExportFeeds(userID int) ([]domain.Feed, error) {
@@ -291,4 +641,4 @@ func stripHTMLTags(html string) string {
 	text := doc.Text()
 	text = strings.Join(strings.Fields(text), " ")
 	return strings.TrimSpace(text)
-}
\ No newline at end of file
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/repository"
+	"rss-reader/pkg/security"
+	"strings"
+)
+
+const minFeverPasswordLength = 8
+
+// FeverService manages per-user Fever API keys. The key a Fever client sends
+// is md5("email:password"); only a SHA-256 of that key is stored.
+type FeverService struct {
+	userRepository            repository.UserRepository
+	feverCredentialRepository repository.FeverCredentialRepository
+	mailer                    *Mailer
+}
+
+func NewFeverService(
+	userRepository repository.UserRepository,
+	feverCredentialRepository repository.FeverCredentialRepository,
+	mailer *Mailer,
+) *FeverService {
+	return &FeverService{
+		userRepository:            userRepository,
+		feverCredentialRepository: feverCredentialRepository,
+		mailer:                    mailer,
+	}
+}
+
+func (s *FeverService) SetPassword(userID int, password string) error {
+	if len(password) < minFeverPasswordLength {
+		return domain.ErrWeakPassword
+	}
+
+	user, err := s.userRepository.GetByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := s.feverCredentialRepository.Upsert(userID, security.HashToken(feverAPIKey(user.Email, password))); err != nil {
+		return fmt.Errorf("failed to set Fever password: %w", err)
+	}
+
+	log.Printf("Fever API enabled for user %d", userID)
+	s.mailer.SendSecurityNotice(userID, SecurityEventFeverPasswordSet, "")
+	return nil
+}
+
+func (s *FeverService) Disable(userID int) error {
+	if err := s.feverCredentialRepository.Delete(userID); err != nil {
+		return fmt.Errorf("failed to disable Fever API: %w", err)
+	}
+	return nil
+}
+
+func (s *FeverService) IsEnabled(userID int) (bool, error) {
+	enabled, err := s.feverCredentialRepository.ExistsForUser(userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check Fever API: %w", err)
+	}
+	return enabled, nil
+}
+
+// Authenticate resolves a Fever api_key to its owner's user ID.
+func (s *FeverService) Authenticate(apiKey string) (int, error) {
+	apiKey = strings.ToLower(strings.TrimSpace(apiKey))
+	if apiKey == "" {
+		return 0, domain.ErrInvalidAPIToken
+	}
+
+	userID, err := s.feverCredentialRepository.GetUserIDByKeyHash(security.HashToken(apiKey))
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+func feverAPIKey(email, password string) string {
+	sum := md5.Sum([]byte(email + ":" + password))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/repository"
+	"rss-reader/pkg/email"
+	"time"
+)
+
+// Transactional email types. Each has an html and a txt template per locale
+// under templates/email.
+const (
+	EmailOTP            = "otp"
+	EmailDigest         = "digest"
+	EmailAlert          = "alert"
+	EmailSecurityNotice = "security_notice"
+)
+
+// Security notice events, rendered by the security_notice templates.
+const (
+	SecurityEventAPITokenCreated  = "api_token_created"
+	SecurityEventFeverPasswordSet = "fever_password_set"
+	SecurityEventPasskeyAdded     = "passkey_added"
+	SecurityEventPasskeyRemoved   = "passkey_removed"
+	SecurityEventTOTPEnabled      = "totp_enabled"
+	SecurityEventTOTPDisabled     = "totp_disabled"
+)
+
+// Mailer renders transactional emails in the recipient's language and
+// hands them to the configured email backend.
+type Mailer struct {
+	emailService   email.Service
+	templates      *email.Templates
+	userRepository repository.UserRepository
+}
+
+func NewMailer(
+	emailService email.Service,
+	templates *email.Templates,
+	userRepository repository.UserRepository,
+) *Mailer {
+	return &Mailer{
+		emailService:   emailService,
+		templates:      templates,
+		userRepository: userRepository,
+	}
+}
+
+// SendToUser sends a message of the given type in the user's locale.
+// Disabled users are skipped.
+func (m *Mailer) SendToUser(user *domain.User, messageType string, data interface{}) error {
+	if user.IsDisabled() {
+		log.Printf("Not sending %s email to disabled user %d", messageType, user.ID)
+		return nil
+	}
+
+	message, err := m.templates.Render(user.Locale, messageType, data)
+	if err != nil {
+		return err
+	}
+
+	message.To = user.Email
+	return m.emailService.Send(message)
+}
+
+// SendSecurityNotice tells the user about a change to how their account can
+// be accessed. It sends in the background and only logs failures, since the
+// change itself has already happened.
+func (m *Mailer) SendSecurityNotice(userID int, event, detail string) {
+	go func() {
+		user, err := m.userRepository.GetByID(userID)
+		if err != nil {
+			log.Printf("Warning: security notice %s for user %d not sent: %v", event, userID, err)
+			return
+		}
+
+		err = m.SendToUser(user, EmailSecurityNotice, map[string]interface{}{
+			"Event":  event,
+			"Detail": detail,
+			"Time":   time.Now().UTC(),
+		})
+		if err != nil {
+			log.Printf("Warning: security notice %s for user %d not sent: %v", event, userID, err)
+		}
+	}()
+}
+
+// Locales lists the languages emails can be sent in.
+func (m *Mailer) Locales() []email.Locale {
+	return m.templates.Locales()
+}
+
+// DefaultLocale is the language used for users who have not chosen one.
+func (m *Mailer) DefaultLocale() string {
+	return m.templates.DefaultLocale()
+}
+
+// UserLocale returns the user's chosen locale, empty for the default.
+func (m *Mailer) UserLocale(userID int) (string, error) {
+	user, err := m.userRepository.GetByID(userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	return user.Locale, nil
+}
+
+// SetUserLocale changes the language of the user's emails. An empty locale
+// resets it to the instance default.
+func (m *Mailer) SetUserLocale(userID int, locale string) error {
+	locale = email.NormalizeLocale(locale)
+	if locale != "" && !m.templates.HasLocale(locale) {
+		return domain.ErrInvalidLocale
+	}
+	return m.userRepository.UpdateLocale(userID, locale)
+}
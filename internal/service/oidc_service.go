@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"rss-reader/internal/domain"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const oidcRequestTimeout = 10 * time.Second
+
+// OIDCConfig identifies this application to an OpenID Connect provider.
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// TrustUnverifiedEmail accepts email addresses the provider does not
+	// mark as verified, for providers that never send email_verified.
+	TrustUnverifiedEmail bool
+}
+
+// OIDCAuthRequest holds the values a login must be completed with. The
+// caller keeps it in the browser's session between BeginLogin and
+// FinishLogin.
+type OIDCAuthRequest struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// OIDCService signs users in through an OpenID Connect provider using the
+// authorization code flow with PKCE. The provider's discovery document is
+// fetched on first use rather than at startup, so a provider outage only
+// affects single sign-on.
+type OIDCService struct {
+	config     OIDCConfig
+	httpClient *http.Client
+
+	mutex    sync.Mutex
+	provider *oidc.Provider
+}
+
+func NewOIDCService(config OIDCConfig) *OIDCService {
+	return &OIDCService{
+		config:     config,
+		httpClient: &http.Client{Timeout: oidcRequestTimeout},
+	}
+}
+
+// BeginLogin returns the provider URL to send the browser to.
+func (s *OIDCService) BeginLogin(ctx context.Context) (string, *OIDCAuthRequest, error) {
+	provider, err := s.discover(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	state, err := randomOIDCValue()
+	if err != nil {
+		return "", nil, err
+	}
+	nonce, err := randomOIDCValue()
+	if err != nil {
+		return "", nil, err
+	}
+
+	request := &OIDCAuthRequest{
+		State:    state,
+		Nonce:    nonce,
+		Verifier: oauth2.GenerateVerifier(),
+	}
+	authURL := s.oauth2Config(provider).AuthCodeURL(
+		request.State,
+		oidc.Nonce(request.Nonce),
+		oauth2.S256ChallengeOption(request.Verifier),
+	)
+	return authURL, request, nil
+}
+
+// FinishLogin exchanges the code from the provider's redirect and returns
+// the signed-in user's email address, lowercased, once the ID token's
+// signature, issuer, audience, expiry and nonce have been checked.
+func (s *OIDCService) FinishLogin(ctx context.Context, request OIDCAuthRequest, state, code string) (string, error) {
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(request.State)) != 1 {
+		log.Printf("OIDC login rejected: state mismatch")
+		return "", domain.ErrOIDCLogin
+	}
+
+	provider, err := s.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	ctx = oidc.ClientContext(ctx, s.httpClient)
+	token, err := s.oauth2Config(provider).Exchange(ctx, code, oauth2.VerifierOption(request.Verifier))
+	if err != nil {
+		log.Printf("OIDC code exchange failed: %v", err)
+		return "", domain.ErrOIDCLogin
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		log.Printf("OIDC token response has no ID token")
+		return "", domain.ErrOIDCLogin
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: s.config.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		log.Printf("OIDC ID token rejected: %v", err)
+		return "", domain.ErrOIDCLogin
+	}
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(request.Nonce)) != 1 {
+		log.Printf("OIDC ID token rejected: nonce mismatch")
+		return "", domain.ErrOIDCLogin
+	}
+
+	var claims struct {
+		Email         string      `json:"email"`
+		EmailVerified interface{} `json:"email_verified"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		log.Printf("OIDC ID token claims unreadable: %v", err)
+		return "", domain.ErrOIDCLogin
+	}
+	if claims.Email == "" {
+		log.Printf("OIDC ID token for subject %s has no email claim", idToken.Subject)
+		return "", domain.ErrOIDCLogin
+	}
+
+	// Accounts are matched by email, so an address the provider has not
+	// verified could take over someone else's account. A missing claim
+	// counts as unverified; some providers send it as a string.
+	verified := false
+	switch value := claims.EmailVerified.(type) {
+	case bool:
+		verified = value
+	case string:
+		verified = value == "true"
+	}
+	if !verified && !s.config.TrustUnverifiedEmail {
+		log.Printf("OIDC ID token for subject %s has no verified email", idToken.Subject)
+		return "", domain.ErrOIDCEmailUnverified
+	}
+
+	return strings.ToLower(claims.Email), nil
+}
+
+func (s *OIDCService) discover(ctx context.Context) (*oidc.Provider, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.provider != nil {
+		return s.provider, nil
+	}
+
+	ctx, cancel := context.WithTimeout(oidc.ClientContext(ctx, s.httpClient), oidcRequestTimeout)
+	defer cancel()
+
+	provider, err := oidc.NewProvider(ctx, s.config.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider %s: %w", s.config.Issuer, err)
+	}
+
+	s.provider = provider
+	return provider, nil
+}
+
+func (s *OIDCService) oauth2Config(provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     s.config.ClientID,
+		ClientSecret: s.config.ClientSecret,
+		RedirectURL:  s.config.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       s.config.Scopes,
+	}
+}
+
+func randomOIDCValue() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
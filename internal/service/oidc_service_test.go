@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"rss-reader/internal/domain"
+	"rss-reader/pkg/idp"
+	"strings"
+	"testing"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+const (
+	testOIDCClientID     = "feedstream"
+	testOIDCClientSecret = "mock-secret"
+	testOIDCRedirectURL  = "http://feedstream.test/login/oidc/callback"
+)
+
+// newMockIdP serves an idp.MockProvider whose issuer is the test server's
+// own URL.
+func newMockIdP(t *testing.T) *httptest.Server {
+	t.Helper()
+	var provider *idp.MockProvider
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provider.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	var err error
+	provider, err = idp.NewMockProvider(server.URL, testOIDCClientID, testOIDCClientSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func newTestOIDCService(issuer string, trustUnverifiedEmail bool) *OIDCService {
+	return NewOIDCService(OIDCConfig{
+		Issuer:               issuer,
+		ClientID:             testOIDCClientID,
+		ClientSecret:         testOIDCClientSecret,
+		RedirectURL:          testOIDCRedirectURL,
+		Scopes:               []string{oidc.ScopeOpenID, "email"},
+		TrustUnverifiedEmail: trustUnverifiedEmail,
+	})
+}
+
+// signInAtMockIdP starts a login and submits the mock provider's sign-in
+// form, returning the pending request and the state and code from the
+// redirect back to the application.
+func signInAtMockIdP(t *testing.T, s *OIDCService, form url.Values) (*OIDCAuthRequest, string, string) {
+	t.Helper()
+	authURL, request, err := s.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	response, err := client.PostForm(authURL, form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusFound {
+		t.Fatalf("authorize returned %d, want %d", response.StatusCode, http.StatusFound)
+	}
+
+	location, err := url.Parse(response.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(location.String(), testOIDCRedirectURL) {
+		t.Fatalf("redirected to %s, want %s", location, testOIDCRedirectURL)
+	}
+	return request, location.Query().Get("state"), location.Query().Get("code")
+}
+
+func TestOIDCFinishLogin(t *testing.T) {
+	server := newMockIdP(t)
+
+	tests := []struct {
+		name      string
+		form      url.Values
+		trust     bool
+		tamper    func(request *OIDCAuthRequest, state *string)
+		wantEmail string
+		wantErr   error
+	}{
+		{
+			name:      "verified email",
+			form:      url.Values{"email": {"Reader@Example.com"}},
+			wantEmail: "reader@example.com",
+		},
+		{
+			name:    "state mismatch",
+			form:    url.Values{"email": {"reader@example.com"}},
+			tamper:  func(_ *OIDCAuthRequest, state *string) { *state = "forged" },
+			wantErr: domain.ErrOIDCLogin,
+		},
+		{
+			name:    "missing state",
+			form:    url.Values{"email": {"reader@example.com"}},
+			tamper:  func(_ *OIDCAuthRequest, state *string) { *state = "" },
+			wantErr: domain.ErrOIDCLogin,
+		},
+		{
+			name:    "nonce mismatch",
+			form:    url.Values{"email": {"reader@example.com"}},
+			tamper:  func(request *OIDCAuthRequest, _ *string) { request.Nonce = "another-login" },
+			wantErr: domain.ErrOIDCLogin,
+		},
+		{
+			name:    "wrong PKCE verifier",
+			form:    url.Values{"email": {"reader@example.com"}},
+			tamper:  func(request *OIDCAuthRequest, _ *string) { request.Verifier = "not-the-verifier" },
+			wantErr: domain.ErrOIDCLogin,
+		},
+		{
+			name:    "unverified email",
+			form:    url.Values{"email": {"reader@example.com"}, "unverified": {"1"}},
+			wantErr: domain.ErrOIDCEmailUnverified,
+		},
+		{
+			name:    "missing email_verified",
+			form:    url.Values{"email": {"reader@example.com"}, "omit_verified": {"1"}},
+			wantErr: domain.ErrOIDCEmailUnverified,
+		},
+		{
+			name:      "unverified email trusted",
+			form:      url.Values{"email": {"reader@example.com"}, "unverified": {"1"}},
+			trust:     true,
+			wantEmail: "reader@example.com",
+		},
+		{
+			name:      "missing email_verified trusted",
+			form:      url.Values{"email": {"reader@example.com"}, "omit_verified": {"1"}},
+			trust:     true,
+			wantEmail: "reader@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestOIDCService(server.URL, tt.trust)
+			request, state, code := signInAtMockIdP(t, s, tt.form)
+			if tt.tamper != nil {
+				tt.tamper(request, &state)
+			}
+
+			email, err := s.FinishLogin(context.Background(), *request, state, code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FinishLogin returned %v, want %v", err, tt.wantErr)
+			}
+			if email != tt.wantEmail {
+				t.Errorf("FinishLogin returned %q, want %q", email, tt.wantEmail)
+			}
+		})
+	}
+}
+
+func TestOIDCCodeIsSingleUse(t *testing.T) {
+	s := newTestOIDCService(newMockIdP(t).URL, false)
+	request, state, code := signInAtMockIdP(t, s, url.Values{"email": {"reader@example.com"}})
+
+	if _, err := s.FinishLogin(context.Background(), *request, state, code); err != nil {
+		t.Fatalf("first exchange: %v", err)
+	}
+	if _, err := s.FinishLogin(context.Background(), *request, state, code); !errors.Is(err, domain.ErrOIDCLogin) {
+		t.Errorf("second exchange: got %v, want %v", err, domain.ErrOIDCLogin)
+	}
+}
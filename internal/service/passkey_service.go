@@ -0,0 +1,319 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+const (
+	defaultPasskeyName  = "Passkey"
+	userHandleLength    = 32
+	passkeyCeremonyTime = 5 * time.Minute
+)
+
+// PasskeyService registers WebAuthn passkeys for signed-in users and signs
+// users in with them. Passkeys are discoverable, so the login page needs no
+// email address; email OTP stays available for users who lose theirs.
+type PasskeyService struct {
+	passkeyRepository repository.PasskeyRepository
+	userRepository    repository.UserRepository
+	mailer            *Mailer
+	webAuthn          *webauthn.WebAuthn
+}
+
+// NewPasskeyService configures the relying party from the application URL.
+// Passkeys are bound to its host name, so they stop working if APP_URL
+// moves to another domain.
+func NewPasskeyService(
+	passkeyRepository repository.PasskeyRepository,
+	userRepository repository.UserRepository,
+	mailer *Mailer,
+	appURL string,
+	productName string,
+) (*PasskeyService, error) {
+	origin, err := url.Parse(appURL)
+	if err != nil || origin.Hostname() == "" {
+		return nil, fmt.Errorf("passkeys need a valid APP_URL, got %q", appURL)
+	}
+
+	timeout := webauthn.TimeoutConfig{Enforce: true, Timeout: passkeyCeremonyTime, TimeoutUVD: passkeyCeremonyTime}
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          origin.Hostname(),
+		RPDisplayName: productName,
+		RPOrigins:     []string{origin.Scheme + "://" + origin.Host},
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		},
+		Timeouts: webauthn.TimeoutsConfig{Login: timeout, Registration: timeout},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure WebAuthn: %w", err)
+	}
+
+	return &PasskeyService{
+		passkeyRepository: passkeyRepository,
+		userRepository:    userRepository,
+		mailer:            mailer,
+		webAuthn:          webAuthn,
+	}, nil
+}
+
+func (s *PasskeyService) GetPasskeys(userID int) ([]domain.Passkey, error) {
+	passkeys, err := s.passkeyRepository.GetAllByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passkeys: %w", err)
+	}
+	return passkeys, nil
+}
+
+// BeginRegistration returns the options for navigator.credentials.create and
+// the session data to keep until FinishRegistration.
+func (s *PasskeyService) BeginRegistration(userID int) (*protocol.CredentialCreation, *webauthn.SessionData, error) {
+	user, err := s.loadUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Excluding existing credentials stops an authenticator from being
+	// registered twice.
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.passkeys))
+	for _, credential := range user.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin passkey registration: %w", err)
+	}
+	return creation, session, nil
+}
+
+// FinishRegistration verifies the browser's response and stores the new
+// passkey under name.
+func (s *PasskeyService) FinishRegistration(userID int, name string, session webauthn.SessionData, response io.Reader) (*domain.Passkey, error) {
+	user, err := s.loadUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(response)
+	if err != nil {
+		log.Printf("Invalid passkey registration response for user %d: %v", userID, err)
+		return nil, domain.ErrPasskeyVerification
+	}
+
+	credential, err := s.webAuthn.CreateCredential(user, session, parsed)
+	if err != nil {
+		log.Printf("Passkey registration failed for user %d: %v", userID, describeWebAuthnError(err))
+		return nil, domain.ErrPasskeyVerification
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = defaultPasskeyName
+	}
+
+	passkey := &domain.Passkey{
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+	for _, transport := range credential.Transport {
+		passkey.Transports = append(passkey.Transports, string(transport))
+	}
+	if err := passkey.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.passkeyRepository.Create(passkey); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Registered passkey %d for user %d", passkey.ID, userID)
+	s.mailer.SendSecurityNotice(userID, SecurityEventPasskeyAdded, passkey.Name)
+	return passkey, nil
+}
+
+// BeginLogin returns the options for navigator.credentials.get. No user is
+// named: the browser offers whichever passkeys it holds for this site.
+func (s *PasskeyService) BeginLogin() (*protocol.CredentialAssertion, *webauthn.SessionData, error) {
+	assertion, session, err := s.webAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin passkey login: %w", err)
+	}
+	return assertion, session, nil
+}
+
+// FinishLogin verifies a signed challenge and returns the passkey's owner.
+func (s *PasskeyService) FinishLogin(session webauthn.SessionData, response io.Reader) (*domain.User, error) {
+	parsed, err := protocol.ParseCredentialRequestResponseBody(response)
+	if err != nil {
+		log.Printf("Invalid passkey login response: %v", err)
+		return nil, domain.ErrPasskeyVerification
+	}
+
+	var owner *passkeyUser
+	credential, err := s.webAuthn.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := s.passkeyRepository.GetUserIDByHandle(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		owner, err = s.loadUser(userID)
+		return owner, err
+	}, session, parsed)
+	if err != nil {
+		log.Printf("Passkey login failed: %v", describeWebAuthnError(err))
+		return nil, domain.ErrPasskeyVerification
+	}
+
+	// A counter that did not increase means two authenticators hold the same
+	// key; refuse rather than guess which one is genuine.
+	if credential.Authenticator.CloneWarning {
+		log.Printf("Passkey login refused for user %d: signature counter did not increase", owner.user.ID)
+		return nil, domain.ErrPasskeyVerification
+	}
+
+	passkey := owner.passkey(credential.ID)
+	if passkey == nil {
+		return nil, domain.ErrPasskeyVerification
+	}
+	if owner.user.IsDisabled() {
+		log.Printf("Passkey login refused for disabled user %d", owner.user.ID)
+		return nil, domain.ErrUserDisabled
+	}
+	if err := s.passkeyRepository.RecordUse(passkey.ID, credential.Authenticator.SignCount, credential.Flags.BackupState, time.Now()); err != nil {
+		log.Printf("Warning: failed to record use of passkey %d: %v", passkey.ID, err)
+	}
+
+	log.Printf("User %s authenticated with passkey %d", owner.user.Email, passkey.ID)
+	return owner.user, nil
+}
+
+func (s *PasskeyService) DeletePasskey(passkeyID, userID int) error {
+	passkeys, err := s.passkeyRepository.GetAllByUserID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get passkeys: %w", err)
+	}
+
+	var name string
+	for _, passkey := range passkeys {
+		if passkey.ID == passkeyID {
+			name = passkey.Name
+		}
+	}
+
+	if err := s.passkeyRepository.Delete(passkeyID, userID); err != nil {
+		return err
+	}
+
+	s.mailer.SendSecurityNotice(userID, SecurityEventPasskeyRemoved, name)
+	return nil
+}
+
+func (s *PasskeyService) loadUser(userID int) (*passkeyUser, error) {
+	user, err := s.userRepository.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	handle := make([]byte, userHandleLength)
+	if _, err := rand.Read(handle); err != nil {
+		return nil, fmt.Errorf("failed to generate user handle: %w", err)
+	}
+	handle, err = s.passkeyRepository.GetOrCreateUserHandle(userID, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	passkeys, err := s.passkeyRepository.GetAllByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passkeys: %w", err)
+	}
+
+	return &passkeyUser{user: user, handle: handle, passkeys: passkeys}, nil
+}
+
+// describeWebAuthnError includes the library's detail, which its Error()
+// leaves out, in log messages.
+func describeWebAuthnError(err error) string {
+	if protocolErr, ok := err.(*protocol.Error); ok && protocolErr.DevInfo != "" {
+		return protocolErr.Error() + ": " + protocolErr.DevInfo
+	}
+	return err.Error()
+}
+
+// passkeyUser adapts a user and their passkeys to webauthn.User.
+type passkeyUser struct {
+	user     *domain.User
+	handle   []byte
+	passkeys []domain.Passkey
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	return u.handle
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, len(u.passkeys))
+	for i, passkey := range u.passkeys {
+		transports := make([]protocol.AuthenticatorTransport, len(passkey.Transports))
+		for j, transport := range passkey.Transports {
+			transports[j] = protocol.AuthenticatorTransport(transport)
+		}
+
+		credentials[i] = webauthn.Credential{
+			ID:              passkey.CredentialID,
+			PublicKey:       passkey.PublicKey,
+			AttestationType: passkey.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: passkey.BackupEligible,
+				BackupState:    passkey.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    passkey.AAGUID,
+				SignCount: passkey.SignCount,
+			},
+		}
+	}
+	return credentials
+}
+
+func (u *passkeyUser) passkey(credentialID []byte) *domain.Passkey {
+	for i := range u.passkeys {
+		if bytes.Equal(u.passkeys[i].CredentialID, credentialID) {
+			return &u.passkeys[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/repository"
+	"rss-reader/pkg/webpush"
+	"strings"
+	"time"
+)
+
+const (
+	pushTTL            = 12 * time.Hour
+	maxPushBodyTitles  = 3
+	maxPushTitleLength = 120
+)
+
+// pushNotification is the JSON payload the service worker turns into a
+// notification.
+type pushNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url"`
+	Tag   string `json:"tag"`
+}
+
+// PushService sends Web Push notifications for new items in the feeds each
+// user opted into, outside their quiet hours. The VAPID key pair is created
+// on first start and kept in the database.
+type PushService struct {
+	pushRepository repository.PushRepository
+	userRepository repository.UserRepository
+	sender         *webpush.Sender
+	appURL         string
+	development    bool
+}
+
+// NewPushService loads or creates the VAPID keys. In development, endpoints
+// may use plain http and private addresses, as the local push service does.
+func NewPushService(
+	pushRepository repository.PushRepository,
+	userRepository repository.UserRepository,
+	subject, appURL string,
+	development bool,
+) (*PushService, error) {
+	keys, err := pushRepository.GetVAPIDKeys()
+	if err == domain.ErrVAPIDKeysNotFound {
+		generated, err := webpush.GenerateVAPIDKeys()
+		if err != nil {
+			return nil, err
+		}
+		if err := pushRepository.CreateVAPIDKeys(generated); err != nil {
+			return nil, err
+		}
+		log.Println("Generated VAPID keys for Web Push")
+		keys, err = pushRepository.GetVAPIDKeys()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &PushService{
+		pushRepository: pushRepository,
+		userRepository: userRepository,
+		sender:         webpush.NewSender(keys, subject, development),
+		appURL:         appURL,
+		development:    development,
+	}, nil
+}
+
+// PublicKey is the VAPID key browsers need as applicationServerKey.
+func (s *PushService) PublicKey() string {
+	return s.sender.PublicKey()
+}
+
+func (s *PushService) Subscribe(userID int, endpoint, p256dh, auth, userAgent string) error {
+	subscription := &domain.PushSubscription{
+		UserID:    userID,
+		Endpoint:  endpoint,
+		P256dh:    p256dh,
+		Auth:      auth,
+		UserAgent: userAgent,
+	}
+	if err := subscription.Validate(); err != nil {
+		return err
+	}
+	if !s.development && !strings.HasPrefix(subscription.Endpoint, "https://") {
+		return domain.ErrInvalidPushSubscription
+	}
+
+	if err := s.pushRepository.SaveSubscription(subscription); err != nil {
+		return err
+	}
+
+	log.Printf("Saved push subscription %d for user %d", subscription.ID, userID)
+	return nil
+}
+
+func (s *PushService) RemoveSubscription(subscriptionID, userID int) error {
+	return s.pushRepository.DeleteSubscription(subscriptionID, userID)
+}
+
+func (s *PushService) GetSubscriptions(userID int) ([]domain.PushSubscription, error) {
+	return s.pushRepository.GetSubscriptions(userID)
+}
+
+func (s *PushService) GetSettings(userID int) (*domain.PushSettings, error) {
+	return s.pushRepository.GetSettings(userID)
+}
+
+func (s *PushService) UpdateSettings(settings *domain.PushSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	return s.pushRepository.SaveSettings(settings)
+}
+
+func (s *PushService) GetFeedIDs(userID int) ([]int, error) {
+	return s.pushRepository.GetFeedIDs(userID)
+}
+
+func (s *PushService) SetFeedIDs(userID int, feedIDs []int) error {
+	return s.pushRepository.SetFeedIDs(userID, feedIDs)
+}
+
+// SendTest notifies every device of the user regardless of feeds and quiet
+// hours and returns how many accepted the push.
+func (s *PushService) SendTest(userID int) (int, error) {
+	subscriptions, err := s.pushRepository.GetSubscriptions(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.send(subscriptions, pushNotification{
+		Title: "FeedStream",
+		Body:  "Notifications are working.",
+		URL:   s.appURL + "/feeds",
+		Tag:   "feedstream-test",
+	}), nil
+}
+
+// HandleNewItems notifies the user's devices about new items from feeds
+// they opted into, unless it is within their quiet hours. Notifications
+// skipped for quiet hours are dropped rather than sent later, and disabled
+// users get none.
+func (s *PushService) HandleNewItems(userID int, items []domain.FeedItem) {
+	user, err := s.userRepository.GetByID(userID)
+	if err != nil {
+		log.Printf("Warning: failed to get user %d for push: %v", userID, err)
+		return
+	}
+	if user.IsDisabled() {
+		return
+	}
+
+	feedIDs, err := s.pushRepository.GetFeedIDs(userID)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	if len(feedIDs) == 0 {
+		return
+	}
+
+	enabled := make(map[int]bool, len(feedIDs))
+	for _, feedID := range feedIDs {
+		enabled[feedID] = true
+	}
+
+	var matching []domain.FeedItem
+	for _, item := range items {
+		if enabled[item.FeedID] {
+			matching = append(matching, item)
+		}
+	}
+	if len(matching) == 0 {
+		return
+	}
+
+	settings, err := s.pushRepository.GetSettings(userID)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	if settings.InQuietHours(time.Now()) {
+		log.Printf("Skipping push for %d items to user %d during quiet hours", len(matching), userID)
+		return
+	}
+
+	subscriptions, err := s.pushRepository.GetSubscriptions(userID)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	go s.send(subscriptions, s.notificationFor(matching))
+}
+
+// notificationFor summarises items: a single item is shown on its own, more
+// are listed by title up to maxPushBodyTitles.
+func (s *PushService) notificationFor(items []domain.FeedItem) pushNotification {
+	if len(items) == 1 {
+		return pushNotification{
+			Title: truncate(items[0].FeedName, maxPushTitleLength),
+			Body:  truncate(items[0].Title, maxPushTitleLength),
+			URL:   items[0].Link,
+			Tag:   fmt.Sprintf("feedstream-item-%d", items[0].ID),
+		}
+	}
+
+	titles := make([]string, 0, maxPushBodyTitles+1)
+	for i, item := range items {
+		if i == maxPushBodyTitles {
+			titles = append(titles, fmt.Sprintf("and %d more", len(items)-maxPushBodyTitles))
+			break
+		}
+		titles = append(titles, truncate(item.Title, maxPushTitleLength))
+	}
+
+	return pushNotification{
+		Title: fmt.Sprintf("%d new items", len(items)),
+		Body:  strings.Join(titles, "\n"),
+		URL:   s.appURL + "/feeds",
+		Tag:   "feedstream-new-items",
+	}
+}
+
+// send pushes the notification to each subscription, deleting those the
+// push service reports as gone, and returns how many succeeded.
+func (s *PushService) send(subscriptions []domain.PushSubscription, notification pushNotification) int {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		log.Printf("Warning: failed to encode push notification: %v", err)
+		return 0
+	}
+
+	sent := 0
+	for _, subscription := range subscriptions {
+		err := s.sender.Send(webpush.Subscription{
+			Endpoint: subscription.Endpoint,
+			P256dh:   subscription.P256dh,
+			Auth:     subscription.Auth,
+		}, payload, pushTTL)
+
+		switch {
+		case err == webpush.ErrSubscriptionGone:
+			log.Printf("Removing expired push subscription %d", subscription.ID)
+			if err := s.pushRepository.DeleteSubscriptionByEndpoint(subscription.Endpoint); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		case err != nil:
+			log.Printf("Warning: push to subscription %d failed: %v", subscription.ID, err)
+		default:
+			sent++
+		}
+	}
+	return sent
+}
+
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length-1]) + "…"
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/repository"
+	"sync"
+	"time"
+)
+
+type RetentionService struct {
+	feedRepository         repository.FeedRepository
+	feedItemRepository     repository.FeedItemRepository
+	userSettingsRepository repository.UserSettingsRepository
+	defaults               domain.RetentionPolicy
+	lastReport             *domain.CleanupReport
+	mu                     sync.Mutex
+}
+
+func NewRetentionService(
+	feedRepository repository.FeedRepository,
+	feedItemRepository repository.FeedItemRepository,
+	userSettingsRepository repository.UserSettingsRepository,
+	defaults domain.RetentionPolicy,
+) *RetentionService {
+	return &RetentionService{
+		feedRepository:         feedRepository,
+		feedItemRepository:     feedItemRepository,
+		userSettingsRepository: userSettingsRepository,
+		defaults:               defaults,
+	}
+}
+
+func (s *RetentionService) Defaults() domain.RetentionPolicy {
+	return s.defaults
+}
+
+func (s *RetentionService) GetUserSettings(userID int) (*domain.UserSettings, error) {
+	settings, err := s.userSettingsRepository.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (s *RetentionService) UpdateUserRetention(userID int, policy domain.RetentionPolicy) error {
+	settings, err := s.userSettingsRepository.GetByUserID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user settings: %w", err)
+	}
+
+	settings.Retention = policy
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.userSettingsRepository.Upsert(settings); err != nil {
+		return fmt.Errorf("failed to update retention: %w", err)
+	}
+
+	return nil
+}
+
+func (s *RetentionService) UpdateFeedRetention(feedID, userID int, policy domain.RetentionPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.feedRepository.UpdateRetention(feedID, userID, policy); err != nil {
+		return fmt.Errorf("failed to update feed retention: %w", err)
+	}
+
+	return nil
+}
+
+// RunCleanup enforces every feed's effective retention policy across the
+// whole instance and returns how many items were removed.
+func (s *RetentionService) RunCleanup() (domain.CleanupReport, error) {
+	report := domain.CleanupReport{StartedAt: time.Now()}
+
+	deleted, err := s.feedItemRepository.DeleteExpired(s.defaults)
+	if err != nil {
+		return report, fmt.Errorf("failed to delete expired items: %w", err)
+	}
+	report.DeletedByAge = deleted
+
+	deleted, err = s.feedItemRepository.DeleteOverLimit(s.defaults)
+	if err != nil {
+		return report, fmt.Errorf("failed to delete items over limit: %w", err)
+	}
+	report.DeletedByCount = deleted
+
+	report.Duration = time.Since(report.StartedAt)
+
+	s.mu.Lock()
+	s.lastReport = &report
+	s.mu.Unlock()
+
+	log.Printf("Retention cleanup removed %d items (%d by age, %d by count) in %s",
+		report.Total(), report.DeletedByAge, report.DeletedByCount, report.Duration)
+	return report, nil
+}
+
+func (s *RetentionService) LastReport() *domain.CleanupReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastReport
+}
+
+// Start runs a cleanup immediately and then once per interval in the background.
+func (s *RetentionService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.RunCleanup(); err != nil {
+				log.Printf("Warning: retention cleanup failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"log"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/repository"
+	"rss-reader/pkg/security"
+	"time"
+)
+
+// sessionTouchInterval limits how often a session's last-seen time and IP
+// are written, so browsing does not cost a database write per request.
+const sessionTouchInterval = time.Minute
+
+// SessionService stores browser sessions server-side and lets users see and
+// revoke them. Sessions are looked up by the hash of the token in the
+// session cookie, so a database leak does not expose usable cookies.
+type SessionService struct {
+	sessionRepository repository.SessionRepository
+}
+
+func NewSessionService(sessionRepository repository.SessionRepository) *SessionService {
+	return &SessionService{
+		sessionRepository: sessionRepository,
+	}
+}
+
+// Load returns the live session for a cookie token, recording that it was
+// seen from ip.
+func (s *SessionService) Load(token, ip string) (*domain.Session, error) {
+	session, err := s.sessionRepository.GetByTokenHash(security.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval || session.IP != ip {
+		if err := s.sessionRepository.Touch(session.ID, ip, now); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		session.LastSeenAt = now
+		session.IP = ip
+	}
+
+	return session, nil
+}
+
+func (s *SessionService) Create(token string, session *domain.Session) error {
+	if len(session.UserAgent) > domain.MaxUserAgentLength {
+		session.UserAgent = session.UserAgent[:domain.MaxUserAgentLength]
+	}
+	return s.sessionRepository.Create(security.HashToken(token), session)
+}
+
+func (s *SessionService) Update(token string, session *domain.Session) error {
+	return s.sessionRepository.Update(security.HashToken(token), session)
+}
+
+func (s *SessionService) Delete(token string) error {
+	return s.sessionRepository.DeleteByTokenHash(security.HashToken(token))
+}
+
+// GetSessions lists the user's signed-in sessions, most recently used
+// first, marking the one holding currentToken.
+func (s *SessionService) GetSessions(userID int, currentToken string) ([]domain.Session, error) {
+	sessions, err := s.sessionRepository.GetAllByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.sessionRepository.GetByTokenHash(security.HashToken(currentToken))
+	if err != nil && err != domain.ErrSessionNotFound {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = current != nil && sessions[i].ID == current.ID
+	}
+
+	return sessions, nil
+}
+
+// Revoke signs one of the user's sessions out.
+func (s *SessionService) Revoke(sessionID, userID int) error {
+	if err := s.sessionRepository.Delete(sessionID, userID); err != nil {
+		return err
+	}
+
+	log.Printf("Revoked session %d for user %d", sessionID, userID)
+	return nil
+}
+
+// RevokeOthers signs the user out everywhere except the session holding
+// currentToken.
+func (s *SessionService) RevokeOthers(userID int, currentToken string) (int64, error) {
+	revoked, err := s.sessionRepository.DeleteOthers(userID, security.HashToken(currentToken))
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("Revoked %d other sessions for user %d", revoked, userID)
+	return revoked, nil
+}
+
+// RevokeAll signs the user out of every browser.
+func (s *SessionService) RevokeAll(userID int) (int64, error) {
+	revoked, err := s.sessionRepository.DeleteAllByUserID(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("Revoked all %d sessions for user %d", revoked, userID)
+	return revoked, nil
+}
+
+// Start purges expired sessions immediately and then on every interval.
+func (s *SessionService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			purged, err := s.sessionRepository.DeleteExpired(time.Now())
+			if err != nil {
+				log.Printf("Warning: %v", err)
+			} else if purged > 0 {
+				log.Printf("Purged %d expired sessions", purged)
+			}
+
+			<-ticker.C
+		}
+	}()
+}
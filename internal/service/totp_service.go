@@ -0,0 +1,318 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"log"
+	"net/url"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/repository"
+	"rss-reader/pkg/ratelimit"
+	"rss-reader/pkg/security"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpPeriod         = 30
+	totpSkew           = 1
+	totpQRCodeSize     = 200
+	recoveryCodeLength = 10
+	// recoveryCodeAlphabet leaves out characters that are easily misread.
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+var totpOptions = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Skew:      totpSkew,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// TOTPEnrollment is what a user needs to add their account to an
+// authenticator app.
+type TOTPEnrollment struct {
+	Secret string
+	URL    string
+	// QRCode is a data: URL of a PNG encoding URL.
+	QRCode string
+}
+
+// TOTPService manages authenticator app (RFC 6238) second factors and their
+// one-time recovery codes, which are stored hashed.
+type TOTPService struct {
+	totpRepository repository.TOTPRepository
+	userRepository repository.UserRepository
+	mailer         *Mailer
+	issuer         string
+	verifyLimiter  ratelimit.Limiter
+}
+
+func NewTOTPService(
+	totpRepository repository.TOTPRepository,
+	userRepository repository.UserRepository,
+	mailer *Mailer,
+	issuer string,
+	verifyLimiter ratelimit.Limiter,
+) *TOTPService {
+	return &TOTPService{
+		totpRepository: totpRepository,
+		userRepository: userRepository,
+		mailer:         mailer,
+		issuer:         issuer,
+		verifyLimiter:  verifyLimiter,
+	}
+}
+
+// IsEnabled reports whether sign-in requires a second factor for the user.
+func (s *TOTPService) IsEnabled(userID int) (bool, error) {
+	credential, err := s.totpRepository.GetByUserID(userID)
+	if err != nil {
+		if err == domain.ErrTOTPNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return credential.IsEnabled(), nil
+}
+
+// RecoveryCodesLeft counts the user's unused recovery codes.
+func (s *TOTPService) RecoveryCodesLeft(userID int) (int, error) {
+	return s.totpRepository.CountRecoveryCodes(userID)
+}
+
+// Enrollment returns the user's pending secret, creating one if needed, so
+// reloading the setup page keeps showing the code already scanned.
+func (s *TOTPService) Enrollment(userID int) (*TOTPEnrollment, error) {
+	user, err := s.userRepository.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	credential, err := s.totpRepository.GetByUserID(userID)
+	if err != nil && err != domain.ErrTOTPNotFound {
+		return nil, err
+	}
+	if credential != nil && credential.IsEnabled() {
+		return nil, domain.ErrTOTPAlreadyEnabled
+	}
+
+	var key *otp.Key
+	if credential != nil {
+		key, err = otp.NewKeyFromURL(s.keyURL(user.Email, credential.Secret))
+	} else {
+		key, err = totp.Generate(totp.GenerateOpts{
+			Issuer:      s.issuer,
+			AccountName: user.Email,
+			Period:      totpPeriod,
+			Digits:      totpOptions.Digits,
+			Algorithm:   totpOptions.Algorithm,
+		})
+		if err == nil {
+			err = s.totpRepository.SavePending(userID, key.Secret())
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TOTP secret: %w", err)
+	}
+
+	image, err := key.Image(totpQRCodeSize, totpQRCodeSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render QR code: %w", err)
+	}
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image); err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	return &TOTPEnrollment{
+		Secret: key.Secret(),
+		URL:    key.URL(),
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(encoded.Bytes()),
+	}, nil
+}
+
+// ConfirmEnrollment enables the pending secret once the user enters a code
+// from their app, and returns the first set of recovery codes.
+func (s *TOTPService) ConfirmEnrollment(userID int, code string) ([]string, error) {
+	credential, err := s.totpRepository.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if credential.IsEnabled() {
+		return nil, domain.ErrTOTPAlreadyEnabled
+	}
+
+	step, ok := matchTOTP(credential.Secret, code, time.Now())
+	if !ok {
+		return nil, domain.ErrInvalidTOTPCode
+	}
+
+	if err := s.totpRepository.Confirm(userID, step, time.Now()); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Enabled two-factor authentication for user %d", userID)
+	s.mailer.SendSecurityNotice(userID, SecurityEventTOTPEnabled, "")
+	return codes, nil
+}
+
+// Verify checks a code from the user's authenticator app or one of their
+// recovery codes. Each code is accepted only once.
+func (s *TOTPService) Verify(userID int, code string) error {
+	key := strconv.Itoa(userID)
+	if !s.verifyLimiter.Allow(key, 5, 15*time.Minute) {
+		log.Printf("Rate limit exceeded for two-factor verification: user %d", userID)
+		return fmt.Errorf("too many two-factor attempts, please wait 15 minutes")
+	}
+
+	credential, err := s.totpRepository.GetByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if !credential.IsEnabled() {
+		return domain.ErrTOTPNotFound
+	}
+
+	if step, ok := matchTOTP(credential.Secret, code, time.Now()); ok {
+		fresh, err := s.totpRepository.RecordStep(userID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return domain.ErrInvalidTOTPCode
+		}
+	} else {
+		used, err := s.totpRepository.UseRecoveryCode(userID, security.HashToken(normalizeRecoveryCode(code)), time.Now())
+		if err != nil {
+			return err
+		}
+		if !used {
+			return domain.ErrInvalidTOTPCode
+		}
+		log.Printf("User %d signed in with a recovery code", userID)
+	}
+
+	s.verifyLimiter.Reset(key)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all of the user's recovery codes after
+// checking a current code.
+func (s *TOTPService) RegenerateRecoveryCodes(userID int, code string) ([]string, error) {
+	if err := s.Verify(userID, code); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(userID)
+}
+
+// Disable removes the second factor after checking a current code.
+func (s *TOTPService) Disable(userID int, code string) error {
+	if err := s.Verify(userID, code); err != nil {
+		return err
+	}
+
+	if err := s.totpRepository.Delete(userID); err != nil {
+		return err
+	}
+
+	log.Printf("Disabled two-factor authentication for user %d", userID)
+	s.mailer.SendSecurityNotice(userID, SecurityEventTOTPDisabled, "")
+	return nil
+}
+
+func (s *TOTPService) replaceRecoveryCodes(userID int) ([]string, error) {
+	codes := make([]string, domain.RecoveryCodeCount)
+	hashes := make([]string, domain.RecoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = security.HashToken(normalizeRecoveryCode(code))
+	}
+
+	if err := s.totpRepository.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// keyURL builds the otpauth:// URL for an existing secret, in the form
+// totp.Generate produces for new ones.
+func (s *TOTPService) keyURL(accountName, secret string) string {
+	query := url.Values{
+		"secret":    {secret},
+		"issuer":    {s.issuer},
+		"period":    {strconv.Itoa(totpPeriod)},
+		"digits":    {totpOptions.Digits.String()},
+		"algorithm": {totpOptions.Algorithm.String()},
+	}
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + s.issuer + ":" + accountName,
+		RawQuery: query.Encode(),
+	}).String()
+}
+
+// matchTOTP checks code against the steps around now and returns the step
+// it belongs to, which callers record to refuse replays.
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpOptions.Digits.Length() {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for offset := int64(-totpSkew); offset <= totpSkew; offset++ {
+		step := current + offset
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totpOptions)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func newRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+
+	code := make([]byte, recoveryCodeLength)
+	for i := range b {
+		code[i] = recoveryCodeAlphabet[int(b[i])%len(recoveryCodeAlphabet)]
+	}
+	half := recoveryCodeLength / 2
+	return string(code[:half]) + "-" + string(code[half:]), nil
+}
+
+// normalizeRecoveryCode accepts codes typed with any case, spacing or
+// dashes.
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"errors"
+	"rss-reader/internal/domain"
+	"rss-reader/pkg/ratelimit"
+	"rss-reader/pkg/security"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+
+// fakeTOTPRepository keeps one user's credential in memory and refuses
+// steps and recovery codes the way the SQL conditions in totpRepository do.
+type fakeTOTPRepository struct {
+	mu            sync.Mutex
+	credential    *domain.TOTPCredential
+	recoveryCodes map[string]bool // hash -> used
+}
+
+func newFakeTOTPRepository(userID int, recoveryCodes ...string) *fakeTOTPRepository {
+	confirmedAt := time.Now()
+	repo := &fakeTOTPRepository{
+		credential: &domain.TOTPCredential{
+			UserID:      userID,
+			Secret:      testTOTPSecret,
+			ConfirmedAt: &confirmedAt,
+		},
+		recoveryCodes: make(map[string]bool),
+	}
+	for _, code := range recoveryCodes {
+		repo.recoveryCodes[security.HashToken(normalizeRecoveryCode(code))] = false
+	}
+	return repo
+}
+
+func (r *fakeTOTPRepository) GetByUserID(userID int) (*domain.TOTPCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.credential == nil || r.credential.UserID != userID {
+		return nil, domain.ErrTOTPNotFound
+	}
+	credential := *r.credential
+	return &credential, nil
+}
+
+func (r *fakeTOTPRepository) SavePending(userID int, secret string) error {
+	return errors.New("not implemented")
+}
+
+func (r *fakeTOTPRepository) Confirm(userID int, step int64, confirmedAt time.Time) error {
+	return errors.New("not implemented")
+}
+
+func (r *fakeTOTPRepository) RecordStep(userID int, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.credential.LastUsedStep >= step {
+		return false, nil
+	}
+	r.credential.LastUsedStep = step
+	return true, nil
+}
+
+func (r *fakeTOTPRepository) Delete(userID int) error {
+	return errors.New("not implemented")
+}
+
+func (r *fakeTOTPRepository) ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	return errors.New("not implemented")
+}
+
+func (r *fakeTOTPRepository) UseRecoveryCode(userID int, codeHash string, usedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	used, ok := r.recoveryCodes[codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	r.recoveryCodes[codeHash] = true
+	return true, nil
+}
+
+func (r *fakeTOTPRepository) CountRecoveryCodes(userID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, used := range r.recoveryCodes {
+		if !used {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func totpCodeAt(t *testing.T, at time.Time) string {
+	t.Helper()
+	code, err := totp.GenerateCodeCustom(testTOTPSecret, at, totpOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestMatchTOTPSkewWindow(t *testing.T) {
+	// Check both edges of the current step so that the window is measured
+	// in whole steps rather than seconds either side of now.
+	stepStart := time.Unix(1700000010, 0)
+	edges := []struct {
+		name string
+		now  time.Time
+	}{
+		{"start of step", stepStart},
+		{"end of step", stepStart.Add((totpPeriod - 1) * time.Second)},
+	}
+
+	for _, edge := range edges {
+		now := edge.now
+		current := now.Unix() / totpPeriod
+
+		tests := []struct {
+			name   string
+			offset int64
+			want   bool
+		}{
+			{"two steps behind", -2, false},
+			{"one step behind", -1, true},
+			{"current step", 0, true},
+			{"one step ahead", 1, true},
+			{"two steps ahead", 2, false},
+		}
+
+		for _, tt := range tests {
+			t.Run(edge.name+"/"+tt.name, func(t *testing.T) {
+				step := current + tt.offset
+				code := totpCodeAt(t, time.Unix(step*totpPeriod, 0))
+
+				got, ok := matchTOTP(testTOTPSecret, code, now)
+				if ok != tt.want {
+					t.Fatalf("matchTOTP with offset %d = %v, want %v", tt.offset, ok, tt.want)
+				}
+				if ok && got != step {
+					t.Errorf("matchTOTP returned step %d, want %d", got, step)
+				}
+			})
+		}
+	}
+}
+
+func TestMatchTOTPCodeFormat(t *testing.T) {
+	now := time.Unix(1700000010, 0)
+	code := totpCodeAt(t, now)
+
+	tests := []struct {
+		name string
+		code string
+		want bool
+	}{
+		{"exact", code, true},
+		{"surrounding space", " " + code + "\n", true},
+		{"too short", code[:5], false},
+		{"too long", code + "0", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := matchTOTP(testTOTPSecret, tt.code, now); ok != tt.want {
+				t.Errorf("matchTOTP(%q) = %v, want %v", tt.code, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRefusesReplayedStep(t *testing.T) {
+	const userID = 1
+	repo := newFakeTOTPRepository(userID)
+	s := NewTOTPService(repo, nil, nil, "feedstream", ratelimit.NewMemoryLimiter())
+
+	now := time.Now()
+	code := totpCodeAt(t, now)
+
+	if err := s.Verify(userID, code); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if err := s.Verify(userID, code); !errors.Is(err, domain.ErrInvalidTOTPCode) {
+		t.Errorf("replayed code: got %v, want %v", err, domain.ErrInvalidTOTPCode)
+	}
+
+	// A code from an earlier step is still inside the skew window but older
+	// than the step just used.
+	previous := totpCodeAt(t, now.Add(-totpPeriod*time.Second))
+	if previous != code {
+		if err := s.Verify(userID, previous); !errors.Is(err, domain.ErrInvalidTOTPCode) {
+			t.Errorf("earlier step after a later one: got %v, want %v", err, domain.ErrInvalidTOTPCode)
+		}
+	}
+}
+
+func TestVerifyRecoveryCodes(t *testing.T) {
+	const userID = 1
+
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{"as issued", "abcde-fghij", nil},
+		{"upper case", "ABCDE-FGHIJ", nil},
+		{"without dash", "abcdefghij", nil},
+		{"with spaces", " abcde fghij ", nil},
+		{"unknown code", "zzzzz-zzzzz", domain.ErrInvalidTOTPCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeTOTPRepository(userID, "abcde-fghij")
+			s := NewTOTPService(repo, nil, nil, "feedstream", ratelimit.NewMemoryLimiter())
+
+			if err := s.Verify(userID, tt.input); !errors.Is(err, tt.want) {
+				t.Fatalf("Verify(%q) = %v, want %v", tt.input, err, tt.want)
+			}
+			if tt.want != nil {
+				return
+			}
+
+			if err := s.Verify(userID, "abcde-fghij"); !errors.Is(err, domain.ErrInvalidTOTPCode) {
+				t.Errorf("second use of a recovery code: got %v, want %v", err, domain.ErrInvalidTOTPCode)
+			}
+			if count, _ := repo.CountRecoveryCodes(userID); count != 0 {
+				t.Errorf("%d recovery codes left, want 0", count)
+			}
+		})
+	}
+}
+
+func TestVerifyRateLimit(t *testing.T) {
+	const userID = 1
+	repo := newFakeTOTPRepository(userID)
+	s := NewTOTPService(repo, nil, nil, "feedstream", ratelimit.NewMemoryLimiter())
+
+	for i := 0; i < 5; i++ {
+		if err := s.Verify(userID, "000000-bad"); !errors.Is(err, domain.ErrInvalidTOTPCode) {
+			t.Fatalf("attempt %d: got %v, want %v", i+1, err, domain.ErrInvalidTOTPCode)
+		}
+	}
+
+	err := s.Verify(userID, totpCodeAt(t, time.Now()))
+	if err == nil || errors.Is(err, domain.ErrInvalidTOTPCode) {
+		t.Errorf("valid code after five failures: got %v, want the rate limit error", err)
+	}
+}
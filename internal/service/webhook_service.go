@@ -0,0 +1,374 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"rss-reader/internal/domain"
+	"rss-reader/internal/repository"
+	"rss-reader/pkg/security"
+	"rss-reader/pkg/webhook"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxWebhookAttempts     = 8
+	webhookBaseBackoff     = 30 * time.Second
+	webhookDeliveryBatch   = 100
+	webhookDeliveryLogSize = 50
+	webhookLogRetention    = 30 * 24 * time.Hour
+)
+
+// webhookPayload is the JSON body POSTed to webhooks. Item is omitted for
+// test events.
+type webhookPayload struct {
+	Event     string       `json:"event"`
+	WebhookID int          `json:"webhook_id"`
+	Timestamp time.Time    `json:"timestamp"`
+	Item      *webhookItem `json:"item,omitempty"`
+}
+
+type webhookItem struct {
+	ID          int         `json:"id"`
+	Title       string      `json:"title"`
+	Link        string      `json:"link"`
+	Description string      `json:"description"`
+	PublishedAt time.Time   `json:"published_at"`
+	Feed        webhookFeed `json:"feed"`
+}
+
+type webhookFeed struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Folder string `json:"folder,omitempty"`
+}
+
+// WebhookService delivers new items to user-configured webhooks. Events are
+// queued first and sent by a background worker, which retries failures with
+// exponential backoff and keeps a log of every delivery.
+type WebhookService struct {
+	webhookRepository repository.WebhookRepository
+	alertRepository   repository.AlertRepository
+	userRepository    repository.UserRepository
+	feedService       *FeedService
+	sender            *webhook.Sender
+	tokenGenerator    *security.TokenGenerator
+	processMutex      sync.Mutex
+}
+
+func NewWebhookService(
+	webhookRepository repository.WebhookRepository,
+	alertRepository repository.AlertRepository,
+	userRepository repository.UserRepository,
+	feedService *FeedService,
+	sender *webhook.Sender,
+	tokenGenerator *security.TokenGenerator,
+) *WebhookService {
+	return &WebhookService{
+		webhookRepository: webhookRepository,
+		alertRepository:   alertRepository,
+		userRepository:    userRepository,
+		feedService:       feedService,
+		sender:            sender,
+		tokenGenerator:    tokenGenerator,
+	}
+}
+
+func (s *WebhookService) GetWebhooks(userID int) ([]domain.Webhook, error) {
+	webhooks, err := s.webhookRepository.GetAllByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func (s *WebhookService) GetRecentDeliveries(userID int) ([]domain.WebhookDelivery, error) {
+	deliveries, err := s.webhookRepository.GetRecentDeliveries(userID, webhookDeliveryLogSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// CreateWebhook adds an endpoint with a fresh signing secret. At most one of
+// feedID, folderID and alertID may be set, and it must belong to the user.
+func (s *WebhookService) CreateWebhook(userID int, name, url string, feedID, folderID, alertID int) (*domain.Webhook, error) {
+	hook := &domain.Webhook{
+		UserID:   userID,
+		Name:     strings.TrimSpace(name),
+		URL:      strings.TrimSpace(url),
+		FeedID:   feedID,
+		FolderID: folderID,
+		AlertID:  alertID,
+	}
+	if err := hook.Validate(); err != nil {
+		return nil, err
+	}
+
+	if feedID != 0 {
+		if _, err := s.feedService.GetFeedByID(feedID, userID); err != nil {
+			return nil, err
+		}
+	}
+	if folderID != 0 {
+		if _, err := s.feedService.GetFolderByID(folderID, userID); err != nil {
+			return nil, err
+		}
+	}
+	if alertID != 0 {
+		matchers, err := s.alertMatchers(userID)
+		if err != nil {
+			return nil, err
+		}
+		if matchers[alertID] == nil {
+			return nil, domain.ErrAlertNotFound
+		}
+	}
+
+	secret, err := s.tokenGenerator.Generate("whsec_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	hook.Secret = secret
+
+	if err := s.webhookRepository.Create(hook); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Created webhook %d for user %d", hook.ID, userID)
+	return hook, nil
+}
+
+func (s *WebhookService) DeleteWebhook(webhookID, userID int) error {
+	return s.webhookRepository.Delete(webhookID, userID)
+}
+
+// SendTest queues a test event and starts a delivery run in the background;
+// the result appears in the delivery log moments later.
+func (s *WebhookService) SendTest(webhookID, userID int) error {
+	hook, err := s.webhookRepository.GetByID(webhookID, userID)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(webhookPayload{
+		Event:     domain.WebhookEventTest,
+		WebhookID: hook.ID,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode test event: %w", err)
+	}
+
+	err = s.webhookRepository.Enqueue([]domain.WebhookDelivery{{
+		WebhookID: hook.ID,
+		Event:     domain.WebhookEventTest,
+		Payload:   string(payload),
+	}})
+	if err != nil {
+		return err
+	}
+
+	go s.ProcessDue(time.Now())
+	return nil
+}
+
+// HandleNewItems queues an item.created event for every new item in each
+// webhook's scope. Nothing is queued for disabled users.
+func (s *WebhookService) HandleNewItems(userID int, items []domain.FeedItem) {
+	user, err := s.userRepository.GetByID(userID)
+	if err != nil {
+		log.Printf("Warning: failed to get user %d for webhooks: %v", userID, err)
+		return
+	}
+	if user.IsDisabled() {
+		return
+	}
+
+	webhooks, err := s.webhookRepository.GetAllByUserID(userID)
+	if err != nil {
+		log.Printf("Warning: failed to load webhooks for user %d: %v", userID, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	feeds, err := s.feedService.GetFeedsByUserID(userID)
+	if err != nil {
+		log.Printf("Warning: failed to load feeds for webhooks: %v", err)
+		return
+	}
+	feedsByID := make(map[int]domain.Feed, len(feeds))
+	for _, feed := range feeds {
+		feedsByID[feed.ID] = feed
+	}
+
+	var matchers map[int]func(domain.FeedItem) bool
+	for _, hook := range webhooks {
+		if hook.AlertID != 0 {
+			matchers, err = s.alertMatchers(userID)
+			if err != nil {
+				log.Printf("Warning: failed to load alerts for webhooks: %v", err)
+				return
+			}
+			break
+		}
+	}
+
+	now := time.Now().UTC()
+	var deliveries []domain.WebhookDelivery
+	for _, item := range items {
+		feed := feedsByID[item.FeedID]
+		for _, hook := range webhooks {
+			if hook.AlertID != 0 {
+				if matches := matchers[hook.AlertID]; matches == nil || !matches(item) {
+					continue
+				}
+			} else if !hook.Covers(item.FeedID, feed.FolderID) {
+				continue
+			}
+
+			payload, err := json.Marshal(webhookPayload{
+				Event:     domain.WebhookEventItemCreated,
+				WebhookID: hook.ID,
+				Timestamp: now,
+				Item: &webhookItem{
+					ID:          item.ID,
+					Title:       item.Title,
+					Link:        item.Link,
+					Description: item.Description,
+					PublishedAt: item.PublishedAt,
+					Feed: webhookFeed{
+						ID:     feed.ID,
+						Name:   feed.Name,
+						URL:    feed.URL,
+						Folder: feed.Folder,
+					},
+				},
+			})
+			if err != nil {
+				log.Printf("Warning: failed to encode webhook event for item %d: %v", item.ID, err)
+				continue
+			}
+
+			deliveries = append(deliveries, domain.WebhookDelivery{
+				WebhookID: hook.ID,
+				Event:     domain.WebhookEventItemCreated,
+				Payload:   string(payload),
+			})
+		}
+	}
+
+	if len(deliveries) == 0 {
+		return
+	}
+	if err := s.webhookRepository.Enqueue(deliveries); err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+
+	log.Printf("Queued %d webhook deliveries for user %d", len(deliveries), userID)
+	go s.ProcessDue(time.Now())
+}
+
+// alertMatchers compiles the user's alerts by ID. An item matches an alert
+// the same way AlertService matches it: by title or description.
+func (s *WebhookService) alertMatchers(userID int) (map[int]func(domain.FeedItem) bool, error) {
+	alerts, err := s.alertRepository.GetAllByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	matchers := make(map[int]func(domain.FeedItem) bool, len(alerts))
+	for i := range alerts {
+		matches, err := alerts[i].Matcher()
+		if err != nil {
+			log.Printf("Warning: skipping alert %d for webhooks: %v", alerts[i].ID, err)
+			continue
+		}
+		matchers[alerts[i].ID] = func(item domain.FeedItem) bool {
+			return matches(item.Title) || matches(item.Description)
+		}
+	}
+	return matchers, nil
+}
+
+// ProcessDue attempts every delivery whose next attempt is due. Deliveries
+// of disabled users are left pending until the account is enabled again.
+func (s *WebhookService) ProcessDue(now time.Time) {
+	s.processMutex.Lock()
+	defer s.processMutex.Unlock()
+
+	for {
+		deliveries, err := s.webhookRepository.GetDueDeliveries(now, webhookDeliveryBatch)
+		if err != nil {
+			log.Printf("Warning: failed to load webhook deliveries: %v", err)
+			return
+		}
+
+		for i := range deliveries {
+			if err := s.attempt(&deliveries[i]); err != nil {
+				// The delivery would be picked up again; leave it for the next run.
+				log.Printf("Warning: %v", err)
+				return
+			}
+		}
+
+		if len(deliveries) < webhookDeliveryBatch {
+			return
+		}
+	}
+}
+
+// attempt sends one delivery and records the outcome. Failures are retried
+// after 30s, 1m, 2m and so on, doubling until maxWebhookAttempts.
+func (s *WebhookService) attempt(delivery *domain.WebhookDelivery) error {
+	status, err := s.sender.Send(delivery.Webhook.URL, delivery.Webhook.Secret, delivery.Event,
+		delivery.ID, []byte(delivery.Payload))
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		delivery.Status = domain.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= maxWebhookAttempts:
+		delivery.Status = domain.WebhookDeliveryFailed
+		delivery.LastError = err.Error()
+		log.Printf("Webhook delivery %d failed permanently: %v", delivery.ID, err)
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(webhookBaseBackoff << (delivery.Attempts - 1))
+	}
+
+	return s.webhookRepository.RecordAttempt(delivery)
+}
+
+// Start processes due deliveries every interval in the background and
+// prunes finished deliveries older than webhookLogRetention.
+func (s *WebhookService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.ProcessDue(time.Now())
+
+			pruned, err := s.webhookRepository.DeleteDeliveriesBefore(time.Now().Add(-webhookLogRetention))
+			if err != nil {
+				log.Printf("Warning: %v", err)
+			} else if pruned > 0 {
+				log.Printf("Pruned %d old webhook deliveries", pruned)
+			}
+
+			<-ticker.C
+		}
+	}()
+}
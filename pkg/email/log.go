@@ -0,0 +1,21 @@
+package email
+
+import (
+	"log"
+	"strings"
+)
+
+// LogService writes messages to the application log instead of sending
+// them. It is meant for local development, where it makes OTP codes
+// readable from the console without any email provider.
+type LogService struct{}
+
+func NewLogService() *LogService {
+	return &LogService{}
+}
+
+func (s *LogService) Send(message *Message) error {
+	body := strings.TrimSpace(message.PlainText())
+	log.Printf("Email to: %s\nSubject: %s\n\n%s\n", message.To, message.Subject, body)
+	return nil
+}
@@ -0,0 +1,153 @@
+package email
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var ErrMailboxMessageNotFound = errors.New("mailbox message not found")
+
+var mailboxIDPattern = regexp.MustCompile(`^[0-9]+-[0-9a-f]+$`)
+
+// MailboxMessage is a message captured by the MailboxService.
+type MailboxMessage struct {
+	ID      string    `json:"id"`
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	HTML    string    `json:"html"`
+	Text    string    `json:"text"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// MailboxService stores messages as JSON files in a local directory instead
+// of sending them, so that sign-in and notifications work offline during
+// development. Files are named by send time and survive restarts.
+type MailboxService struct {
+	dir string
+}
+
+func NewMailboxService(dir string) (*MailboxService, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("mailbox directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create mailbox directory: %w", err)
+	}
+
+	return &MailboxService{dir: dir}, nil
+}
+
+func (s *MailboxService) Send(message *Message) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to generate message ID: %w", err)
+	}
+
+	now := time.Now()
+	stored := MailboxMessage{
+		ID:      strconv.FormatInt(now.UnixNano(), 10) + "-" + hex.EncodeToString(suffix),
+		To:      message.To,
+		Subject: message.Subject,
+		HTML:    message.HTML,
+		Text:    message.PlainText(),
+		SentAt:  now,
+	}
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	// Write to a temporary name and rename, so readers never see a
+	// partially written message.
+	path := s.path(stored.ID)
+	if err := os.WriteFile(path+".tmp", data, 0o600); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	log.Printf("Email to %s captured in mailbox: %s", message.To, path)
+	return nil
+}
+
+// Messages returns the captured messages, newest first.
+func (s *MailboxService) Messages() ([]MailboxMessage, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mailbox: %w", err)
+	}
+
+	messages := make([]MailboxMessage, 0, len(paths))
+	for _, path := range paths {
+		message, err := readMailboxMessage(path)
+		if err != nil {
+			log.Printf("Warning: skipping unreadable mailbox file %s: %v", path, err)
+			continue
+		}
+		messages = append(messages, *message)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].SentAt.After(messages[j].SentAt)
+	})
+	return messages, nil
+}
+
+func (s *MailboxService) Get(id string) (*MailboxMessage, error) {
+	if !mailboxIDPattern.MatchString(id) {
+		return nil, ErrMailboxMessageNotFound
+	}
+
+	message, err := readMailboxMessage(s.path(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrMailboxMessageNotFound
+		}
+		return nil, err
+	}
+	return message, nil
+}
+
+// Clear deletes every captured message.
+func (s *MailboxService) Clear() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list mailbox: %w", err)
+	}
+
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete message: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *MailboxService) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func readMailboxMessage(path string) (*MailboxMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var message MailboxMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, fmt.Errorf("failed to decode message: %w", err)
+	}
+	return &message, nil
+}
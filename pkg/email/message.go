@@ -0,0 +1,45 @@
+package email
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Message is a single email. HTML is the rich body; Text is the plain-text
+// alternative, derived from HTML when left empty.
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+var (
+	blockTags     = regexp.MustCompile(`(?i)<\s*(br|/p|/div|/h[1-6]|/li|/tr)\s*/?>`)
+	listItemTags  = regexp.MustCompile(`(?i)<\s*li[^>]*>`)
+	linkTags      = regexp.MustCompile(`(?is)<a\s[^>]*href="([^"]*)"[^>]*>(.*?)</a>`)
+	hiddenContent = regexp.MustCompile(`(?is)<(head|style|script)[^>]*>.*?</(head|style|script)>`)
+	anyTag        = regexp.MustCompile(`<[^>]*>`)
+	blankLines    = regexp.MustCompile(`\n{3,}`)
+)
+
+// PlainText returns Text, or a readable plain-text rendering of HTML that
+// keeps line breaks and link targets.
+func (m *Message) PlainText() string {
+	if m.Text != "" {
+		return m.Text
+	}
+
+	text := hiddenContent.ReplaceAllString(m.HTML, "")
+	text = linkTags.ReplaceAllString(text, "$2 ($1)")
+	text = listItemTags.ReplaceAllString(text, "- ")
+	text = blockTags.ReplaceAllString(text, "\n")
+	text = html.UnescapeString(anyTag.ReplaceAllString(text, ""))
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")) + "\n"
+}
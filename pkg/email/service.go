@@ -8,7 +8,7 @@ import (
 )
 
 type Service interface {
-	SendEmail(to, subject, body string) error
+	Send(message *Message) error
 }
 
 type ResendService struct {
@@ -34,15 +34,17 @@ func NewResendService(apiKey, from string) (*ResendService, error) {
 	}, nil
 }
 
-func (s *ResendService) SendEmail(to, subject, body string) error {
+func (s *ResendService) Send(message *Message) error {
+	to := message.To
 	log.Printf("Attempting to send email to: %s", to)
-	log.Printf("Email subject: %s", subject)
+	log.Printf("Email subject: %s", message.Subject)
 
 	params := &resend.SendEmailRequest{
 		From:    s.from,
 		To:      []string{to},
-		Html:    body,
-		Subject: subject,
+		Html:    message.HTML,
+		Text:    message.PlainText(),
+		Subject: message.Subject,
 	}
 
 	log.Printf("Sending email via Resend API...")
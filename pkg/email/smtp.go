@@ -0,0 +1,259 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SMTPSecurityStartTLS = "starttls"
+	SMTPSecurityTLS      = "tls"
+	SMTPSecurityNone     = "none"
+)
+
+// SMTPConfig describes an SMTP relay. Security is "starttls" (upgrade a
+// plain connection, usually port 587), "tls" (implicit TLS, usually port
+// 465) or "none". Authentication is used when Username is set.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Security string
+	From     string
+}
+
+// SMTPService sends email through an SMTP relay, reusing one connection
+// across messages and redialling when the server has dropped it.
+type SMTPService struct {
+	config SMTPConfig
+	from   *mail.Address
+	mutex  sync.Mutex
+	client *smtp.Client
+}
+
+func NewSMTPService(config SMTPConfig) (*SMTPService, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("SMTP host is required")
+	}
+	if config.Port <= 0 {
+		return nil, fmt.Errorf("SMTP port is required")
+	}
+	switch config.Security {
+	case SMTPSecurityStartTLS, SMTPSecurityTLS, SMTPSecurityNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP security mode %q", config.Security)
+	}
+
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from email address: %w", err)
+	}
+
+	return &SMTPService{config: config, from: from}, nil
+}
+
+func (s *SMTPService) Send(message *Message) error {
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	data, err := s.build(to, message)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// A reused connection may have been closed by the server since the last
+	// message; retry once on a fresh one.
+	err = s.deliver(to.Address, data)
+	if err != nil && s.client != nil {
+		s.reset()
+		err = s.deliver(to.Address, data)
+	}
+	if err != nil {
+		s.reset()
+		log.Printf("Failed to send email via SMTP: %v", err)
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	log.Printf("Email sent successfully to: %s via SMTP", to.Address)
+	return nil
+}
+
+func (s *SMTPService) deliver(to string, data []byte) error {
+	if s.client == nil {
+		client, err := s.dial()
+		if err != nil {
+			return err
+		}
+		s.client = client
+	} else if err := s.client.Reset(); err != nil {
+		return err
+	}
+
+	if err := s.client.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := s.client.Rcpt(to); err != nil {
+		return err
+	}
+
+	writer, err := s.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+func (s *SMTPService) dial() (*smtp.Client, error) {
+	address := net.JoinHostPort(s.config.Host, fmt.Sprint(s.config.Port))
+	tlsConfig := &tls.Config{ServerName: s.config.Host, MinVersion: tls.VersionTLS12}
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if s.config.Security == SMTPSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+
+	if s.config.Security == SMTPSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	if s.config.Username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted
+		// connection to anything but localhost.
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+func (s *SMTPService) reset() {
+	if s.client != nil {
+		s.client.Close()
+		s.client = nil
+	}
+}
+
+// Close ends the reused connection, if any.
+func (s *SMTPService) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.client == nil {
+		return nil
+	}
+	err := s.client.Quit()
+	s.client = nil
+	return err
+}
+
+// build renders the message as multipart/alternative with a plain-text and
+// an HTML part, both quoted-printable.
+func (s *SMTPService) build(to *mail.Address, message *Message) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + s.from.String(),
+		"To: " + to.String(),
+		"Subject: " + mime.QEncoding.Encode("utf-8", message.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + messageID(s.from.Address),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + writer.Boundary(),
+	}
+	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", message.PlainText()},
+		{"text/html; charset=utf-8", message.HTML},
+	}
+	for _, part := range parts {
+		if part.body == "" {
+			continue
+		}
+
+		partWriter, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+
+		encoder := quotedprintable.NewWriter(partWriter)
+		if _, err := encoder.Write([]byte(part.body)); err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build email: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
@@ -0,0 +1,194 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Branding is available to every template through the product and appURL
+// functions, so an instance can be renamed without editing templates.
+type Branding struct {
+	ProductName string
+	AppURL      string
+}
+
+// Locale is a language the templates are available in.
+type Locale struct {
+	Code string
+	Name string
+}
+
+type localeTemplates struct {
+	name string
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+// Templates renders transactional emails from a directory with one
+// subdirectory per locale, e.g. templates/email/en. Each locale holds a
+// layout.html and, per message type, a <type>.html body rendered inside the
+// layout and a <type>.txt that defines the "subject" and the plain-text
+// body. Locales may translate only some types; the rest fall back to the
+// default locale, which must have them all.
+type Templates struct {
+	defaultLocale string
+	locales       map[string]*localeTemplates
+}
+
+func LoadTemplates(dir, defaultLocale string, branding Branding) (*Templates, error) {
+	funcs := map[string]interface{}{
+		"product": func() string { return branding.ProductName },
+		"appURL":  func() string { return strings.TrimSuffix(branding.AppURL, "/") },
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email templates: %w", err)
+	}
+
+	t := &Templates{
+		defaultLocale: NormalizeLocale(defaultLocale),
+		locales:       make(map[string]*localeTemplates),
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		locale, err := loadLocale(filepath.Join(dir, entry.Name()), funcs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s email templates: %w", entry.Name(), err)
+		}
+		t.locales[NormalizeLocale(entry.Name())] = locale
+	}
+
+	fallback, ok := t.locales[t.defaultLocale]
+	if !ok {
+		return nil, fmt.Errorf("no email templates for default locale %q", t.defaultLocale)
+	}
+	for code, locale := range t.locales {
+		for name := range locale.html {
+			if _, ok := fallback.html[name]; !ok {
+				return nil, fmt.Errorf("email template %s/%s has no %s version", code, name, t.defaultLocale)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+func loadLocale(dir string, funcs map[string]interface{}) (*localeTemplates, error) {
+	layout := filepath.Join(dir, "layout.html")
+	locale := &localeTemplates{
+		html: make(map[string]*htmltemplate.Template),
+		text: make(map[string]*texttemplate.Template),
+	}
+
+	htmlFiles, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range htmlFiles {
+		if file == layout {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".html")
+
+		html, err := htmltemplate.New(filepath.Base(file)).Funcs(funcs).ParseFiles(layout, file)
+		if err != nil {
+			return nil, err
+		}
+		text, err := texttemplate.New(name + ".txt").Funcs(funcs).ParseFiles(filepath.Join(dir, name+".txt"))
+		if err != nil {
+			return nil, err
+		}
+		if text.Lookup("subject") == nil {
+			return nil, fmt.Errorf("%s.txt does not define a subject", name)
+		}
+
+		locale.html[name] = html
+		locale.text[name] = text
+	}
+
+	languageTemplate, err := htmltemplate.New("layout.html").Funcs(funcs).ParseFiles(layout)
+	if err != nil {
+		return nil, err
+	}
+	var language bytes.Buffer
+	if err := languageTemplate.ExecuteTemplate(&language, "language", nil); err != nil {
+		return nil, fmt.Errorf("layout.html does not define a language name: %w", err)
+	}
+	locale.name = strings.TrimSpace(language.String())
+
+	return locale, nil
+}
+
+// Render builds the message of the given type in locale, falling back to
+// the default locale. The caller sets the recipient.
+func (t *Templates) Render(locale, name string, data interface{}) (*Message, error) {
+	templates, ok := t.locales[NormalizeLocale(locale)]
+	if !ok || templates.html[name] == nil {
+		templates = t.locales[t.defaultLocale]
+	}
+
+	html, ok := templates.html[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+	text := templates.text[name]
+
+	var subject, textBody, htmlBody bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := text.Execute(&textBody, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+	if err := html.Execute(&htmlBody, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s HTML: %w", name, err)
+	}
+
+	return &Message{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		HTML:    htmlBody.String(),
+		Text:    strings.TrimSpace(textBody.String()) + "\n",
+	}, nil
+}
+
+// HasLocale reports whether templates exist for locale.
+func (t *Templates) HasLocale(locale string) bool {
+	_, ok := t.locales[NormalizeLocale(locale)]
+	return ok
+}
+
+// DefaultLocale is used for users who have not chosen a language.
+func (t *Templates) DefaultLocale() string {
+	return t.defaultLocale
+}
+
+// Locales lists the available locales ordered by code.
+func (t *Templates) Locales() []Locale {
+	locales := make([]Locale, 0, len(t.locales))
+	for code, locale := range t.locales {
+		locales = append(locales, Locale{Code: code, Name: locale.name})
+	}
+	sort.Slice(locales, func(i, j int) bool {
+		return locales[i].Code < locales[j].Code
+	})
+	return locales
+}
+
+// NormalizeLocale reduces a language tag such as "de-AT" or "pt_BR" to its
+// lowercase primary language, which is how template directories are named.
+func NormalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
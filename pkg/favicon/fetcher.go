@@ -0,0 +1,62 @@
+package favicon
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const maxIconSize = 256 * 1024
+
+type Fetcher struct {
+	client *http.Client
+}
+
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Fetch downloads the icon at iconURL, or /favicon.ico on the site's host
+// when iconURL is empty, and returns its MIME type and bytes.
+func (f *Fetcher) Fetch(siteURL, iconURL string) (string, []byte, error) {
+	if iconURL == "" {
+		site, err := url.Parse(siteURL)
+		if err != nil || site.Host == "" {
+			return "", nil, fmt.Errorf("invalid site URL %q", siteURL)
+		}
+		iconURL = (&url.URL{Scheme: site.Scheme, Host: site.Host, Path: "/favicon.ico"}).String()
+	}
+
+	resp, err := f.client.Get(iconURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to fetch icon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("failed to fetch icon: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxIconSize+1))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read icon: %w", err)
+	}
+	if len(data) > maxIconSize {
+		return "", nil, fmt.Errorf("icon exceeds %d bytes", maxIconSize)
+	}
+
+	mimeType := http.DetectContentType(data)
+	if strings.HasSuffix(iconURL, ".ico") && !strings.HasPrefix(mimeType, "image/") {
+		mimeType = "image/x-icon"
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return "", nil, fmt.Errorf("unexpected icon content type %q", mimeType)
+	}
+
+	return mimeType, data, nil
+}
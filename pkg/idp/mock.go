@@ -0,0 +1,330 @@
+package idp
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	mockCodeLifetime  = time.Minute
+	mockTokenLifetime = 5 * time.Minute
+	mockKeyID         = "mock"
+)
+
+var b64 = base64.RawURLEncoding
+
+var mockLoginTemplate = template.Must(template.New("login").Parse(`<!doctype html>
+<html>
+    <head>
+        <title>Mock Identity Provider</title>
+        <link rel="stylesheet" type="text/css" href="/static/css/style.css" />
+    </head>
+    <body>
+        <div class="container">
+            <div class="header">
+                <h1>Mock Identity Provider</h1>
+            </div>
+            <p class="settings-hint">Development only. Any email address signs in; no password is checked.</p>
+            <form method="POST">
+                {{range $name, $value := .Params}}<input type="hidden" name="{{$name}}" value="{{$value}}" />
+                {{end}}<input type="email" name="email" placeholder="Email" required autofocus />
+                <label><input type="checkbox" name="unverified" value="1" /> Email not verified</label>
+                <label><input type="checkbox" name="omit_verified" value="1" /> Leave out email_verified</label>
+                <button type="submit">Sign In</button>
+            </form>
+        </div>
+    </body>
+</html>
+`))
+
+type mockCode struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	challenge     string
+	email         string
+	emailVerified bool
+	omitVerified  bool
+	expiresAt     time.Time
+}
+
+// MockProvider is a minimal OpenID Connect provider for development. It
+// serves discovery, a JWKS, an authorization endpoint that signs in any
+// email address typed into it, and a token endpoint that requires PKCE and
+// issues RS256-signed ID tokens. Keys and codes live in memory.
+type MockProvider struct {
+	mutex        sync.Mutex
+	issuer       string
+	clientID     string
+	clientSecret string
+	key          *rsa.PrivateKey
+	codes        map[string]*mockCode
+}
+
+// NewMockProvider serves a provider whose issuer is the URL it is mounted
+// at, accepting a single client.
+func NewMockProvider(issuer, clientID, clientSecret string) (*MockProvider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	return &MockProvider{
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		key:          key,
+		codes:        make(map[string]*mockCode),
+	}, nil
+}
+
+func (p *MockProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	issuerPath := "/"
+	if issuer, err := url.Parse(p.issuer); err == nil {
+		issuerPath = issuer.Path
+	}
+
+	switch strings.TrimPrefix(r.URL.Path, issuerPath) {
+	case "/.well-known/openid-configuration":
+		p.discovery(w)
+	case "/jwks":
+		p.jwks(w)
+	case "/authorize":
+		p.authorize(w, r)
+	case "/token":
+		p.token(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (p *MockProvider) discovery(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.issuer,
+		"authorization_endpoint":                p.issuer + "/authorize",
+		"token_endpoint":                        p.issuer + "/token",
+		"jwks_uri":                              p.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"scopes_supported":                      []string{"openid", "email", "profile"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+		"claims_supported":                      []string{"sub", "email", "email_verified"},
+	})
+}
+
+func (p *MockProvider) jwks(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": mockKeyID,
+			"n":   b64.EncodeToString(p.key.N.Bytes()),
+			"e":   b64.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize shows a sign-in form on GET and redirects back to the client
+// with a code on POST. The request parameters travel through the form.
+func (p *MockProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if r.Form.Get("client_id") != p.clientID {
+		http.Error(w, "Unknown client_id", http.StatusBadRequest)
+		return
+	}
+	redirectURI, err := url.Parse(r.Form.Get("redirect_uri"))
+	if err != nil || redirectURI.Host == "" {
+		http.Error(w, "Invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("response_type") != "code" {
+		http.Error(w, "Only response_type=code is supported", http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("code_challenge") == "" || r.Form.Get("code_challenge_method") != "S256" {
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		params := make(map[string]string)
+		for _, name := range []string{"client_id", "redirect_uri", "response_type", "scope", "state", "nonce", "code_challenge", "code_challenge_method"} {
+			params[name] = r.Form.Get(name)
+		}
+		mockLoginTemplate.Execute(w, map[string]interface{}{"Params": params})
+		return
+	}
+
+	email := strings.TrimSpace(r.PostForm.Get("email"))
+	if email == "" {
+		http.Error(w, "Email is required", http.StatusBadRequest)
+		return
+	}
+
+	code, err := randomString()
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	p.mutex.Lock()
+	for c, pending := range p.codes {
+		if time.Now().After(pending.expiresAt) {
+			delete(p.codes, c)
+		}
+	}
+	p.codes[code] = &mockCode{
+		clientID:      p.clientID,
+		redirectURI:   redirectURI.String(),
+		nonce:         r.Form.Get("nonce"),
+		challenge:     r.Form.Get("code_challenge"),
+		email:         email,
+		emailVerified: r.PostForm.Get("unverified") == "",
+		omitVerified:  r.PostForm.Get("omit_verified") != "",
+		expiresAt:     time.Now().Add(mockCodeLifetime),
+	}
+	p.mutex.Unlock()
+
+	query := redirectURI.Query()
+	query.Set("code", code)
+	query.Set("state", r.Form.Get("state"))
+	redirectURI.RawQuery = query.Encode()
+
+	log.Printf("Mock identity provider signed in %s", email)
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (p *MockProvider) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != p.clientID || clientSecret != p.clientSecret {
+		tokenError(w, "invalid_client")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+
+	p.mutex.Lock()
+	code, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mutex.Unlock()
+
+	if !ok || time.Now().After(code.expiresAt) || code.redirectURI != r.PostForm.Get("redirect_uri") {
+		tokenError(w, "invalid_grant")
+		return
+	}
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if b64.EncodeToString(verifier[:]) != code.challenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":            p.issuer,
+		"sub":            "mock|" + strings.ToLower(code.email),
+		"aud":            code.clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(mockTokenLifetime).Unix(),
+		"nonce":          code.nonce,
+		"email":          code.email,
+		"email_verified": code.emailVerified,
+	}
+	// Some providers never send email_verified.
+	if code.omitVerified {
+		delete(claims, "email_verified")
+	}
+
+	idToken, err := p.sign(claims)
+	if err != nil {
+		tokenError(w, "server_error")
+		return
+	}
+
+	accessToken, err := randomString()
+	if err != nil {
+		tokenError(w, "server_error")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(mockTokenLifetime / time.Second),
+		"id_token":     idToken,
+	})
+}
+
+func (p *MockProvider) sign(claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": mockKeyID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + b64.EncodeToString(signature), nil
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return b64.EncodeToString(b), nil
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
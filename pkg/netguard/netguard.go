@@ -0,0 +1,74 @@
+// Package netguard builds HTTP clients for requests to user-supplied URLs,
+// such as webhooks and Web Push endpoints, that must not reach the server's
+// own network.
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when a request would connect to a
+// loopback, private, link-local or otherwise internal address.
+var ErrForbiddenAddress = errors.New("destination address is not allowed")
+
+// carrierGradeNAT is the shared address space of RFC 6598, which is not
+// covered by netip.Addr.IsPrivate.
+var carrierGradeNAT = netip.MustParsePrefix("100.64.0.0/10")
+
+// Forbidden reports whether ip is an address user-supplied URLs may not
+// reach.
+func Forbidden(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() ||
+		carrierGradeNAT.Contains(ip)
+}
+
+// Control is a net.Dialer Control function that refuses connections to
+// forbidden addresses. It runs after name resolution, for every address
+// tried, so a host name cannot be pointed at an internal address.
+func Control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if Forbidden(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// NewClient returns a client that does not follow redirects, ignores proxy
+// settings and, unless allowPrivate is set, refuses forbidden addresses.
+// allowPrivate is meant for development, where endpoints are often local.
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = Control
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,177 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Entry is a single feed outline. Folder is the title of the innermost
+// enclosing outline that is not itself a feed, and Line is where the outline
+// starts in the source document.
+type Entry struct {
+	Title   string
+	XMLURL  string
+	HTMLURL string
+	Folder  string
+	Line    int
+}
+
+type document struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    head     `xml:"head"`
+	Body    body     `xml:"body"`
+}
+
+type head struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated"`
+}
+
+type body struct {
+	Outlines []outline `xml:"outline"`
+}
+
+type outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []outline `xml:"outline"`
+}
+
+// Parse reads every feed outline in an OPML document, at any nesting depth.
+// Outlines with neither a title nor an xmlUrl are still returned so callers
+// can report them.
+func Parse(r io.Reader) ([]Entry, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+
+	var (
+		entries []Entry
+		folders []string
+		isFeed  []bool
+		sawOPML bool
+	)
+
+	for {
+		line, _ := decoder.InputPos()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid OPML at line %d: %w", line, err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			if strings.EqualFold(element.Name.Local, "opml") {
+				sawOPML = true
+			}
+			if !strings.EqualFold(element.Name.Local, "outline") {
+				continue
+			}
+
+			attrs := outlineAttrs(element.Attr)
+			title := attrs["title"]
+			if title == "" {
+				title = attrs["text"]
+			}
+
+			if attrs["xmlurl"] == "" && !strings.EqualFold(attrs["type"], "rss") {
+				folders = append(folders, strings.TrimSpace(title))
+				isFeed = append(isFeed, false)
+				continue
+			}
+
+			entry := Entry{
+				Title:   strings.TrimSpace(title),
+				XMLURL:  strings.TrimSpace(attrs["xmlurl"]),
+				HTMLURL: strings.TrimSpace(attrs["htmlurl"]),
+				Line:    line,
+			}
+			for i := len(folders) - 1; i >= 0; i-- {
+				if !isFeed[i] && folders[i] != "" {
+					entry.Folder = folders[i]
+					break
+				}
+			}
+			entries = append(entries, entry)
+			folders = append(folders, "")
+			isFeed = append(isFeed, true)
+		case xml.EndElement:
+			if strings.EqualFold(element.Name.Local, "outline") && len(folders) > 0 {
+				folders = folders[:len(folders)-1]
+				isFeed = isFeed[:len(isFeed)-1]
+			}
+		}
+	}
+
+	if !sawOPML {
+		return nil, fmt.Errorf("not an OPML document")
+	}
+	return entries, nil
+}
+
+// outlineAttrs indexes attributes by lower-cased name, since exporters
+// disagree on the case of xmlUrl and htmlUrl.
+func outlineAttrs(attrs []xml.Attr) map[string]string {
+	values := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		values[strings.ToLower(attr.Name.Local)] = attr.Value
+	}
+	return values
+}
+
+// Write encodes entries as an OPML 2.0 document. Entries sharing a Folder
+// are nested under one outline per folder, in order of first appearance;
+// entries without a folder sit at the top level.
+func Write(w io.Writer, title string, entries []Entry) error {
+	doc := document{
+		Version: "2.0",
+		Head: head{
+			Title:       title,
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	folderIndex := make(map[string]int)
+	for _, entry := range entries {
+		feed := outline{
+			Text:    entry.Title,
+			Title:   entry.Title,
+			Type:    "rss",
+			XMLURL:  entry.XMLURL,
+			HTMLURL: entry.HTMLURL,
+		}
+
+		if entry.Folder == "" {
+			doc.Body.Outlines = append(doc.Body.Outlines, feed)
+			continue
+		}
+
+		index, ok := folderIndex[entry.Folder]
+		if !ok {
+			index = len(doc.Body.Outlines)
+			folderIndex[entry.Folder] = index
+			doc.Body.Outlines = append(doc.Body.Outlines, outline{Text: entry.Folder, Title: entry.Folder})
+		}
+		doc.Body.Outlines[index].Outlines = append(doc.Body.Outlines[index].Outlines, feed)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode OPML: %w", err)
+	}
+	return encoder.Flush()
+}
@@ -5,20 +5,33 @@ import (
 	"time"
 )
 
-type Limiter struct {
+// Limiter allows at most maxAttempts per key within a sliding window.
+// Callers give each use its own Limiter, so keys only need to be unique
+// within it.
+type Limiter interface {
+	Allow(key string, maxAttempts int, window time.Duration) bool
+	// RetryAfter returns how long until Allow would next succeed for key,
+	// or zero if it would now.
+	RetryAfter(key string, maxAttempts int, window time.Duration) time.Duration
+	Reset(key string)
+}
+
+// MemoryLimiter keeps attempts in process memory. Limits reset on restart
+// and are not shared between instances; see PostgresLimiter for that.
+type MemoryLimiter struct {
 	attempts map[string][]time.Time
 	mu       sync.RWMutex
 }
 
-func NewLimiter() *Limiter {
-	l := &Limiter{
+func NewMemoryLimiter() *MemoryLimiter {
+	l := &MemoryLimiter{
 		attempts: make(map[string][]time.Time),
 	}
 	go l.cleanup()
 	return l
 }
 
-func (l *Limiter) Allow(key string, maxAttempts int, window time.Duration) bool {
+func (l *MemoryLimiter) Allow(key string, maxAttempts int, window time.Duration) bool {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -48,13 +61,36 @@ func (l *Limiter) Allow(key string, maxAttempts int, window time.Duration) bool
 	return true
 }
 
-func (l *Limiter) Reset(key string) {
+func (l *MemoryLimiter) RetryAfter(key string, maxAttempts int, window time.Duration) time.Duration {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	now := time.Now()
+	cutoff := now.Add(-window)
+
+	var validAttempts []time.Time
+	for _, timestamp := range l.attempts[key] {
+		if timestamp.After(cutoff) {
+			validAttempts = append(validAttempts, timestamp)
+		}
+	}
+
+	if maxAttempts <= 0 || len(validAttempts) < maxAttempts {
+		return 0
+	}
+
+	// Attempts are in order, so this is the one that has to leave the
+	// window before another fits.
+	return validAttempts[len(validAttempts)-maxAttempts].Add(window).Sub(now)
+}
+
+func (l *MemoryLimiter) Reset(key string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	delete(l.attempts, key)
 }
 
-func (l *Limiter) cleanup() {
+func (l *MemoryLimiter) cleanup() {
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
 
@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// attemptRetention bounds how long attempts are kept, and so the longest
+// window a limiter can enforce, as with MemoryLimiter.
+const attemptRetention = 24 * time.Hour
+
+// PostgresLimiter keeps attempts in the rate_limit_attempts table so limits
+// survive restarts and apply across every instance sharing the database.
+// Attempts for one key are serialised with a transaction-scoped advisory
+// lock, so concurrent requests on different instances cannot both take the
+// last free attempt.
+type PostgresLimiter struct {
+	db    *sql.DB
+	scope string
+}
+
+// NewPostgresLimiter stores attempts under scope, which keeps limiters that
+// use the same keys, such as email addresses, apart.
+func NewPostgresLimiter(db *sql.DB, scope string) *PostgresLimiter {
+	l := &PostgresLimiter{
+		db:    db,
+		scope: scope,
+	}
+	go l.cleanup()
+	return l
+}
+
+// Allow fails closed: if the database cannot be reached the attempt is
+// refused, since the request would not get far without it anyway.
+func (l *PostgresLimiter) Allow(key string, maxAttempts int, window time.Duration) bool {
+	allowed, err := l.allow(key, maxAttempts, window)
+	if err != nil {
+		log.Printf("Warning: rate limiter %s failed, refusing attempt: %v", l.scope, err)
+		return false
+	}
+	return allowed
+}
+
+func (l *PostgresLimiter) allow(key string, maxAttempts int, window time.Duration) (bool, error) {
+	tx, err := l.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1 || ':' || $2))`, l.scope, key); err != nil {
+		return false, fmt.Errorf("failed to lock rate limit key: %w", err)
+	}
+
+	var attempts int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM rate_limit_attempts
+		WHERE scope = $1 AND key = $2 AND attempted_at > NOW() - make_interval(secs => $3)`,
+		l.scope, key, window.Seconds(),
+	).Scan(&attempts)
+	if err != nil {
+		return false, fmt.Errorf("failed to count attempts: %w", err)
+	}
+
+	if attempts >= maxAttempts {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(`INSERT INTO rate_limit_attempts (scope, key) VALUES ($1, $2)`, l.scope, key); err != nil {
+		return false, fmt.Errorf("failed to record attempt: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+func (l *PostgresLimiter) RetryAfter(key string, maxAttempts int, window time.Duration) time.Duration {
+	if maxAttempts <= 0 {
+		return 0
+	}
+
+	// The maxAttempts-th most recent attempt is the one that has to leave
+	// the window before another fits.
+	var seconds float64
+	err := l.db.QueryRow(`
+		SELECT EXTRACT(EPOCH FROM attempted_at + make_interval(secs => $3) - NOW())
+		FROM rate_limit_attempts
+		WHERE scope = $1 AND key = $2 AND attempted_at > NOW() - make_interval(secs => $3)
+		ORDER BY attempted_at DESC
+		OFFSET $4 LIMIT 1`,
+		l.scope, key, window.Seconds(), maxAttempts-1,
+	).Scan(&seconds)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Warning: rate limiter %s failed to compute retry time: %v", l.scope, err)
+			return window
+		}
+		return 0
+	}
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func (l *PostgresLimiter) Reset(key string) {
+	if _, err := l.db.Exec(`DELETE FROM rate_limit_attempts WHERE scope = $1 AND key = $2`, l.scope, key); err != nil {
+		log.Printf("Warning: failed to reset rate limiter %s: %v", l.scope, err)
+	}
+}
+
+func (l *PostgresLimiter) cleanup() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		_, err := l.db.Exec(`
+			DELETE FROM rate_limit_attempts
+			WHERE scope = $1 AND attempted_at < NOW() - make_interval(secs => $2)`,
+			l.scope, attemptRetention.Seconds(),
+		)
+		if err != nil {
+			log.Printf("Warning: failed to clean up rate limiter %s: %v", l.scope, err)
+		}
+	}
+}
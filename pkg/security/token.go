@@ -0,0 +1,33 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+type TokenGenerator struct{}
+
+func NewTokenGenerator() *TokenGenerator {
+	return &TokenGenerator{}
+}
+
+// Generate returns a random URL-safe token with the given prefix, carrying
+// 256 bits of entropy.
+func (g *TokenGenerator) Generate(prefix string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+
+	return prefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex SHA-256 of a token. Tokens are high-entropy, so a
+// fast hash is sufficient for lookup without storing them in plaintext.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,69 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"rss-reader/pkg/netguard"
+	"strconv"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-FeedStream-Signature"
+	EventHeader     = "X-FeedStream-Event"
+	DeliveryHeader  = "X-FeedStream-Delivery"
+)
+
+type Sender struct {
+	client    *http.Client
+	userAgent string
+}
+
+// NewSender delivers webhooks without following redirects. Unless
+// allowPrivate is set, endpoints that resolve to loopback, private or
+// link-local addresses are refused when connecting.
+func NewSender(allowPrivate bool) *Sender {
+	return &Sender{
+		client:    netguard.NewClient(10*time.Second, allowPrivate),
+		userAgent: "FeedStream-Webhook/1.0",
+	}
+}
+
+// Sign returns the signature header value for body: "sha256=" followed by
+// the hex HMAC-SHA256 of the body keyed with the webhook secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send POSTs a signed JSON body and returns the response status. Any status
+// outside 2xx is returned as an error alongside the status code.
+func (s *Sender) Send(url, secret, event string, deliveryID int, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", s.userAgent)
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(deliveryID))
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
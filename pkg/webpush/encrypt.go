@@ -0,0 +1,150 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	recordSize = 4096
+	// MaxPayloadSize keeps the whole body, including the 86-byte header,
+	// the padding delimiter and the AES-GCM tag, within the 4096 bytes push
+	// services are required to accept.
+	MaxPayloadSize = recordSize - 86 - 17
+)
+
+// hkdf is RFC 5869 HKDF-SHA256 for outputs of at most one hash block, which
+// is all RFC 8291 needs.
+func hkdf(salt, ikm, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(ikm)
+	prk := extract.Sum(nil)
+
+	expand := hmac.New(sha256.New, prk)
+	expand.Write(info)
+	expand.Write([]byte{1})
+	return expand.Sum(nil)[:length]
+}
+
+// contentKeys derives the aes128gcm content encryption key and nonce shared
+// by the application server and the user agent (RFC 8291 section 3.4).
+func contentKeys(ecdhSecret, authSecret, uaPublic, asPublic, salt []byte) ([]byte, []byte) {
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublic...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm := hkdf(authSecret, ecdhSecret, keyInfo, 32)
+
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+	return cek, nonce
+}
+
+// Encrypt encrypts payload for a subscription with the aes128gcm content
+// coding (RFC 8188) as profiled for Web Push (RFC 8291). p256dh and auth are
+// the base64url keys from the browser's PushSubscription.
+func Encrypt(payload []byte, p256dh, auth string) ([]byte, error) {
+	if len(payload) > MaxPayloadSize {
+		return nil, fmt.Errorf("push payload of %d bytes exceeds %d", len(payload), MaxPayloadSize)
+	}
+
+	uaPublicBytes, err := b64.DecodeString(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := b64.DecodeString(auth)
+	if err != nil || len(authSecret) == 0 {
+		return nil, fmt.Errorf("invalid auth secret")
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ephemeral key: %w", err)
+	}
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("key agreement failed: %w", err)
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	asPublic := asPrivate.PublicKey().Bytes()
+	cek, nonce := contentKeys(ecdhSecret, authSecret, uaPublicBytes, asPublic, salt)
+
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	body.Write(salt)
+	binary.Write(&body, binary.BigEndian, uint32(recordSize))
+	body.WriteByte(byte(len(asPublic)))
+	body.Write(asPublic)
+
+	// A single, final record: the content followed by the 0x02 delimiter.
+	plaintext := append(append([]byte{}, payload...), 2)
+	body.Write(gcm.Seal(nil, nonce, plaintext, nil))
+	return body.Bytes(), nil
+}
+
+// Decrypt reverses Encrypt for the holder of the subscription's private key.
+// Browsers do this themselves; it exists for the local push service.
+func Decrypt(body []byte, uaPrivate *ecdh.PrivateKey, authSecret []byte) ([]byte, error) {
+	if len(body) < 21 {
+		return nil, fmt.Errorf("push body too short")
+	}
+	salt := body[:16]
+	idLen := int(body[20])
+	if len(body) < 21+idLen {
+		return nil, fmt.Errorf("push body too short")
+	}
+	asPublicBytes := body[21 : 21+idLen]
+	ciphertext := body[21+idLen:]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender key: %w", err)
+	}
+	ecdhSecret, err := uaPrivate.ECDH(asPublic)
+	if err != nil {
+		return nil, fmt.Errorf("key agreement failed: %w", err)
+	}
+
+	cek, nonce := contentKeys(ecdhSecret, authSecret, uaPrivate.PublicKey().Bytes(), asPublicBytes, salt)
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt push payload: %w", err)
+	}
+
+	end := bytes.LastIndexByte(plaintext, 2)
+	if end < 0 || len(bytes.Trim(plaintext[end+1:], "\x00")) != 0 {
+		return nil, fmt.Errorf("missing padding delimiter")
+	}
+	return plaintext[:end], nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,124 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"strings"
+	"testing"
+)
+
+// Test vector from RFC 8291, Appendix A.
+const (
+	rfcPlaintext  = "When I grow up, I want to be a watermelon"
+	rfcASPrivate  = "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"
+	rfcASPublic   = "BP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A8"
+	rfcUAPrivate  = "q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94"
+	rfcUAPublic   = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+	rfcAuthSecret = "BTBZMqHH6r4Tts7J_aSIgg"
+	rfcSalt       = "DGv6ra1nlYgDCS1FRnbzlw"
+	rfcECDH       = "kyrL1jIIOHEzg3sM2ZWRHDRB62YACZhhSlknJ672kSs"
+	rfcCEK        = "oIhVW04MRdy2XN9CiKLxTg"
+	rfcNonce      = "4h_95klXJ5E_qnoN"
+	rfcMessage    = "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+)
+
+func decodeB64(t *testing.T, value string) []byte {
+	t.Helper()
+	decoded, err := b64.DecodeString(value)
+	if err != nil {
+		t.Fatalf("decoding %q: %v", value, err)
+	}
+	return decoded
+}
+
+func rfcPrivateKey(t *testing.T, private, public string) *ecdh.PrivateKey {
+	t.Helper()
+	key, err := ecdh.P256().NewPrivateKey(decodeB64(t, private))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.PublicKey().Bytes(), decodeB64(t, public)) {
+		t.Fatalf("public key of %s does not match the test vector", private)
+	}
+	return key
+}
+
+func TestContentKeysRFC8291(t *testing.T) {
+	asPrivate := rfcPrivateKey(t, rfcASPrivate, rfcASPublic)
+	uaPrivate := rfcPrivateKey(t, rfcUAPrivate, rfcUAPublic)
+
+	ecdhSecret, err := asPrivate.ECDH(uaPrivate.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ecdhSecret, decodeB64(t, rfcECDH)) {
+		t.Fatalf("ECDH secret = %s, want %s", b64.EncodeToString(ecdhSecret), rfcECDH)
+	}
+
+	cek, nonce := contentKeys(ecdhSecret, decodeB64(t, rfcAuthSecret), decodeB64(t, rfcUAPublic),
+		decodeB64(t, rfcASPublic), decodeB64(t, rfcSalt))
+	if got := b64.EncodeToString(cek); got != rfcCEK {
+		t.Errorf("CEK = %s, want %s", got, rfcCEK)
+	}
+	if got := b64.EncodeToString(nonce); got != rfcNonce {
+		t.Errorf("nonce = %s, want %s", got, rfcNonce)
+	}
+}
+
+func TestDecryptRFC8291(t *testing.T) {
+	uaPrivate := rfcPrivateKey(t, rfcUAPrivate, rfcUAPublic)
+
+	plaintext, err := Decrypt(decodeB64(t, rfcMessage), uaPrivate, decodeB64(t, rfcAuthSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plaintext) != rfcPlaintext {
+		t.Errorf("plaintext = %q, want %q", plaintext, rfcPlaintext)
+	}
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	uaPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+
+	p256dh := b64.EncodeToString(uaPrivate.PublicKey().Bytes())
+	auth := b64.EncodeToString(authSecret)
+
+	for _, payload := range [][]byte{
+		{},
+		[]byte(`{"title":"New item","body":"\u0002 inside"}`),
+		bytes.Repeat([]byte{2}, 16),
+		[]byte(strings.Repeat("x", MaxPayloadSize)),
+	} {
+		body, err := Encrypt(payload, p256dh, auth)
+		if err != nil {
+			t.Fatalf("encrypting %d bytes: %v", len(payload), err)
+		}
+		if len(body) > recordSize {
+			t.Errorf("body of %d bytes exceeds the %d byte record", len(body), recordSize)
+		}
+
+		plaintext, err := Decrypt(body, uaPrivate, authSecret)
+		if err != nil {
+			t.Fatalf("decrypting %d bytes: %v", len(payload), err)
+		}
+		if !bytes.Equal(plaintext, payload) {
+			t.Errorf("round trip of %d bytes returned %q", len(payload), plaintext)
+		}
+
+		wrongSecret := append([]byte{}, authSecret...)
+		wrongSecret[0] ^= 1
+		if _, err := Decrypt(body, uaPrivate, wrongSecret); err == nil {
+			t.Errorf("decrypting %d bytes with the wrong auth secret succeeded", len(payload))
+		}
+	}
+
+	if _, err := Encrypt(make([]byte, MaxPayloadSize+1), p256dh, auth); err == nil {
+		t.Error("oversized payload was encrypted")
+	}
+}
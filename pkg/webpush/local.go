@@ -0,0 +1,254 @@
+package webpush
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxLocalMessages = 50
+
+// LocalMessage is a push received and decrypted by the LocalService.
+type LocalMessage struct {
+	Endpoint   string          `json:"endpoint"`
+	ReceivedAt time.Time       `json:"received_at"`
+	TTL        string          `json:"ttl"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+type localDevice struct {
+	privateKey *ecdh.PrivateKey
+	authSecret []byte
+}
+
+// LocalService stands in for both a browser and its push service during
+// development. It hands out subscriptions whose endpoints point back at
+// itself, checks the VAPID signature on every push it receives, decrypts the
+// payload with the device's keys and keeps the most recent messages.
+type LocalService struct {
+	mutex    sync.Mutex
+	baseURL  string
+	vapidKey string
+	devices  map[string]*localDevice
+	messages []LocalMessage
+}
+
+// NewLocalService serves endpoints under baseURL + "/dev/push/" and accepts
+// pushes signed with the given VAPID public key.
+func NewLocalService(baseURL, vapidPublicKey string) *LocalService {
+	return &LocalService{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		vapidKey: vapidPublicKey,
+		devices:  make(map[string]*localDevice),
+	}
+}
+
+// NewSubscription creates a simulated device. Devices live in memory and
+// are forgotten on restart, after which pushes to them get 410 Gone.
+func (l *LocalService) NewSubscription() (Subscription, error) {
+	privateKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("failed to generate device key: %w", err)
+	}
+
+	authSecret := make([]byte, 16)
+	id := make([]byte, 16)
+	if _, err := rand.Read(authSecret); err != nil {
+		return Subscription{}, fmt.Errorf("failed to generate auth secret: %w", err)
+	}
+	if _, err := rand.Read(id); err != nil {
+		return Subscription{}, fmt.Errorf("failed to generate device ID: %w", err)
+	}
+
+	endpoint := l.baseURL + "/dev/push/" + b64.EncodeToString(id)
+
+	l.mutex.Lock()
+	l.devices[endpoint] = &localDevice{privateKey: privateKey, authSecret: authSecret}
+	l.mutex.Unlock()
+
+	return Subscription{
+		Endpoint: endpoint,
+		P256dh:   b64.EncodeToString(privateKey.PublicKey().Bytes()),
+		Auth:     b64.EncodeToString(authSecret),
+	}, nil
+}
+
+// Messages returns the received pushes, newest first.
+func (l *LocalService) Messages() []LocalMessage {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	messages := make([]LocalMessage, len(l.messages))
+	for i, message := range l.messages {
+		messages[len(l.messages)-1-i] = message
+	}
+	return messages
+}
+
+// ServeHTTP accepts pushes with POST /dev/push/<device> and lists received
+// messages as JSON with GET /dev/push.
+func (l *LocalService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(l.Messages())
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	endpoint := l.baseURL + r.URL.Path
+	l.mutex.Lock()
+	device, ok := l.devices[endpoint]
+	l.mutex.Unlock()
+	if !ok {
+		http.Error(w, "Unknown subscription", http.StatusGone)
+		return
+	}
+
+	if err := l.verifyAuthorization(r.Header.Get("Authorization")); err != nil {
+		log.Printf("Local push service rejected push: %v", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get("Content-Encoding") != "aes128gcm" {
+		http.Error(w, "Unsupported content encoding", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, recordSize+1))
+	if err != nil || len(body) > recordSize {
+		http.Error(w, "Payload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	payload, err := Decrypt(body, device.privateKey, device.authSecret)
+	if err != nil {
+		log.Printf("Local push service could not decrypt push: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !json.Valid(payload) {
+		payload, _ = json.Marshal(string(payload))
+	}
+
+	l.mutex.Lock()
+	l.messages = append(l.messages, LocalMessage{
+		Endpoint:   endpoint,
+		ReceivedAt: time.Now(),
+		TTL:        r.Header.Get("TTL"),
+		Payload:    payload,
+	})
+	if len(l.messages) > maxLocalMessages {
+		l.messages = l.messages[len(l.messages)-maxLocalMessages:]
+	}
+	l.mutex.Unlock()
+
+	log.Printf("Local push service received push for %s: %s", endpoint, payload)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// verifyAuthorization checks a "vapid t=<jwt>, k=<key>" header the way a
+// push service would: the key must be the expected one, the ES256 signature
+// must verify and the token must be unexpired and addressed to this origin.
+func (l *LocalService) verifyAuthorization(header string) error {
+	params, found := strings.CutPrefix(header, "vapid ")
+	if !found {
+		return fmt.Errorf("missing VAPID authorization")
+	}
+
+	var token, key string
+	for _, part := range strings.Split(params, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch name {
+		case "t":
+			token = value
+		case "k":
+			key = value
+		}
+	}
+	if key != l.vapidKey {
+		return fmt.Errorf("unexpected VAPID key")
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed VAPID token")
+	}
+
+	publicKey, err := parseECDSAPublicKey(key)
+	if err != nil {
+		return err
+	}
+	signature, err := b64.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		return fmt.Errorf("malformed VAPID signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(publicKey, digest[:], r, s) {
+		return fmt.Errorf("invalid VAPID signature")
+	}
+
+	rawClaims, err := b64.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("malformed VAPID claims")
+	}
+	var claims struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	if err := json.Unmarshal(rawClaims, &claims); err != nil {
+		return fmt.Errorf("malformed VAPID claims")
+	}
+	if !strings.HasPrefix(l.baseURL, claims.Aud) || claims.Aud == "" {
+		return fmt.Errorf("VAPID audience %q does not match %s", claims.Aud, l.baseURL)
+	}
+	if time.Unix(claims.Exp, 0).Before(time.Now()) {
+		return fmt.Errorf("VAPID token expired")
+	}
+	if claims.Sub == "" {
+		return fmt.Errorf("VAPID token has no subject")
+	}
+	return nil
+}
+
+func parseECDSAPublicKey(key string) (*ecdsa.PublicKey, error) {
+	raw, err := b64.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID key: %w", err)
+	}
+	point, err := ecdh.P256().NewPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID key: %w", err)
+	}
+
+	// x509 converts between the ecdh and ecdsa representations.
+	der, err := x509.MarshalPKIXPublicKey(point)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID key: %w", err)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID key: %w", err)
+	}
+	publicKey, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid VAPID key")
+	}
+	return publicKey, nil
+}
@@ -0,0 +1,85 @@
+package webpush
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"rss-reader/pkg/netguard"
+	"strconv"
+	"time"
+)
+
+// ErrSubscriptionGone means the push service no longer knows the
+// subscription; it should be deleted.
+var ErrSubscriptionGone = errors.New("push subscription expired or unsubscribed")
+
+// Subscription is the part of a browser PushSubscription needed to send to
+// it.
+type Subscription struct {
+	Endpoint string
+	P256dh   string
+	Auth     string
+}
+
+type Sender struct {
+	client  *http.Client
+	keys    *VAPIDKeys
+	subject string
+}
+
+// NewSender sends pushes signed with keys. subject is the contact URL or
+// mailto: address push services see in the VAPID token. Unless allowPrivate
+// is set, endpoints that resolve to loopback, private or link-local
+// addresses are refused when connecting.
+func NewSender(keys *VAPIDKeys, subject string, allowPrivate bool) *Sender {
+	return &Sender{
+		client:  netguard.NewClient(10*time.Second, allowPrivate),
+		keys:    keys,
+		subject: subject,
+	}
+}
+
+func (s *Sender) PublicKey() string {
+	return s.keys.PublicKey
+}
+
+// Send encrypts payload for the subscription and delivers it. ttl is how
+// long the push service should hold the message for an offline device.
+func (s *Sender) Send(subscription Subscription, payload []byte, ttl time.Duration) error {
+	body, err := Encrypt(payload, subscription.P256dh, subscription.Auth)
+	if err != nil {
+		return err
+	}
+
+	authorization, err := s.keys.authorization(subscription.Endpoint, s.subject, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid push request: %w", err)
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(ttl.Seconds())))
+	req.Header.Set("Urgency", "normal")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrSubscriptionGone
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("push service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package webpush
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// VAPIDKeys identify this server to push services (RFC 8292). PublicKey is
+// the uncompressed P-256 point that browsers take as applicationServerKey;
+// PrivateKey is the PKCS #8 encoding. Both are unpadded base64url.
+type VAPIDKeys struct {
+	PublicKey  string
+	PrivateKey string
+}
+
+var b64 = base64.RawURLEncoding
+
+func GenerateVAPIDKeys() (*VAPIDKeys, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate VAPID key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode VAPID key: %w", err)
+	}
+
+	public, err := key.PublicKey.ECDH()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode VAPID public key: %w", err)
+	}
+
+	return &VAPIDKeys{
+		PublicKey:  b64.EncodeToString(public.Bytes()),
+		PrivateKey: b64.EncodeToString(der),
+	}, nil
+}
+
+func (k *VAPIDKeys) signingKey() (*ecdsa.PrivateKey, error) {
+	der, err := b64.DecodeString(k.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok || key.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("VAPID private key is not a P-256 key")
+	}
+	return key, nil
+}
+
+// authorization returns the Authorization header for a push to endpoint: a
+// short-lived ES256 JWT for the endpoint's origin plus the public key.
+func (k *VAPIDKeys) authorization(endpoint, subject string, now time.Time) (string, error) {
+	target, err := url.Parse(endpoint)
+	if err != nil || target.Host == "" {
+		return "", fmt.Errorf("invalid push endpoint %q", endpoint)
+	}
+
+	key, err := k.signingKey()
+	if err != nil {
+		return "", err
+	}
+
+	header := b64.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": target.Scheme + "://" + target.Host,
+		"exp": now.Add(12 * time.Hour).Unix(),
+		"sub": subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + b64.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	return "vapid t=" + unsigned + "." + b64.EncodeToString(signature) + ", k=" + k.PublicKey, nil
+}
@@ -1,89 +1,159 @@
 document.addEventListener("DOMContentLoaded", function () {
-    const feedFilter = document.getElementById('feed-filter');
-    const loadMoreBtn = document.getElementById("load-more-btn");
-    const loading = document.getElementById("loading");
+    const filterForm = document.getElementById("filter-form");
     const feedContent = document.getElementById("feed-content");
+    const csrfToken = document.querySelector('meta[name="csrf-token"]')?.content || "";
 
-    if (feedFilter) {
-        const savedFilter = localStorage.getItem('selectedFeed');
-        if (savedFilter) {
-            feedFilter.value = savedFilter;
-            applyFeedFilter(savedFilter);
-        }
+    if (filterForm) {
+        // Drop empty fields so shared URLs only carry the active filters.
+        filterForm.addEventListener("submit", function () {
+            filterForm.querySelectorAll("select, input").forEach((field) => {
+                if (field.value === "") {
+                    field.disabled = true;
+                }
+            });
+        });
 
-        feedFilter.addEventListener('change', function() {
-            const selectedFeed = this.value;
-            localStorage.setItem('selectedFeed', selectedFeed);
-            applyFeedFilter(selectedFeed);
+        filterForm.querySelectorAll("select").forEach((select) => {
+            select.addEventListener("change", () => filterForm.requestSubmit());
         });
     }
 
-    function applyFeedFilter(selectedFeed) {
-        const feedItems = document.querySelectorAll('.feed-item');
-        const dateSections = document.querySelectorAll('.date-section');
-        
-        feedItems.forEach(item => {
-            const feedName = item.getAttribute('data-feed-name');
-            if (selectedFeed === 'all' || feedName === selectedFeed) {
-                item.style.display = '';
-            } else {
-                item.style.display = 'none';
+    function updateItem(item, action, field, value) {
+        const body = new URLSearchParams();
+        body.set(field, value);
+
+        return fetch(`/feeds/items/${item.dataset.itemId}/${action}`, {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/x-www-form-urlencoded",
+                "X-CSRF-Token": csrfToken,
+            },
+            body: body,
+        }).then((response) => {
+            if (!response.ok) {
+                throw new Error(`HTTP ${response.status}`);
             }
+            return response.json();
         });
-        
-        dateSections.forEach(section => {
-            const visibleItems = section.querySelectorAll('.feed-item:not([style*="display: none"])');
-            if (selectedFeed === 'all' || visibleItems.length > 0) {
-                section.style.display = '';
-            } else {
-                section.style.display = 'none';
-            }
+    }
+
+    function setReadState(item, read) {
+        const button = item.querySelector(".toggle-read");
+        return updateItem(item, "read", "read", read).then(() => {
+            item.classList.toggle("read", read);
+            button.dataset.read = String(read);
+            button.textContent = read ? "mark unread" : "mark read";
         });
     }
 
-    if (loadMoreBtn) {
-        loadMoreBtn.addEventListener("click", function () {
-            const nextOffset = this.getAttribute("data-next-offset");
-
-            this.style.display = "none";
-            loading.style.display = "block";
-
-            fetch(`/feeds?days=${nextOffset}`)
-                .then((response) => response.text())
-                .then((html) => {
-                    const parser = new DOMParser();
-                    const doc = parser.parseFromString(html, "text/html");
-                    const newContent = doc.querySelector("#feed-content");
-
-                    if (newContent) {
-                        const dateSections = newContent.querySelectorAll(".date-section");
-                        const newLoadMoreSection = newContent.querySelector(".load-more-section");
-
-                        dateSections.forEach((section) => {
-                            feedContent.insertBefore(
-                                section,
-                                document.querySelector(".load-more-section")
-                            );
-                        });
-
-                        const currentLoadMoreSection = document.querySelector(".load-more-section");
-                        if (newLoadMoreSection) {
-                            currentLoadMoreSection.replaceWith(newLoadMoreSection);
-                            const newBtn = document.getElementById("load-more-btn");
-                            if (newBtn) {
-                                newBtn.addEventListener("click", arguments.callee);
-                            }
-                        } else {
-                            currentLoadMoreSection.remove();
-                        }
-                    }
+    feedContent.addEventListener("click", function (event) {
+        const item = event.target.closest(".feed-item");
+        if (!item) {
+            return;
+        }
+
+        if (event.target.classList.contains("item-link")) {
+            if (!item.classList.contains("read")) {
+                setReadState(item, true).catch((error) => console.error("Error marking item read:", error));
+            }
+            return;
+        }
+
+        if (event.target.classList.contains("toggle-read")) {
+            const read = event.target.dataset.read !== "true";
+            setReadState(item, read).catch((error) => console.error("Error updating read state:", error));
+            return;
+        }
+
+        if (event.target.classList.contains("toggle-star")) {
+            const button = event.target;
+            const starred = button.dataset.starred !== "true";
+            updateItem(item, "star", "starred", starred)
+                .then(() => {
+                    button.dataset.starred = String(starred);
+                    button.textContent = starred ? "★ starred" : "☆ star";
                 })
-                .catch((error) => {
-                    console.error("Error loading more items:", error);
-                    loading.style.display = "none";
-                    loadMoreBtn.style.display = "block";
-                    loadMoreBtn.textContent = "Error - Try Again";
+                .catch((error) => console.error("Error updating starred state:", error));
+        }
+    });
+
+    function loadNextPage(section) {
+        if (section.dataset.loading === "true") {
+            return;
+        }
+        section.dataset.loading = "true";
+
+        const button = section.querySelector(".load-more-btn");
+        const indicator = section.querySelector(".loading-indicator");
+        button.style.display = "none";
+        indicator.style.display = "block";
+
+        const params = new URLSearchParams(section.dataset.filter || "");
+        params.set("cursor", section.dataset.nextCursor);
+
+        fetch(`/feeds/items?${params.toString()}`)
+            .then((response) => {
+                if (!response.ok) {
+                    throw new Error(`HTTP ${response.status}`);
+                }
+                return response.text();
+            })
+            .then((html) => {
+                const fragment = document.createElement("template");
+                fragment.innerHTML = html;
+
+                fragment.content.querySelectorAll(".date-section").forEach((newSection) => {
+                    const existing = feedContent.querySelector(
+                        `.date-section[data-date="${newSection.dataset.date}"]`
+                    );
+                    if (existing) {
+                        const grid = existing.querySelector(".feed-items-grid");
+                        newSection.querySelectorAll(".feed-item").forEach((item) => grid.appendChild(item));
+                    } else {
+                        feedContent.insertBefore(newSection, section);
+                    }
                 });
-        });
+
+                if (observer) {
+                    observer.unobserve(section);
+                }
+
+                const nextSection = fragment.content.querySelector(".load-more-section");
+                if (nextSection) {
+                    section.replaceWith(nextSection);
+                    observeLoadMore(nextSection);
+                } else {
+                    section.remove();
+                }
+            })
+            .catch((error) => {
+                console.error("Error loading more items:", error);
+                section.dataset.loading = "false";
+                indicator.style.display = "none";
+                button.style.display = "inline";
+                button.textContent = "Error - Try Again";
+            });
+    }
+
+    const observer = "IntersectionObserver" in window
+        ? new IntersectionObserver((entries) => {
+            entries.forEach((entry) => {
+                if (entry.isIntersecting) {
+                    loadNextPage(entry.target);
+                }
+            });
+        }, { rootMargin: "400px" })
+        : null;
+
+    function observeLoadMore(section) {
+        section.querySelector(".load-more-btn").addEventListener("click", () => loadNextPage(section));
+        if (observer) {
+            observer.observe(section);
+        }
+    }
+
+    const loadMoreSection = document.querySelector(".load-more-section");
+    if (loadMoreSection) {
+        observeLoadMore(loadMoreSection);
     }
 });
\ No newline at end of file
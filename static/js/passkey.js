@@ -0,0 +1,171 @@
+// Passkey registration (/passkeys) and sign-in (/login). The server speaks
+// the WebAuthn JSON format with binary fields as base64url strings, which
+// the browser API wants as ArrayBuffers.
+document.addEventListener("DOMContentLoaded", function () {
+    const registerForm = document.getElementById("register-passkey");
+    const loginBox = document.getElementById("passkey-login");
+    const errorBox = document.getElementById("passkey-error");
+    const csrfToken = document.querySelector('meta[name="csrf-token"]')?.content || "";
+
+    if (!registerForm && !loginBox) {
+        return;
+    }
+
+    function showError(message) {
+        errorBox.textContent = message;
+        errorBox.hidden = false;
+    }
+
+    if (!window.PublicKeyCredential) {
+        if (registerForm) {
+            registerForm.querySelector("button").disabled = true;
+            showError("This browser does not support passkeys.");
+        }
+        return;
+    }
+
+    function decode(base64url) {
+        const padded = base64url + "=".repeat((4 - (base64url.length % 4)) % 4);
+        const raw = atob(padded.replace(/-/g, "+").replace(/_/g, "/"));
+        return Uint8Array.from(raw, (c) => c.charCodeAt(0)).buffer;
+    }
+
+    function encode(buffer) {
+        if (!buffer) {
+            return undefined;
+        }
+        const bytes = new Uint8Array(buffer);
+        let raw = "";
+        bytes.forEach((b) => (raw += String.fromCharCode(b)));
+        return btoa(raw).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+    }
+
+    function post(url, body) {
+        return fetch(url, {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/json",
+                "X-CSRF-Token": csrfToken,
+            },
+            body: body === undefined ? undefined : JSON.stringify(body),
+        }).then((response) =>
+            response.json().then((data) => {
+                if (!response.ok) {
+                    throw new Error(data.error || "Request failed.");
+                }
+                return data;
+            })
+        );
+    }
+
+    // A cancelled or timed-out browser prompt is not worth an error message.
+    function handleError(err) {
+        if (err.name !== "NotAllowedError" && err.name !== "AbortError") {
+            showError(err.message);
+        }
+    }
+
+    if (registerForm) {
+        registerForm.addEventListener("submit", function (event) {
+            event.preventDefault();
+            const button = registerForm.querySelector("button");
+            const name = registerForm.elements.name.value;
+            button.disabled = true;
+            errorBox.hidden = true;
+
+            post("/passkeys/register/begin")
+                .then((options) => {
+                    const publicKey = options.publicKey;
+                    publicKey.challenge = decode(publicKey.challenge);
+                    publicKey.user.id = decode(publicKey.user.id);
+                    (publicKey.excludeCredentials || []).forEach((credential) => {
+                        credential.id = decode(credential.id);
+                    });
+                    return navigator.credentials.create({ publicKey: publicKey });
+                })
+                .then((credential) =>
+                    post("/passkeys/register/finish?name=" + encodeURIComponent(name), {
+                        id: credential.id,
+                        rawId: encode(credential.rawId),
+                        type: credential.type,
+                        authenticatorAttachment: credential.authenticatorAttachment,
+                        clientExtensionResults: credential.getClientExtensionResults(),
+                        response: {
+                            clientDataJSON: encode(credential.response.clientDataJSON),
+                            attestationObject: encode(credential.response.attestationObject),
+                            transports: credential.response.getTransports ? credential.response.getTransports() : [],
+                        },
+                    })
+                )
+                .then((result) => {
+                    window.location.href = result.redirect;
+                })
+                .catch(handleError)
+                .finally(() => {
+                    button.disabled = false;
+                });
+        });
+    }
+
+    if (loginBox) {
+        const button = document.getElementById("passkey-login-button");
+        let pending = null;
+
+        // signIn asks the browser for a passkey. With mediation
+        // "conditional" the request waits quietly and the browser offers
+        // passkeys in the email field's autofill instead of a dialog.
+        function signIn(mediation) {
+            if (pending) {
+                pending.abort();
+            }
+            const controller = new AbortController();
+            pending = controller;
+
+            return post("/login/passkey/begin")
+                .then((options) => {
+                    const publicKey = options.publicKey;
+                    publicKey.challenge = decode(publicKey.challenge);
+                    (publicKey.allowCredentials || []).forEach((credential) => {
+                        credential.id = decode(credential.id);
+                    });
+                    return navigator.credentials.get({
+                        publicKey: publicKey,
+                        mediation: mediation,
+                        signal: controller.signal,
+                    });
+                })
+                .then((credential) =>
+                    post("/login/passkey/finish", {
+                        id: credential.id,
+                        rawId: encode(credential.rawId),
+                        type: credential.type,
+                        authenticatorAttachment: credential.authenticatorAttachment,
+                        clientExtensionResults: credential.getClientExtensionResults(),
+                        response: {
+                            clientDataJSON: encode(credential.response.clientDataJSON),
+                            authenticatorData: encode(credential.response.authenticatorData),
+                            signature: encode(credential.response.signature),
+                            userHandle: encode(credential.response.userHandle),
+                        },
+                    })
+                )
+                .then((result) => {
+                    window.location.href = result.redirect;
+                });
+        }
+
+        loginBox.hidden = false;
+        button.addEventListener("click", function () {
+            errorBox.hidden = true;
+            signIn("optional").catch(handleError);
+        });
+
+        if (PublicKeyCredential.isConditionalMediationAvailable) {
+            PublicKeyCredential.isConditionalMediationAvailable().then((available) => {
+                if (available) {
+                    signIn("conditional").catch(handleError);
+                }
+            });
+        }
+    }
+});
@@ -0,0 +1,22 @@
+self.addEventListener("push", function (event) {
+    let data = {};
+    try {
+        data = event.data ? event.data.json() : {};
+    } catch (error) {
+        data = { body: event.data.text() };
+    }
+
+    event.waitUntil(
+        self.registration.showNotification(data.title || "FeedStream", {
+            body: data.body || "",
+            tag: data.tag,
+            icon: "/static/favicon.ico",
+            data: { url: data.url || "/feeds" },
+        })
+    );
+});
+
+self.addEventListener("notificationclick", function (event) {
+    event.notification.close();
+    event.waitUntil(self.clients.openWindow(event.notification.data.url));
+});
@@ -0,0 +1,72 @@
+document.addEventListener("DOMContentLoaded", function () {
+    const button = document.getElementById("enable-push");
+    const errorBox = document.getElementById("push-error");
+    const csrfToken = document.querySelector('meta[name="csrf-token"]')?.content || "";
+
+    if (!button) {
+        return;
+    }
+
+    function showError(message) {
+        errorBox.textContent = message;
+        errorBox.hidden = false;
+    }
+
+    if (!("serviceWorker" in navigator) || !("PushManager" in window)) {
+        button.disabled = true;
+        showError("This browser does not support push notifications.");
+        return;
+    }
+
+    // applicationServerKey must be the raw bytes of the VAPID public key.
+    function decodeKey(base64url) {
+        const padded = base64url + "=".repeat((4 - (base64url.length % 4)) % 4);
+        const raw = atob(padded.replace(/-/g, "+").replace(/_/g, "/"));
+        return Uint8Array.from(raw, (c) => c.charCodeAt(0));
+    }
+
+    button.addEventListener("click", function () {
+        button.disabled = true;
+
+        Notification.requestPermission()
+            .then((permission) => {
+                if (permission !== "granted") {
+                    throw new Error("Notifications were not allowed for this site.");
+                }
+                return navigator.serviceWorker.register("/static/js/push-sw.js");
+            })
+            .then((registration) =>
+                registration.pushManager.subscribe({
+                    userVisibleOnly: true,
+                    applicationServerKey: decodeKey(button.dataset.publicKey),
+                })
+            )
+            .then((subscription) => {
+                const keys = subscription.toJSON().keys || {};
+                const body = new URLSearchParams();
+                body.set("endpoint", subscription.endpoint);
+                body.set("p256dh", keys.p256dh || "");
+                body.set("auth", keys.auth || "");
+
+                return fetch("/notifications/subscribe", {
+                    method: "POST",
+                    headers: {
+                        "Content-Type": "application/x-www-form-urlencoded",
+                        "X-CSRF-Token": csrfToken,
+                    },
+                    body: body,
+                });
+            })
+            .then((response) => {
+                if (!response.ok) {
+                    throw new Error(`Could not save the subscription (HTTP ${response.status}).`);
+                }
+                window.location.reload();
+            })
+            .catch((error) => {
+                console.error("Error enabling notifications:", error);
+                showError(error.message);
+                button.disabled = false;
+            });
+    });
+});